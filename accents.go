@@ -0,0 +1,192 @@
+// accents.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AccentsConfig enables distinct border colors per workspace or per output
+type AccentsConfig struct {
+	Mode       string `json:"mode"`       // "workspace", "output" or "" (disabled)
+	Workspaces int    `json:"workspaces"` // number of workspaces to set up rules for (Hyprland)
+}
+
+// accentSlots are palette colors used in turn, starting with the regular accent
+var accentSlots = []string{"color4", "color5", "color6", "color2", "color3", "color1",
+	"color12", "color13", "color14", "color10", "color11", "color9"}
+
+// accentColors returns n accent colors from the palette; when the slots run out,
+// further rounds are shaded towards the foreground
+func accentColors(p *ColorPalette, n int) []string {
+	var colors []string
+	for i := 0; i < n; i++ {
+		c := p.Colors[accentSlots[i%len(accentSlots)]]
+		if c == "" {
+			c = p.Colors["color4"]
+		}
+		if round := i / len(accentSlots); round > 0 {
+			c = mixColors(c, p.Foreground, 0.2*float64(round))
+		}
+		colors = append(colors, c)
+	}
+	return colors
+}
+
+// applyAccents sets up per-workspace accents in the running compositor. On sway this
+// keeps watching workspace focus events, so it must be run as a background process.
+func applyAccents(p *ColorPalette, ac *AccentsConfig) error {
+	if ac == nil || ac.Mode == "" {
+		return fmt.Errorf("accents are disabled, set \"accents\" in %s", colorSyncManager.configFile)
+	}
+	if ac.Mode != "workspace" && ac.Mode != "output" {
+		return fmt.Errorf("unknown accents mode '%s'", ac.Mode)
+	}
+	if _, skipHooks := activeSkips(colorSyncManager.config.Skip); skipHooks["accents"] {
+		log.Info("Accents are skipped on this host/session")
+		return nil
+	}
+	switch {
+	case os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		if ac.Mode == "output" {
+			return fmt.Errorf("per-output accents are not supported on Hyprland")
+		}
+		return applyHyprlandAccents(p, ac.Workspaces)
+	case os.Getenv("SWAYSOCK") != "":
+		return watchSwayAccents(p, ac.Mode)
+	}
+	return fmt.Errorf("accents need Hyprland or sway")
+}
+
+// applyHyprlandAccents writes window rules coloring borders by workspace between the nwg-look markers
+// of hyprland.conf, and has Hyprland reload them. Rules set with hyprctl keyword would pile up on each sync.
+func applyHyprlandAccents(p *ColorPalette, workspaces int) error {
+	if workspaces <= 0 {
+		workspaces = 10
+	}
+	var rules []string
+	for i, c := range accentColors(p, workspaces) {
+		rules = append(rules, fmt.Sprintf("windowrulev2 = bordercolor rgb(%s),workspace:%d",
+			strings.TrimPrefix(c, "#"), i+1))
+	}
+	path := filepath.Join(configHome(), "hypr", "hyprland.conf")
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	output, err := injectBetweenMarkers(path, existing, strings.Join(rules, "\n"))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if bytes.Equal(output, existing) {
+		log.Debugf("Hyprland border accents unchanged")
+		return nil
+	}
+	if err := writeFileAtomic(path, output, 0644); err != nil {
+		return err
+	}
+	out, err := exec.Command("hyprctl", "reload").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("hyprctl: %v %s", err, string(out))
+	}
+	log.Infof("Set border accents for %v Hyprland workspaces in %s", workspaces, path)
+	return nil
+}
+
+type swayWorkspaceEvent struct {
+	Change  string `json:"change"`
+	Current struct {
+		Num    int    `json:"num"`
+		Output string `json:"output"`
+	} `json:"current"`
+}
+
+// swayOutputs returns sorted output names, so that each output keeps its accent
+func swayOutputs() []string {
+	var outputs []struct {
+		Name string `json:"name"`
+	}
+	out, err := exec.Command("swaymsg", "-r", "-t", "get_outputs").Output()
+	if err == nil {
+		json.Unmarshal(out, &outputs)
+	}
+	var names []string
+	for _, o := range outputs {
+		names = append(names, o.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// swayAccent picks the accent for the focused workspace
+func swayAccent(colors []string, outputs []string, mode string, e swayWorkspaceEvent) string {
+	i := e.Current.Num - 1
+	if mode == "output" {
+		i = 0
+		for n, name := range outputs {
+			if name == e.Current.Output {
+				i = n
+			}
+		}
+	}
+	if i < 0 {
+		i = 0
+	}
+	return colors[i%len(colors)]
+}
+
+// watchSwayAccents recolors focused window borders whenever the workspace focus changes
+func watchSwayAccents(p *ColorPalette, mode string) error {
+	cmd := exec.Command("swaymsg", "-r", "-m", "-t", "subscribe", `["workspace"]`)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	log.Infof("Watching sway workspaces, accents by %s", mode)
+
+	colors := accentColors(p, len(accentSlots))
+	outputs := swayOutputs()
+	var configTime time.Time
+	if info, err := os.Stat(colorSyncManager.configFile); err == nil {
+		configTime = info.ModTime()
+	}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var e swayWorkspaceEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Change != "focus" {
+			continue
+		}
+		// follow palette changes made meanwhile
+		if info, err := os.Stat(colorSyncManager.configFile); err == nil && !info.ModTime().Equal(configTime) {
+			configTime = info.ModTime()
+			colorSyncManager.loadConfig()
+			if colorSyncManager.config.LastColors != nil {
+				p = colorSyncManager.config.LastColors
+				colors = accentColors(p, len(accentSlots))
+			}
+		}
+		if mode == "output" && !isIn(outputs, e.Current.Output) {
+			outputs = swayOutputs()
+		}
+		c := swayAccent(colors, outputs, mode, e)
+		// client.focused <border> <background> <text> <indicator> <child_border>
+		out, err := exec.Command("swaymsg", "client.focused", c, c, p.Background, c, c).CombinedOutput()
+		if err != nil {
+			log.Warnf("swaymsg: %v %s", err, string(out))
+		}
+	}
+	return cmd.Wait()
+}
@@ -0,0 +1,120 @@
+// accessibility.go
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// rgbaFunc matches rgba(r, g, b, a) as produced by the {name.rgb} placeholder
+var rgbaFunc = regexp.MustCompile(`rgba\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)\s*,\s*([\d.]+)\s*\)`)
+
+// opaqueLines force an opaque window background where the format can't be handled by rgbaFunc
+var opaqueLines = map[string]string{
+	"kitty.conf":           "background_opacity 1.0",
+	"foot.ini":             "[colors]\nalpha=1.0",
+	"alacritty.yml":        "window:\n  opacity: 1.0",
+	"ghostty":              "background-opacity = 1",
+	"hyprland-colors.conf": "decoration {\n    active_opacity = 1.0\n    inactive_opacity = 1.0\n    blur {\n        enabled = false\n    }\n}",
+}
+
+// reducedMotionLines turn animations off, for formats where it's possible
+var reducedMotionLines = map[string]string{
+	"hyprland-colors.conf": "animations {\n    enabled = false\n}",
+}
+
+// reducedMotionCSS stops GTK CSS transitions and animations, e.g. in waybar or nwg-panel
+const reducedMotionCSS = "* {\n    transition: none;\n    animation: none;\n}"
+
+// applyAccessibility adjusts a rendered template to the reduce-transparency preference
+// and the enable-animations gsetting
+func applyAccessibility(template, output string, palette *ColorPalette) string {
+	var extra []string
+	if preferences.ReduceTransparency {
+		output = opaqueColors(output, palette.Background)
+		if lines, ok := opaqueLines[template]; ok {
+			extra = append(extra, lines)
+		}
+	}
+	if !gsettings.enableAnimations {
+		if lines, ok := reducedMotionLines[template]; ok {
+			extra = append(extra, lines)
+		} else if isGtkCSSTemplate(template) {
+			extra = append(extra, reducedMotionCSS)
+		}
+	}
+	if len(extra) == 0 {
+		return output
+	}
+	header := "# Accessibility - Generated by nwg-look"
+	switch filepath.Ext(template) {
+	case ".css":
+		header = "/* Accessibility - Generated by nwg-look */"
+	case ".scss":
+		header = "// Accessibility - Generated by nwg-look"
+	}
+	return fmt.Sprintf("%s\n\n%s\n%s\n", strings.TrimRight(output, "\n"), header, strings.Join(extra, "\n"))
+}
+
+// isGtkCSSTemplate tells whether the template is a stylesheet of a GTK application;
+// Firefox's userChrome.css is left alone, as the browser has a setting of its own
+func isGtkCSSTemplate(template string) bool {
+	ext := filepath.Ext(template)
+	return (ext == ".css" || ext == ".scss") && template != "firefox-colors.css"
+}
+
+// opaqueColors replaces rgba() colors with the opaque color they give over the background
+func opaqueColors(output, background string) string {
+	return rgbaFunc.ReplaceAllStringFunc(output, func(match string) string {
+		m := rgbaFunc.FindStringSubmatch(match)
+		r, _ := strconv.Atoi(m[1])
+		g, _ := strconv.Atoi(m[2])
+		b, _ := strconv.Atoi(m[3])
+		alpha, err := strconv.ParseFloat(m[4], 64)
+		if err != nil || alpha > 1 {
+			return match
+		}
+		return mixColors(background, rgbToHex(r, g, b), alpha)
+	})
+}
+
+// accessibilityOptions returns the state of the toggles, as shown by `nwg-look accessibility show`
+func accessibilityOptions() map[string]bool {
+	return map[string]bool{
+		"reduce-motion":       !gsettings.enableAnimations,
+		"reduce-transparency": preferences.ReduceTransparency,
+	}
+}
+
+// setAccessibility turns a toggle on or off, and propagates it to GTK settings and generated configs
+func setAccessibility(option string, on bool) error {
+	switch option {
+	case "reduce-motion":
+		gsettings.enableAnimations = !on
+		applyGsettings()
+		saveGsettingsBackup()
+		if preferences.ExportSettingsIni {
+			saveGtkIni3()
+		}
+		if preferences.ExportXsettingsd {
+			saveXsettingsd()
+		}
+		if preferences.ExportGtk4Symlinks {
+			saveGtkIni4()
+		}
+	case "reduce-transparency":
+		preferences.ReduceTransparency = on
+		savePreferences()
+	default:
+		return fmt.Errorf("unknown option '%s', expected reduce-motion or reduce-transparency", option)
+	}
+
+	csm := colorSyncManager
+	if !csm.IsEnabled() || csm.config.LastColors == nil {
+		return nil
+	}
+	return csm.templates.ApplyColors(csm.config.LastColors, csm.config.Applications)
+}
@@ -0,0 +1,335 @@
+// appearanceimport.go
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DetectedAppearance holds colors and the font found in the user's existing configs
+type DetectedAppearance struct {
+	Palette  *ColorPalette `json:"palette"`
+	FontName string        `json:"font-name,omitempty"`
+	Sources  []string      `json:"sources"`
+}
+
+var (
+	configHexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	hyprHexColor   = regexp.MustCompile(`^rgba?\(([0-9a-fA-F]{6})([0-9a-fA-F]{2})?\)`)
+	argbHexColor   = regexp.MustCompile(`^0x[0-9a-fA-F]{2}([0-9a-fA-F]{6})$`)
+	rgbFuncColor   = regexp.MustCompile(`^rgba?\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)`)
+)
+
+// parseConfigColor converts colors as written in sway, Hyprland, waybar and kitty configs to #rrggbb.
+// Alpha is dropped: #rrggbbaa, rgba(rrggbbaa), 0xaarrggbb and rgba(r, g, b, a) are all accepted.
+func parseConfigColor(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if configHexColor.MatchString(value) {
+		r, g, b, err := hexToRGB(value)
+		if err != nil {
+			return "", false
+		}
+		return rgbToHex(r, g, b), true
+	}
+	if m := hyprHexColor.FindStringSubmatch(value); m != nil {
+		return strings.ToLower("#" + m[1]), true
+	}
+	if m := argbHexColor.FindStringSubmatch(value); m != nil {
+		return strings.ToLower("#" + m[1]), true
+	}
+	if m := rgbFuncColor.FindStringSubmatch(value); m != nil {
+		r, _ := strconv.Atoi(m[1])
+		g, _ := strconv.Atoi(m[2])
+		b, _ := strconv.Atoi(m[3])
+		return rgbToHex(r, g, b), true
+	}
+	return "", false
+}
+
+// appearanceSource is a config file and the parser reading palette slots and the font out of it
+type appearanceSource struct {
+	name  string
+	path  func() string
+	parse func(lines []string, dir string) (map[string]string, string)
+}
+
+// appearanceSources in order of precedence: terminal colors are the most complete, window borders give the accent
+var appearanceSources = []appearanceSource{
+	{"kitty", func() string { return filepath.Join(configHome(), "kitty/kitty.conf") }, parseKittyAppearance},
+	{"sway", func() string { return filepath.Join(configHome(), "sway/config") }, parseSwayAppearance},
+	{"Hyprland", func() string { return filepath.Join(configHome(), "hypr/hyprland.conf") }, parseHyprlandAppearance},
+	{"waybar", func() string { return filepath.Join(configHome(), "waybar/style.css") }, parseWaybarAppearance},
+}
+
+// detectAppearance reads the existing configs, and returns nil if no colors were found in any of them.
+// Slots not found are left with the generateStandardPalette defaults.
+func detectAppearance() *DetectedAppearance {
+	slots := make(map[string]string)
+	a := &DetectedAppearance{}
+	for _, src := range appearanceSources {
+		path := src.path()
+		lines, err := loadTextFile(path)
+		if err != nil {
+			continue
+		}
+		found, font := src.parse(lines, filepath.Dir(path))
+		if len(found) == 0 && font == "" {
+			continue
+		}
+		log.Debugf("Detected %v colors in %s, font: '%s'", len(found), path, font)
+		for slot, value := range found {
+			if _, ok := slots[slot]; !ok {
+				slots[slot] = value
+			}
+		}
+		if a.FontName == "" {
+			a.FontName = font
+		}
+		a.Sources = append(a.Sources, src.name)
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+
+	a.Palette = NewColorExtractor().generateStandardPalette(map[string]string{})
+	for slot, value := range slots {
+		a.Palette.setSlot(slot, value)
+	}
+	if _, ok := slots["cursor"]; !ok {
+		a.Palette.Cursor = a.Palette.Foreground
+	}
+	return a
+}
+
+// parseKittyAppearance reads kitty.conf and the files it includes, e.g. current-theme.conf written by `kitten themes`.
+// The font is not taken from here: it's a monospace one, not fit for GTK applications.
+func parseKittyAppearance(lines []string, dir string) (map[string]string, string) {
+	slots := make(map[string]string)
+	var parse func(lines []string, depth int)
+	parse = func(lines []string, depth int) {
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			key, value := fields[0], fields[1]
+			switch {
+			case key == "include" && depth < 3:
+				path := value
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(dir, path)
+				}
+				if included, err := loadTextFile(path); err == nil {
+					parse(included, depth+1)
+				}
+			case key == "background" || key == "foreground" || key == "cursor" || strings.HasPrefix(key, "color"):
+				if n, err := strconv.Atoi(strings.TrimPrefix(key, "color")); err == nil && (n < 0 || n > 15) {
+					continue
+				}
+				if color, ok := parseConfigColor(value); ok {
+					slots[key] = color
+				}
+			}
+		}
+	}
+	parse(lines, 0)
+	return slots, ""
+}
+
+// parseSwayAppearance reads window colors and the font from the sway config, resolving `set $name value` variables.
+// The focused border is the accent, the unfocused title bar gives the background and text colors.
+func parseSwayAppearance(lines []string, _ string) (map[string]string, string) {
+	vars := make(map[string]string)
+	slots := make(map[string]string)
+	font := ""
+	resolve := func(value string) (string, bool) {
+		if v, ok := vars[value]; ok {
+			value = v
+		}
+		return parseConfigColor(value)
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "set":
+			if len(fields) > 2 && strings.HasPrefix(fields[1], "$") {
+				vars[fields[1]] = strings.Join(fields[2:], " ")
+			}
+		case "font":
+			font = strings.TrimPrefix(strings.Join(fields[1:], " "), "pango:")
+		case "client.focused":
+			if c, ok := resolve(fields[1]); ok {
+				slots["color4"] = c
+			}
+		case "client.unfocused":
+			if len(fields) > 3 {
+				if c, ok := resolve(fields[2]); ok {
+					slots["background"] = c
+				}
+				if c, ok := resolve(fields[3]); ok {
+					slots["foreground"] = c
+				}
+			}
+			if c, ok := resolve(fields[1]); ok {
+				slots["color8"] = c
+			}
+		case "client.urgent":
+			if c, ok := resolve(fields[1]); ok {
+				slots["color1"] = c
+			}
+		}
+	}
+	return slots, font
+}
+
+// parseHyprlandAppearance reads border colors from hyprland.conf, resolving `$name = value` variables.
+// Gradients, e.g. `rgba(33ccffee) rgba(00ff99ee) 45deg`, give their first color.
+func parseHyprlandAppearance(lines []string, _ string) (map[string]string, string) {
+	vars := make(map[string]string)
+	slots := make(map[string]string)
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if strings.HasPrefix(key, "$") {
+			vars[key] = value
+			continue
+		}
+		if fields := strings.Fields(value); len(fields) > 0 {
+			value = fields[0]
+		}
+		if v, ok := vars[value]; ok && v != "" {
+			value = strings.Fields(v)[0]
+		}
+		color, ok := parseConfigColor(value)
+		if !ok {
+			continue
+		}
+		switch strings.TrimPrefix(key, "general:") {
+		case "col.active_border":
+			slots["color4"] = color
+		case "col.inactive_border":
+			slots["color8"] = color
+		}
+	}
+	return slots, ""
+}
+
+// waybarColorNames maps common @define-color names to palette slots
+var waybarColorNames = map[string]string{
+	"background": "background",
+	"bg":         "background",
+	"base":       "background",
+	"foreground": "foreground",
+	"fg":         "foreground",
+	"text":       "foreground",
+	"accent":     "color4",
+	"blue":       "color4",
+	"red":        "color1",
+	"green":      "color2",
+	"yellow":     "color3",
+	"magenta":    "color5",
+	"mauve":      "color5",
+	"cyan":       "color6",
+	"teal":       "color6",
+}
+
+var (
+	waybarDefineColor = regexp.MustCompile(`@define-color\s+([\w-]+)\s+([^;]+);`)
+	cssComment        = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssDeclaration    = regexp.MustCompile(`(?:^|[;{\s])(background-color|background|color|font-family)\s*:\s*([^;}]+)`)
+)
+
+// parseWaybarAppearance reads @define-color definitions and the bar colors and font from waybar's style.css
+func parseWaybarAppearance(lines []string, _ string) (map[string]string, string) {
+	defined := make(map[string]string)
+	slots := make(map[string]string)
+	font := ""
+	resolve := func(value string) (string, bool) {
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		if strings.HasPrefix(value, "@") {
+			value = defined[value[1:]]
+		}
+		return parseConfigColor(value)
+	}
+
+	css := cssComment.ReplaceAllString(strings.Join(lines, "\n"), "")
+	for _, m := range waybarDefineColor.FindAllStringSubmatch(css, -1) {
+		defined[m[1]] = strings.TrimSpace(m[2])
+		if slot, ok := waybarColorNames[strings.ToLower(m[1])]; ok {
+			if c, ok := resolve(m[2]); ok {
+				slots[slot] = c
+			}
+		}
+	}
+
+	// rules applying to the whole bar, e.g. `* { font-family: ... }` or `window#waybar { background: ... }`
+	for _, block := range strings.Split(css, "}") {
+		selector, body, ok := strings.Cut(block, "{")
+		if !ok {
+			continue
+		}
+		// @define-color statements may precede the first rule
+		selector = strings.TrimSpace(selector[strings.LastIndex(selector, ";")+1:])
+		if selector != "*" && selector != "window#waybar" {
+			continue
+		}
+		for _, m := range cssDeclaration.FindAllStringSubmatch(body, -1) {
+			switch m[1] {
+			case "font-family":
+				if font == "" {
+					family := strings.Split(m[2], ",")[0]
+					font = strings.Trim(strings.TrimSpace(family), `"'`)
+				}
+			case "color":
+				if c, ok := resolve(m[2]); ok {
+					slots["foreground"] = c
+				}
+			default:
+				if c, ok := resolve(m[2]); ok {
+					slots["background"] = c
+				}
+			}
+		}
+	}
+	return slots, font
+}
+
+// fontWithSize completes a detected font name with the size of the current one, if it has none
+func fontWithSize(font string) string {
+	if _, size := splitFontName(font); size > 0 {
+		return font
+	}
+	if _, size := splitFontName(gsettings.fontName); size > 0 {
+		return fmt.Sprintf("%s %v", font, size)
+	}
+	return font
+}
+
+// importAppearance applies the detected palette and font, and saves them as the "imported" profile
+func importAppearance(a *DetectedAppearance) error {
+	createRestorePoint("before importing the current appearance")
+	p := profileFromGsettings("imported")
+	if a.FontName != "" {
+		p.FontName = fontWithSize(a.FontName)
+	}
+	if err := saveProfile(p); err != nil {
+		return err
+	}
+	applyProfileSettings(p)
+	return colorSyncManager.ApplyPalette(a.Palette, "imported from "+strings.Join(a.Sources, ", "))
+}
@@ -0,0 +1,152 @@
+// appstatus.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AppOutcome is the result of the last apply to an application, kept in the manifest
+type AppOutcome struct {
+	Time  time.Time `json:"time"`
+	Files []string  `json:"files,omitempty"` // written
+	Error string    `json:"error,omitempty"` // the first failure
+}
+
+func (o *AppOutcome) fail(format string, args ...interface{}) {
+	if o.Error == "" {
+		o.Error = fmt.Sprintf(format, args...)
+	}
+}
+
+// application states shown as badges in the Color Sync tab, and by `nwg-look colors apps`
+const (
+	statusNever        = "never"        // not applied yet
+	statusOK           = "ok"           // written, and referenced by the application's config
+	statusSkipped      = "skipped"      // nothing written, e.g. the files were kept as the user's own
+	statusFailed       = "failed"       // the last apply failed
+	statusMissing      = "missing"      // written, but deleted since
+	statusUnreferenced = "unreferenced" // written, but the application's config doesn't include it
+)
+
+// AppStatus tells how the last apply went for an application, and whether its files are still in use
+type AppStatus struct {
+	State  string     `json:"state"`
+	Time   *time.Time `json:"time,omitempty"` // of the last apply
+	Detail string     `json:"detail,omitempty"`
+}
+
+// appReference is where an application is told to read the generated file; looked up by the file name
+// if "name" is empty. Applications reading their colors without any user setup are left out.
+type appReference struct {
+	configs []string // relative to configHome() unless starting with "~/", the first one found is checked
+	name    string
+}
+
+var appReferences = map[string]appReference{
+	"alacritty":   {[]string{"alacritty/alacritty.toml", "alacritty/alacritty.yml"}, ""},
+	"waybar":      {[]string{"waybar/style.css"}, ""},
+	"kitty":       {[]string{"kitty/kitty.conf"}, ""},
+	"rofi":        {[]string{"rofi/config.rasi"}, ""},
+	"foot":        {[]string{"foot/foot.ini"}, ""},
+	"wezterm":     {[]string{"wezterm/wezterm.lua"}, "nwg-look"},
+	"ghostty":     {[]string{"ghostty/config"}, "nwg-look"},
+	"zathura":     {[]string{"zathura/zathurarc"}, ""},
+	"mako":        {[]string{"mako/config"}, ""},
+	"swayosd":     {[]string{"swayosd/style.css"}, ""},
+	"fuzzel":      {[]string{"fuzzel/fuzzel.ini"}, ""},
+	"hyprland":    {[]string{"hypr/hyprland.conf"}, ""},
+	"hyprlock":    {[]string{"hypr/hyprlock.conf"}, ""},
+	"sway":        {[]string{"sway/config"}, ""},
+	"i3":          {[]string{"i3/config"}, ""},
+	"polybar":     {[]string{"polybar/config.ini", "polybar/config"}, ""},
+	"helix":       {[]string{"helix/config.toml"}, "nwg-look"},
+	"micro":       {[]string{"micro/settings.json"}, "nwg-look"},
+	"nano":        {[]string{"nano/nanorc"}, ""},
+	"zsh":         {[]string{"~/.zshrc", "zsh/.zshrc"}, ""},
+	"newsboat":    {[]string{"newsboat/config", "~/.newsboat/config"}, ""},
+	"qutebrowser": {[]string{"qutebrowser/config.py"}, "nwg-colors"},
+	"eww":         {[]string{"eww/eww.scss"}, "nwg-colors"},
+	"ags":         {[]string{"ags/style.scss"}, "nwg-colors"},
+}
+
+// isReferenced tells if one of the application's configs mentions the file, outside of comments.
+// It's a heuristic: an include line commented out in another way, or a file name used elsewhere, fools it.
+func isReferenced(app, path string) (bool, string) {
+	ref, ok := appReferences[app]
+	if !ok {
+		return true, ""
+	}
+	name := ref.name
+	if name == "" {
+		name = filepath.Base(strings.TrimSuffix(path, ".nwg-look"))
+	}
+	for _, config := range ref.configs {
+		configPath := expandPath(config)
+		if configPath == path {
+			return true, configPath // injected into the config itself
+		}
+		content, err := os.ReadFile(configPath)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "--") {
+				continue
+			}
+			if strings.Contains(line, name) {
+				return true, configPath
+			}
+		}
+		return false, configPath
+	}
+	return false, expandPath(ref.configs[0])
+}
+
+// appStatus checks the application's files against the outcome of the last apply
+func appStatus(manifest *Manifest, app string) AppStatus {
+	outcome, ok := manifest.Outcomes[app]
+	if !ok {
+		return AppStatus{State: statusNever}
+	}
+	status := AppStatus{Time: &outcome.Time}
+	switch {
+	case outcome.Error != "":
+		status.State, status.Detail = statusFailed, outcome.Error
+		return status
+	case len(outcome.Files) == 0:
+		status.State, status.Detail = statusSkipped, "no file written"
+		return status
+	}
+	for _, path := range outcome.Files {
+		if !pathExists(path) {
+			status.State, status.Detail = statusMissing, fmt.Sprintf("%s deleted", path)
+			return status
+		}
+	}
+	for _, path := range outcome.Files {
+		if ok, config := isReferenced(app, path); !ok {
+			status.State, status.Detail = statusUnreferenced, fmt.Sprintf("%s doesn't include %s", config, filepath.Base(path))
+			if !pathExists(config) {
+				status.Detail = fmt.Sprintf("no %s to include %s", config, filepath.Base(path))
+			}
+			return status
+		}
+	}
+	status.State, status.Detail = statusOK, strings.Join(outcome.Files, "\n")
+	return status
+}
+
+// appStatuses returns the state of all applications, read from the manifest once
+func appStatuses() map[string]AppStatus {
+	manifest := loadManifest()
+	statuses := make(map[string]AppStatus)
+	for _, app := range colorApps() {
+		statuses[app] = appStatus(manifest, app)
+	}
+	return statuses
+}
@@ -0,0 +1,27 @@
+// assets.go
+package main
+
+import (
+	"fmt"
+	"os/exec"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// kinds of assets that may be installed by the user
+const (
+	assetFont = "font"
+)
+
+// refreshFontCache rescans a font directory with fontconfig
+func refreshFontCache(fontDir string) error {
+	if _, err := exec.LookPath("fc-cache"); err != nil {
+		return fmt.Errorf("fc-cache not found")
+	}
+	out, err := exec.Command("fc-cache", "-f", fontDir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("fc-cache %s: %s", fontDir, string(out))
+	}
+	log.Infof("Refreshed font cache: %s", fontDir)
+	return nil
+}
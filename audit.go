@@ -0,0 +1,142 @@
+// audit.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// drift statuses
+const (
+	auditOK      = "ok"
+	auditMissing = "missing"
+	auditChanged = "changed"
+)
+
+// AuditItem describes the difference between a managed file or setting and the configuration
+type AuditItem struct {
+	Kind    string `json:"kind"`   // "colors" or "gsettings"
+	Target  string `json:"target"` // file path or gsettings key
+	Status  string `json:"status"`
+	Current string `json:"current,omitempty"`
+	Wanted  string `json:"wanted,omitempty"`
+}
+
+// auditColors compares enabled color sync destinations with the rendered templates
+func auditColors() []AuditItem {
+	palette := colorSyncManager.config.LastColors
+	if palette == nil {
+		var err error
+		palette, err = colorSyncManager.extractor.ExtractColors(gsettings.gtkTheme)
+		if err != nil {
+			log.Warnf("Audit: no palette to compare color files with: %v", err)
+			return nil
+		}
+	}
+	return compareColorFiles(palette)
+}
+
+// compareColorFiles compares the destinations color sync would write with the templates rendered for the palette
+func compareColorFiles(palette *ColorPalette) []AuditItem {
+	var items []AuditItem
+	tm := colorSyncManager.templates
+	skipApps, _ := activeSkips(tm.skip)
+	for _, t := range colorTargets {
+		if !colorSyncManager.IsAppEnabled(t.app) || skipApps[t.app] || skipApps[t.template] || tm.templateOff(t) {
+			continue
+		}
+		rendered, ok := tm.render(t, palette)
+		if !ok {
+			continue
+		}
+		opts := tm.destinations[t.template]
+		path := t.destPath(opts)
+		if path == "" {
+			continue
+		}
+		item := AuditItem{Kind: "colors", Target: path, Status: auditOK}
+		wanted, err := t.outputFor(path, rendered, opts)
+		if err != nil {
+			log.Warnf("Audit: %s: %v", path, err)
+			continue
+		}
+
+		current, err := os.ReadFile(path)
+		if err != nil {
+			item.Status = auditMissing
+			items = append(items, item)
+			continue
+		}
+		if !bytes.Equal(current, wanted) {
+			item.Status = auditChanged
+			items = append(items, item)
+			continue
+		}
+		if mode, err := opts.fileMode(); err == nil && opts != nil && opts.Mode != "" {
+			if info, err := os.Stat(path); err == nil && info.Mode().Perm() != mode {
+				item.Status = auditChanged
+				item.Current = fmt.Sprintf("mode %04o", info.Mode().Perm())
+				item.Wanted = fmt.Sprintf("mode %04o", mode)
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// auditGsettings compares the live gsettings with the backup that "nwg-look -a" would apply
+func auditGsettings() []AuditItem {
+	var items []AuditItem
+	gsettingsFile := filepath.Join(stateDir(), "gsettings")
+	lines, err := loadTextFile(gsettingsFile)
+	if err != nil {
+		log.Warnf("Audit: %v", err)
+		return items
+	}
+	for _, line := range lines {
+		parts := strings.Split(line, "=")
+		if strings.HasPrefix(line, "#") || len(parts) != 2 {
+			continue
+		}
+		key, wanted := parts[0], parts[1]
+		schema := "org.gnome.desktop.interface"
+		if key == "event-sounds" || key == "input-feedback-sounds" {
+			schema = "org.gnome.desktop.sound"
+		}
+		item := AuditItem{Kind: "gsettings", Target: key, Status: auditOK}
+		current, err := getGsettingsValue(schema, key)
+		if err != nil {
+			item.Status = auditMissing
+		} else if current != wanted {
+			item.Status = auditChanged
+			item.Current = current
+			item.Wanted = wanted
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// audit prints what would change to match the configuration, without writing anything.
+// Returns the number of drifted items.
+func audit() int {
+	items := append(auditGsettings(), auditColors()...)
+	drifted := 0
+	for _, item := range items {
+		if item.Status != auditOK {
+			drifted++
+		}
+		line := fmt.Sprintf("%-8s %-10s %s", item.Status, item.Kind, item.Target)
+		if item.Current != "" || item.Wanted != "" {
+			line += fmt.Sprintf(": %s -> %s", item.Current, item.Wanted)
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("%v of %v items differ from the configuration\n", drifted, len(items))
+	return drifted
+}
@@ -0,0 +1,260 @@
+// backups.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Each apply run copies the files it's about to change into a backup set of its own, so that a run gone
+// wrong may be undone. Files the run creates are listed too, to be removed on restore.
+
+// defaultBackupSets is the number of backup sets kept if color-sync.json doesn't say, older ones are deleted
+const defaultBackupSets = 5
+
+// BackupFile is a destination as it was before the run; File is the copy's name in the set, empty if
+// the run created the destination. Hash is the manifest entry of the destination, empty if it had none.
+type BackupFile struct {
+	File string `json:"file,omitempty"`
+	Hash string `json:"hash,omitempty"`
+}
+
+// BackupSet holds the files of one apply run, keyed by destination path
+type BackupSet struct {
+	ID      string                 `json:"id"`
+	Created time.Time              `json:"created"`
+	Files   map[string]*BackupFile `json:"files"`
+}
+
+func backupsDir() string {
+	return filepath.Join(stateDir(), "backups")
+}
+
+func (b *BackupSet) dir() string {
+	return filepath.Join(backupsDir(), b.ID)
+}
+
+func newBackupSet() *BackupSet {
+	now := time.Now()
+	return &BackupSet{ID: now.Format("20060102-150405.000"), Created: now, Files: make(map[string]*BackupFile)}
+}
+
+// add copies the destination into the set, before its first write in the run
+func (b *BackupSet) add(path string, manifest *Manifest) error {
+	if _, ok := b.Files[path]; ok {
+		return nil
+	}
+	entry := &BackupFile{Hash: manifest.Files[path]}
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		b.Files[path] = entry
+		return nil
+	case err != nil:
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(b.dir(), 0700); err != nil {
+		return err
+	}
+	entry.File = fmt.Sprintf("%d-%s", len(b.Files), filepath.Base(path))
+	if err := os.WriteFile(filepath.Join(b.dir(), entry.File), data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	b.Files[path] = entry
+	return nil
+}
+
+// save writes the index of the set, if anything was added, and deletes the sets beyond keep
+func (b *BackupSet) save(keep int) error {
+	if len(b.Files) == 0 {
+		return nil
+	}
+	if keep <= 0 {
+		keep = defaultBackupSets
+	}
+	if err := os.MkdirAll(b.dir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(b.dir(), "index.json"), data, 0600); err != nil {
+		return err
+	}
+	sets := listBackupSets()
+	for _, old := range sets[min(len(sets), keep):] {
+		os.RemoveAll(old.dir())
+	}
+	return nil
+}
+
+// listBackupSets returns backup sets, newest first
+func listBackupSets() []*BackupSet {
+	var sets []*BackupSet
+	files, err := listFiles(backupsDir())
+	if err != nil {
+		return sets
+	}
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(backupsDir(), f.Name(), "index.json"))
+		if err != nil {
+			continue
+		}
+		b := &BackupSet{}
+		if err := json.Unmarshal(data, b); err != nil || b.ID != f.Name() {
+			log.Warnf("Skipping invalid backup set %s", f.Name())
+			continue
+		}
+		sets = append(sets, b)
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].Created.After(sets[j].Created)
+	})
+	return sets
+}
+
+// findBackupSet returns the set of the given id, or the latest one if id is empty
+func findBackupSet(id string) (*BackupSet, error) {
+	sets := listBackupSets()
+	if id == "" {
+		if len(sets) == 0 {
+			return nil, fmt.Errorf("no backups yet")
+		}
+		return sets[0], nil
+	}
+	for _, b := range sets {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("no backup set '%s'", id)
+}
+
+// paths returns the destination paths of the set, sorted
+func (b *BackupSet) paths() []string {
+	var paths []string
+	for path := range b.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// restore puts the files back as they were before the run, removes the ones it created, and brings back
+// their manifest entries. Applications aren't reloaded.
+func (b *BackupSet) restore() ([]string, error) {
+	log.Infof(">>> Restoring files from before %s", b.Created.Format("2006-01-02 15:04:05"))
+	manifest := loadManifest()
+	var restored, failed []string
+	for _, path := range b.paths() {
+		if err := b.restoreFile(path); err != nil {
+			log.Warnf("Failed to restore %s: %v", path, err)
+			failed = append(failed, path)
+			continue
+		}
+		if hash := b.Files[path].Hash; hash == "" {
+			delete(manifest.Files, path)
+		} else {
+			manifest.Files[path] = hash
+		}
+		log.Infof("✓ Restored %s", path)
+		restored = append(restored, path)
+	}
+	if err := manifest.save(); err != nil {
+		log.Warnf("Failed to save %s: %v", manifestFile(), err)
+	}
+	if len(failed) > 0 {
+		return restored, fmt.Errorf("failed to restore %s", strings.Join(failed, ", "))
+	}
+	return restored, nil
+}
+
+// restoreFile puts one destination back as it was, or removes it if the run created it
+func (b *BackupSet) restoreFile(path string) error {
+	entry, ok := b.Files[path]
+	if !ok {
+		return fmt.Errorf("%s not in the backup set", path)
+	}
+	if entry.File == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	backup := filepath.Join(b.dir(), entry.File)
+	data, err := os.ReadFile(backup)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(backup)
+	if err != nil {
+		return err
+	}
+	makeDir(filepath.Dir(path))
+	if err := writeFileAtomic(path, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chmod(path, info.Mode().Perm())
+}
+
+// discard deletes the set, e.g. after its files were rolled back
+func (b *BackupSet) discard() {
+	os.RemoveAll(b.dir())
+}
+
+// writeFileAtomic writes into a temporary file next to path, then renames it over path, so that readers
+// never see a half-written file. Symlinks are followed, so that dotfile managers' links stay in place.
+// An existing file keeps its mode and ownership, mode is for new files.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	uid, gid := -1, -1
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			uid, gid = int(st.Uid), int(st.Gid)
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if uid != -1 && (uid != os.Getuid() || gid != os.Getgid()) {
+		if err := os.Chown(tmp.Name(), uid, gid); err != nil {
+			log.Debugf("Couldn't keep the ownership of %s: %v", path, err)
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
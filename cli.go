@@ -0,0 +1,768 @@
+// cli.go
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Exit codes of the command line interface
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+type cliCommand struct {
+	usage string
+	run   func(args []string) error
+}
+
+// cliCommands is the scripting interface, e.g. for nwg-shell-config. Results are printed
+// to stdout as JSON, errors to stderr. Keep the output format backward compatible.
+var cliCommands = map[string]map[string]cliCommand{
+	"colors": {
+		"extract":     {"[theme]", cliColorsExtract},
+		"extract-all": {"[--format csv|json]", cliColorsExtractAll},
+		"apply":       {"[theme] [--dry-run]", cliColorsApply},
+		"palette":     {"", cliColorsPalette},
+		"apps":        {"", cliColorsApps},
+		"enable":      {"<app>", cliColorsEnable},
+		"disable":     {"<app>", cliColorsDisable},
+		"import":      {"<file>", cliColorsImport},
+		"export-gtk":  {"", cliColorsExportGtk},
+		"accents":     {"", cliColorsAccents},
+		"pipelines":   {"", cliColorsPipelines},
+		"pipeline":    {"<name>", cliColorsPipeline},
+		"detect":      {"[--apply]", cliColorsDetect},
+		"copy":        {"<slot|all> [format]", cliColorsCopy},
+		"consent":     {"<app> <always|ask|never|reset>", cliColorsConsent},
+		"set":         {"<slot> <color>", cliColorsSet},
+		"chrome":      {"", cliColorsChrome},
+		"opacity":     {"<app> <0.1-1|reset>", cliColorsOpacity},
+	},
+	"console": {
+		"install":   {"", cliConsoleInstall},
+		"uninstall": {"", cliConsoleUninstall},
+	},
+	"greetd": {
+		"export":  {"[dir]", cliGreetdExport},
+		"install": {"", cliGreetdInstall},
+		"sync":    {"<on|off>", cliGreetdSync},
+	},
+	"sddm": {
+		"export":  {"[file]", cliSddmExport},
+		"install": {"[theme]", cliSddmInstall},
+		"sync":    {"<on|off> [file]", cliSddmSync},
+	},
+	"profile": {
+		"list":      {"", cliProfileList},
+		"show":      {"<name>", cliProfileShow},
+		"diff":      {"<name>", cliProfileDiff},
+		"save":      {"<name>", cliProfileSave},
+		"apply":     {"<name>", cliProfileApply},
+		"wallpaper": {"<name> [path] [command]", cliProfileWallpaper},
+	},
+	"templates": {
+		"index":    {"", cliTemplatesIndex},
+		"preview":  {"<name>", cliTemplatesPreview},
+		"install":  {"<name>", cliTemplatesInstall},
+		"update":   {"[name]", cliTemplatesUpdate},
+		"remove":   {"<name>", cliTemplatesRemove},
+		"pack":     {"<url|path>", cliTemplatesPack},
+		"where":    {"<name>", cliTemplatesWhere},
+		"edit":     {"<name>", cliTemplatesEdit},
+		"reset":    {"<name>", cliTemplatesReset},
+		"export":   {"<dir>", cliTemplatesExport},
+		"disable":  {"<name>", cliTemplatesDisable},
+		"enable":   {"<name>", cliTemplatesEnable},
+		"defaults": {"<on|off>", cliTemplatesDefaults},
+	},
+	"accessibility": {
+		"show": {"", cliAccessibilityShow},
+		"set":  {"<option> <on|off>", cliAccessibilitySet},
+	},
+	"theme": {
+		"lint": {"<theme|dir>", cliThemeLint},
+	},
+	"config": {
+		"validate": {"[file]", cliConfigValidate},
+		"schema":   {"[color-sync|profile]", cliConfigSchema},
+	},
+	"restore": {
+		"list":  {"", cliRestoreList},
+		"apply": {"<id>", cliRestoreApply},
+	},
+	"backups": {
+		"list":    {"", cliBackupsList},
+		"restore": {"[id]", cliBackupsRestore},
+	},
+}
+
+// runCommand executes a subcommand and returns the exit code
+func runCommand(args []string) int {
+	group, ok := cliCommands[args[0]]
+	if !ok || len(args) < 2 {
+		printUsage(args[0])
+		return exitUsage
+	}
+	cmd, ok := group[args[1]]
+	if !ok {
+		printUsage(args[0])
+		return exitUsage
+	}
+	params := args[2:]
+	maxArgs := len(strings.Fields(cmd.usage))
+	if len(params) > maxArgs || strings.HasPrefix(cmd.usage, "<") && len(params) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: nwg-look %s %s %s\n", args[0], args[1], cmd.usage)
+		return exitUsage
+	}
+	if err := cmd.run(params); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// printUsage lists subcommands of the group, or all of them if the group is unknown
+func printUsage(group string) {
+	var groups []string
+	if _, ok := cliCommands[group]; ok {
+		groups = []string{group}
+	} else {
+		for name := range cliCommands {
+			groups = append(groups, name)
+		}
+		sort.Strings(groups)
+	}
+	fmt.Fprintln(os.Stderr, "usage:")
+	for _, name := range groups {
+		var subs []string
+		for sub := range cliCommands[name] {
+			subs = append(subs, sub)
+		}
+		sort.Strings(subs)
+		for _, sub := range subs {
+			fmt.Fprintf(os.Stderr, "  nwg-look %s %s %s\n", name, sub, cliCommands[name][sub].usage)
+		}
+	}
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// argOr returns the first argument, or the fallback if there's none
+func argOr(args []string, fallback string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return fallback
+}
+
+func cliColorsExtract(args []string) error {
+	palette, err := colorSyncManager.extractor.ExtractColors(argOr(args, gsettings.gtkTheme))
+	if err != nil {
+		return err
+	}
+	return printJSON(palette)
+}
+
+// cliColorsExtractAll prints a matrix of palettes of all installed themes, one row per theme
+func cliColorsExtractAll(args []string) error {
+	fs := flag.NewFlagSet("extract-all", flag.ContinueOnError)
+	format := fs.String("format", "json", "output format: csv or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format '%s'", *format)
+	}
+
+	names, _ := getThemeNames()
+	sort.Strings(names)
+	columns := paletteColumns()
+	var rows [][]string
+	for _, name := range names {
+		palette, err := colorSyncManager.extractor.cachedExtract(name)
+		if err != nil {
+			log.Warnf("Skipping %s: %v", name, err)
+			continue
+		}
+		row := []string{name}
+		for _, column := range columns {
+			row = append(row, palette.slot(column))
+		}
+		rows = append(rows, row)
+	}
+
+	if *format == "csv" {
+		w := csv.NewWriter(os.Stdout)
+		w.Write(append([]string{"theme"}, columns...))
+		w.WriteAll(rows)
+		return w.Error()
+	}
+	matrix := []map[string]string{}
+	for _, row := range rows {
+		entry := map[string]string{"theme": row[0]}
+		for i, column := range columns {
+			entry[column] = row[i+1]
+		}
+		matrix = append(matrix, entry)
+	}
+	return printJSON(matrix)
+}
+
+// cliColorsApply applies the theme's colors, or with --dry-run prints the diffs it would make
+func cliColorsApply(args []string) error {
+	dryRun := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--dry-run" {
+			dryRun = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	themeName := argOr(rest, gsettings.gtkTheme)
+	if !colorSyncManager.IsEnabled() {
+		return fmt.Errorf("color sync is disabled")
+	}
+	if dryRun {
+		return colorSyncManager.DryRunTheme(themeName, os.Stdout)
+	}
+	return colorSyncManager.ApplyTheme(themeName)
+}
+
+func cliColorsPalette(args []string) error {
+	if colorSyncManager.config.LastColors == nil {
+		return fmt.Errorf("no palette applied yet")
+	}
+	return printJSON(colorSyncManager.config.LastColors)
+}
+
+func cliColorsApps(args []string) error {
+	type app struct {
+		Name      string    `json:"name"`
+		Enabled   bool      `json:"enabled"`
+		Consent   string    `json:"consent,omitempty"`
+		Templates []string  `json:"templates"`
+		Status    AppStatus `json:"status"`
+	}
+	apps := []app{}
+	statuses := appStatuses()
+	for _, name := range colorSyncManager.GetApplications() {
+		var templates []string
+		for _, t := range appTemplates(name) {
+			templates = append(templates, t.template)
+		}
+		apps = append(apps, app{name, colorSyncManager.IsAppEnabled(name), colorSyncManager.config.Consent[name], templates, statuses[name]})
+	}
+	return printJSON(apps)
+}
+
+func setAppEnabledChecked(name string, enabled bool) error {
+	if !isIn(colorSyncManager.GetApplications(), name) {
+		return fmt.Errorf("unknown application '%s'", name)
+	}
+	colorSyncManager.SetAppEnabled(name, enabled)
+	return nil
+}
+
+func cliColorsEnable(args []string) error {
+	return setAppEnabledChecked(args[0], true)
+}
+
+func cliColorsDisable(args []string) error {
+	return setAppEnabledChecked(args[0], false)
+}
+
+// cliColorsSet changes one color of the last palette, rewriting only the targets using it
+func cliColorsSet(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: nwg-look colors set <slot> <color>")
+	}
+	if err := colorSyncManager.SetPaletteColor(args[0], args[1]); err != nil {
+		return err
+	}
+	return printJSON(colorSyncManager.config.LastColors)
+}
+
+func cliColorsOpacity(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: nwg-look colors opacity <app> <0.1-1|reset>")
+	}
+	if err := colorSyncManager.SetOpacity(args[0], args[1]); err != nil {
+		return err
+	}
+	return printJSON(map[string]interface{}{"app": args[0], "opacity": colorSyncManager.Opacity(args[0])})
+}
+
+func cliColorsConsent(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: nwg-look colors consent <app> <always|ask|never|reset>")
+	}
+	return colorSyncManager.SetConsent(args[0], args[1])
+}
+
+func cliColorsImport(args []string) error {
+	palette, err := ImportPalette(args[0])
+	if err != nil {
+		return err
+	}
+	return colorSyncManager.ApplyPalette(palette, args[0])
+}
+
+// cliColorsCopy copies a color of the last palette to the clipboard, or all of them to cliphist
+func cliColorsCopy(args []string) error {
+	if colorSyncManager.config.LastColors == nil {
+		return fmt.Errorf("no palette applied yet")
+	}
+	value, err := copyPaletteColor(colorSyncManager.config.LastColors, args[0], argOr(args[1:], "hex"))
+	if err != nil || value == "" {
+		return err
+	}
+	return printJSON(value)
+}
+
+func cliColorsExportGtk(args []string) error {
+	return ExportGtkColors(colorSyncManager.config.LastColors)
+}
+
+func cliColorsAccents(args []string) error {
+	if colorSyncManager.config.LastColors == nil {
+		return fmt.Errorf("no palette applied yet")
+	}
+	return applyAccents(colorSyncManager.config.LastColors, colorSyncManager.config.Accents)
+}
+
+func cliColorsPipelines(args []string) error {
+	pipelines := colorSyncManager.config.Pipelines
+	if pipelines == nil {
+		pipelines = map[string][]string{}
+	}
+	return printJSON(pipelines)
+}
+
+func cliColorsPipeline(args []string) error {
+	palette, err := colorSyncManager.runPipeline(args[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(palette)
+}
+
+// cliColorsDetect prints colors and the font found in sway, Hyprland, waybar and kitty configs; --apply imports them
+func cliColorsDetect(args []string) error {
+	a := detectAppearance()
+	if a == nil {
+		return fmt.Errorf("no colors found in sway, Hyprland, waybar or kitty configs")
+	}
+	if argOr(args, "") == "--apply" {
+		if err := importAppearance(a); err != nil {
+			return err
+		}
+	} else if len(args) > 0 {
+		return fmt.Errorf("unknown option '%s'", args[0])
+	}
+	return printJSON(a)
+}
+
+func cliProfileList(args []string) error {
+	profiles := listProfiles()
+	if profiles == nil {
+		profiles = []string{}
+	}
+	return printJSON(profiles)
+}
+
+func cliProfileShow(args []string) error {
+	p, err := loadProfile(args[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(p)
+}
+
+func cliProfileDiff(args []string) error {
+	p, err := resolveProfile(args[0])
+	if err != nil {
+		return err
+	}
+	items := diffProfile(p)
+	if items == nil {
+		items = []AuditItem{}
+	}
+	return printJSON(items)
+}
+
+func cliProfileSave(args []string) error {
+	return saveProfile(profileFromGsettings(args[0]))
+}
+
+func cliProfileApply(args []string) error {
+	p, err := resolveProfile(args[0])
+	if err != nil {
+		return err
+	}
+	if err := applyProfile(p); err != nil {
+		return err
+	}
+	log.Infof("Profile '%s' applied", p.Name)
+	time.Sleep(time.Second)
+	if msg := verifyTheme(); msg != "" {
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
+
+// cliProfileWallpaper sets the wallpaper of a profile, or removes it if no path is given
+func cliProfileWallpaper(args []string) error {
+	path, command := "", ""
+	if len(args) > 1 {
+		path = args[1]
+	}
+	if len(args) > 2 {
+		command = args[2]
+	}
+	if path != "" && !strings.HasPrefix(path, "~/") {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		path = abs
+	}
+	return setProfileWallpaper(args[0], path, command)
+}
+
+// cliColorsChrome tells how to load the generated Chrome theme, which browsers only read when (re)loaded
+func cliColorsChrome(args []string) error {
+	dir := filepath.Dir(targetPath("chrome-theme.json"))
+	if !pathExists(dir) {
+		return fmt.Errorf("%s not found: enable the chrome target and apply colors first", dir)
+	}
+	return printJSON(struct {
+		Dir   string   `json:"dir"`
+		Flag  string   `json:"flag"`
+		Steps []string `json:"steps"`
+	}{dir, "--load-extension=" + dir, []string{
+		"Open chrome://extensions and turn on Developer mode",
+		"Press \"Load unpacked\" and select " + dir,
+		"After applying new colors, press the reload button of the nwg-look theme, or start the browser with the flag",
+	}})
+}
+
+func cliConsoleInstall(args []string) error {
+	return installVtrgb()
+}
+
+func cliConsoleUninstall(args []string) error {
+	return uninstallVtrgb()
+}
+
+func cliGreetdExport(args []string) error {
+	paths, err := exportGreetd(argOr(args, filepath.Join(cacheDir(), "greetd")))
+	if err != nil {
+		return err
+	}
+	return printJSON(paths)
+}
+
+func cliGreetdInstall(args []string) error {
+	return installGreetd()
+}
+
+func cliGreetdSync(args []string) error {
+	if len(args) != 1 || args[0] != "on" && args[0] != "off" {
+		return fmt.Errorf("usage: nwg-look greetd sync <on|off>")
+	}
+	colorSyncManager.SetGreetdSync(args[0] == "on")
+	return nil
+}
+
+func cliSddmExport(args []string) error {
+	path, err := filepath.Abs(argOr(args, filepath.Join(cacheDir(), "sddm", sddmUserConf)))
+	if err != nil {
+		return err
+	}
+	if err := exportSddm(path); err != nil {
+		return err
+	}
+	return printJSON(path)
+}
+
+func cliSddmInstall(args []string) error {
+	return installSddm(argOr(args, ""))
+}
+
+func cliSddmSync(args []string) error {
+	if len(args) < 1 || len(args) > 2 || args[0] != "on" && args[0] != "off" {
+		return fmt.Errorf("usage: nwg-look sddm sync <on|off> [file]")
+	}
+	path := argOr(args[1:], "")
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		path = abs
+	}
+	colorSyncManager.SetSddmSync(args[0] == "on", path)
+	return nil
+}
+
+func cliRestoreList(args []string) error {
+	type entry struct {
+		ID       string    `json:"id"`
+		Name     string    `json:"name"`
+		Created  time.Time `json:"created"`
+		GtkTheme string    `json:"gtk-theme"`
+	}
+	entries := []entry{}
+	for _, rp := range listRestorePoints() {
+		entries = append(entries, entry{rp.ID, rp.Name, rp.Created, rp.Settings.GtkTheme})
+	}
+	return printJSON(entries)
+}
+
+func cliRestoreApply(args []string) error {
+	rp, err := findRestorePoint(args[0])
+	if err != nil {
+		return err
+	}
+	return rp.restore()
+}
+
+func cliBackupsList(args []string) error {
+	type entry struct {
+		ID      string    `json:"id"`
+		Created time.Time `json:"created"`
+		Files   []string  `json:"files"`
+	}
+	entries := []entry{}
+	for _, b := range listBackupSets() {
+		entries = append(entries, entry{b.ID, b.Created, b.paths()})
+	}
+	return printJSON(entries)
+}
+
+// cliBackupsRestore puts back the files overwritten by an apply run, the latest if no id given
+func cliBackupsRestore(args []string) error {
+	b, err := findBackupSet(argOr(args, ""))
+	if err != nil {
+		return err
+	}
+	restored, err := b.restore()
+	if restored == nil {
+		restored = []string{}
+	}
+	if perr := printJSON(restored); err == nil {
+		err = perr
+	}
+	return err
+}
+
+func cliTemplatesIndex(args []string) error {
+	index, err := colorSyncManager.fetchTemplateIndex()
+	if err != nil {
+		return err
+	}
+	statuses := templateStatuses(index)
+	if statuses == nil {
+		statuses = []TemplateStatus{}
+	}
+	return printJSON(statuses)
+}
+
+// cliTemplatesPreview prints the template content, verified against the index checksum
+func cliTemplatesPreview(args []string) error {
+	index, err := colorSyncManager.fetchTemplateIndex()
+	if err != nil {
+		return err
+	}
+	t, err := findIndexTemplate(index, args[0])
+	if err != nil {
+		return err
+	}
+	content, err := downloadTemplate(t)
+	if err != nil {
+		return err
+	}
+	fmt.Print(content)
+	return nil
+}
+
+func cliTemplatesInstall(args []string) error {
+	index, err := colorSyncManager.fetchTemplateIndex()
+	if err != nil {
+		return err
+	}
+	t, err := findIndexTemplate(index, args[0])
+	if err != nil {
+		return err
+	}
+	return colorSyncManager.installCommunityTemplate(t)
+}
+
+// cliTemplatesUpdate updates installed community templates, or the given one, and prints their names
+func cliTemplatesUpdate(args []string) error {
+	index, err := colorSyncManager.fetchTemplateIndex()
+	if err != nil {
+		return err
+	}
+	updated, err := colorSyncManager.updateCommunityTemplates(index, argOr(args, ""))
+	if updated == nil {
+		updated = []string{}
+	}
+	if err != nil {
+		return err
+	}
+	return printJSON(updated)
+}
+
+func cliTemplatesRemove(args []string) error {
+	return colorSyncManager.removeCommunityTemplate(args[0])
+}
+
+// cliTemplatesPack installs a template pack, and prints the names of the installed templates
+func cliTemplatesPack(args []string) error {
+	names, err := colorSyncManager.installTemplatePack(args[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(names)
+}
+
+// cliTemplatesWhere prints the file a template is rendered from, or "built-in"
+func cliTemplatesWhere(args []string) error {
+	for _, t := range colorTargets {
+		if t.template == args[0] {
+			_, source, err := colorSyncManager.templates.templateContent(t)
+			if err != nil {
+				return err
+			}
+			return printJSON(source)
+		}
+	}
+	return fmt.Errorf("unknown template '%s'", args[0])
+}
+
+// cliTemplatesEdit copies a default template into the config dir, and prints its path
+func cliTemplatesEdit(args []string) error {
+	path, err := colorSyncManager.templates.overrideTemplate(args[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(path)
+}
+
+func cliTemplatesReset(args []string) error {
+	return colorSyncManager.templates.resetTemplate(args[0])
+}
+
+func cliTemplatesDisable(args []string) error {
+	return colorSyncManager.SetTemplateEnabled(args[0], false)
+}
+
+func cliTemplatesEnable(args []string) error {
+	return colorSyncManager.SetTemplateEnabled(args[0], true)
+}
+
+// cliTemplatesDefaults turns rendering the default templates on or off; off, only the user's own are rendered
+func cliTemplatesDefaults(args []string) error {
+	if args[0] != "on" && args[0] != "off" {
+		return fmt.Errorf("usage: nwg-look templates defaults <on|off>")
+	}
+	return colorSyncManager.SetUserTemplatesOnly(args[0] == "off")
+}
+
+// cliTemplatesExport writes the built-in templates, for packagers to install as the system defaults
+func cliTemplatesExport(args []string) error {
+	paths, err := colorSyncManager.templates.exportDefaultTemplates(args[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(paths)
+}
+
+func cliAccessibilityShow(args []string) error {
+	return printJSON(accessibilityOptions())
+}
+
+func cliAccessibilitySet(args []string) error {
+	if len(args) != 2 || args[1] != "on" && args[1] != "off" {
+		return fmt.Errorf("usage: nwg-look accessibility set <option> <on|off>")
+	}
+	return setAccessibility(args[0], args[1] == "on")
+}
+
+// cliConfigValidate checks color-sync.json and all profiles, or the given file
+func cliConfigValidate(args []string) error {
+	files := configFiles()
+	if len(args) > 0 {
+		files = map[string]string{args[0]: configKind(args[0])}
+	}
+	issues := []ConfigIssue{}
+	var paths []string
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if len(args) == 0 && !pathExists(path) {
+			continue
+		}
+		found, err := validateConfigFile(path, files[path])
+		if err != nil {
+			return err
+		}
+		issues = append(issues, found...)
+	}
+	if err := printJSON(issues); err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		fmt.Fprintln(os.Stderr, issue)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%v problems found", len(issues))
+	}
+	return nil
+}
+
+func cliConfigSchema(args []string) error {
+	schema, err := configSchema(argOr(args, "color-sync"))
+	if err != nil {
+		return err
+	}
+	return printJSON(schema)
+}
+
+// cliThemeLint prints the lint report; it fails if errors were found, so that it may be used in CI
+func cliThemeLint(args []string) error {
+	report, err := lintTheme(args[0])
+	if err != nil {
+		return err
+	}
+	if err := printJSON(report); err != nil {
+		return err
+	}
+	if n := report.errors(); n > 0 {
+		return fmt.Errorf("%s: %v errors found", report.Theme, n)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// clipboard.go
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// clipboardColor formats a palette color for pasting: "hex" (#rrggbb), "strip" (rrggbb), "rgb" (r,g,b)
+// or "css" (rgb(r, g, b))
+func clipboardColor(value, format string) (string, error) {
+	switch format {
+	case "", "hex":
+		return value, nil
+	case "css":
+		r, g, b, err := hexToRGB(value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("rgb(%d, %d, %d)", r, g, b), nil
+	}
+	if formatted, ok := formatColor(value, format); ok {
+		return formatted, nil
+	}
+	return "", fmt.Errorf("unknown format '%s', expected hex, strip, rgb or css", format)
+}
+
+// copyToClipboard puts the text on the Wayland clipboard, as plain text
+func copyToClipboard(text string) error {
+	if _, err := exec.LookPath("wl-copy"); err != nil {
+		return fmt.Errorf("wl-copy not found, install wl-clipboard")
+	}
+	// no output capture: wl-copy forks to serve the clipboard, and the child would keep the pipes open
+	cmd := exec.Command("wl-copy", "--type", "text/plain;charset=utf-8")
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("wl-copy: %v", err)
+	}
+	return nil
+}
+
+func clipboardHistoryAvailable() bool {
+	_, err := exec.LookPath("cliphist")
+	return err == nil
+}
+
+// storeInClipboardHistory adds palette colors to cliphist, so that they can be picked from any
+// clipboard history menu. Colors are stored last to first, so that the background is listed on top.
+func storeInClipboardHistory(palette *ColorPalette, format string) error {
+	if !clipboardHistoryAvailable() {
+		return fmt.Errorf("cliphist not found")
+	}
+	columns := paletteColumns()
+	for i := len(columns) - 1; i >= 0; i-- {
+		value, err := clipboardColor(palette.slot(columns[i]), format)
+		if err != nil {
+			return err
+		}
+		cmd := exec.Command("cliphist", "store")
+		cmd.Stdin = strings.NewReader(value)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("cliphist store: %v %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	log.Infof("Stored %v palette colors in cliphist", len(columns))
+	return nil
+}
+
+// copyPaletteColor copies one slot of the palette, or stores all of them in the clipboard history
+func copyPaletteColor(palette *ColorPalette, slot, format string) (string, error) {
+	if slot == "all" {
+		return "", storeInClipboardHistory(palette, format)
+	}
+	if !isPaletteSlot(slot) {
+		return "", fmt.Errorf("unknown palette slot '%s'", slot)
+	}
+	value, err := clipboardColor(palette.slot(slot), format)
+	if err != nil {
+		return "", err
+	}
+	return value, copyToClipboard(value)
+}
@@ -2,14 +2,18 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -24,16 +28,58 @@ type ColorPalette struct {
 
 // ColorSyncConfig holds settings for color synchronization
 type ColorSyncConfig struct {
-	Enabled      bool              `json:"enabled"`
-	AutoApply    bool              `json:"auto-apply"`
-	Applications map[string]bool   `json:"applications"`
-	LastTheme    string            `json:"last-theme"`
-	LastColors   *ColorPalette     `json:"last-colors,omitempty"`
+	Enabled      bool            `json:"enabled"`
+	AutoApply    bool            `json:"auto-apply"`
+	Applications map[string]bool `json:"applications"`
+	LastTheme    string          `json:"last-theme"`
+	LastColors   *ColorPalette   `json:"last-colors,omitempty"`
+	// When LastColors were applied
+	LastApplied time.Time `json:"last-applied,omitempty"`
+	// Modification time of the pywal palette last offered for adoption, see pywal.go
+	PywalOffered time.Time `json:"pywal-offered,omitempty"`
+	// Per-template output overrides, keyed by template name
+	Destinations map[string]*DestinationOptions `json:"destinations,omitempty"`
+	// Opt-in reloads, for applications that reload more than just colors; false turns a built-in reload off
+	Reload map[string]bool `json:"reload,omitempty"`
+	// Per-workspace or per-output border accents
+	Accents *AccentsConfig `json:"accents,omitempty"`
+	// GTK named colors set by the user, taking precedence over the ones derived from the palette
+	GtkOverrides map[string]string `json:"gtk-overrides"`
+	// Export imported palettes to GTK too, so that GTK applications adopt them
+	GtkReverseSync bool `json:"gtk-reverse-sync,omitempty"`
+	// Named chains of palette steps, see pipeline.go
+	Pipelines map[string][]string `json:"pipelines,omitempty"`
+	// Targets and hooks disabled on some hosts or sessions
+	Skip []SkipRule `json:"skip,omitempty"`
+	// Where community templates are listed, see templatestore.go
+	TemplateIndex string `json:"template-index,omitempty"`
+	// Fill {color16}-{color255} with the 256-color cube and grayscale ramp derived from the palette
+	ExtendedPalette bool `json:"extended-palette,omitempty"`
+	// Per-application consent to writing config files: "always", "ask" or "never", see consent.go
+	Consent map[string]string `json:"consent,omitempty"`
+	// Install the gtkgreet style sheet to /etc/greetd on apply, see greetd.go
+	Greetd bool `json:"greetd,omitempty"`
+	// Write the SDDM theme colors on apply, see sddm.go
+	Sddm *SddmOptions `json:"sddm,omitempty"`
+	// Number of apply runs whose overwritten files are kept, see backups.go; 5 if unset
+	Backups int `json:"backups,omitempty"`
+	// Background opacity by application, 0.1 to 1, for {background-alpha} and {<color>-rgba}, see opacity.go
+	Opacity map[string]float64 `json:"opacity,omitempty"`
+	// Templates not rendered although their application is enabled, see templatesource.go
+	DisabledTemplates []string `json:"disabled-templates,omitempty"`
+	// Render only the templates in color-templates, ignoring the defaults
+	UserTemplatesOnly bool `json:"user-templates-only,omitempty"`
+	// Shell commands run after applying, globally and by application, see hooks.go
+	Hooks *HooksConfig `json:"hooks,omitempty"`
+	// Recolor open terminals with escape sequences after applying, see osc.go
+	LiveTerminals bool `json:"live-terminals,omitempty"`
 }
 
 // ColorExtractor extracts colors from GTK themes
 type ColorExtractor struct {
 	themePaths []string
+	rules      []ColorMappingRule
+	report     ExtractionReport
 }
 
 // NewColorExtractor creates a new color extractor
@@ -43,7 +89,7 @@ func NewColorExtractor() *ColorExtractor {
 		filepath.Join(os.Getenv("HOME"), ".local/share/themes"),
 		"/usr/share/themes",
 	}
-	return &ColorExtractor{themePaths: paths}
+	return &ColorExtractor{themePaths: paths, rules: loadColorMapping()}
 }
 
 // FindThemePath locates the GTK theme directory
@@ -69,39 +115,20 @@ func (ce *ColorExtractor) ExtractColors(themeName string) (*ColorPalette, error)
 		return nil, fmt.Errorf("gtk.css not found in %s", themePath)
 	}
 
-	colors := make(map[string]string)
-	
 	content, err := os.ReadFile(cssFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read css file: %w", err)
 	}
-
-	// Extract @define-color declarations
-	colorPattern := regexp.MustCompile(`@define-color\s+(\w+)\s+([#\w(),.\s]+);`)
-	matches := colorPattern.FindAllStringSubmatch(string(content), -1)
-	
-	for _, match := range matches {
-		if len(match) >= 3 {
-			name := match[1]
-			value := strings.TrimSpace(match[2])
-			colors[name] = value
-		}
-	}
-
-	// Extract CSS variables
-	varPattern := regexp.MustCompile(`--(\w+-\w+(?:-\w+)*)\s*:\s*([#\w(),.\s]+);`)
-	varMatches := varPattern.FindAllStringSubmatch(string(content), -1)
-	
-	for _, match := range varMatches {
-		if len(match) >= 3 {
-			name := match[1]
-			value := strings.TrimSpace(match[2])
-			colors[name] = value
-		}
-	}
+	colors := parseThemeColors(string(content))
 
 	// Resolve color references
+	ce.report = ExtractionReport{Theme: themeName}
 	colors = ce.resolveColorReferences(colors)
+	if len(ce.report.Unresolved) > 0 || len(ce.report.Cyclic) > 0 {
+		log.Warnf("Theme %s: %v unresolved and %v cyclic color definitions, confidence %.2f",
+			themeName, len(ce.report.Unresolved), len(ce.report.Cyclic), ce.report.Confidence(len(colors)))
+		log.Debugf("Unresolved: %v, cyclic: %v", ce.report.Unresolved, ce.report.Cyclic)
+	}
 
 	// Generate standard palette
 	palette := ce.generateStandardPalette(colors)
@@ -109,38 +136,123 @@ func (ce *ColorExtractor) ExtractColors(themeName string) (*ColorPalette, error)
 	return palette, nil
 }
 
-// resolveColorReferences resolves @color references in values
+var (
+	themeColorPattern = regexp.MustCompile(`@define-color\s+(\w+)\s+([#\w(),.\s]+);`)
+	cssVarPattern     = regexp.MustCompile(`--(\w+-\w+(?:-\w+)*)\s*:\s*([#\w(),.\s]+);`)
+)
+
+// parseThemeColors returns @define-color declarations and CSS variables of a theme's gtk.css, unresolved
+func parseThemeColors(css string) map[string]string {
+	colors := make(map[string]string)
+	for _, match := range themeColorPattern.FindAllStringSubmatch(css, -1) {
+		colors[match[1]] = strings.TrimSpace(match[2])
+	}
+	for _, match := range cssVarPattern.FindAllStringSubmatch(css, -1) {
+		colors[match[1]] = strings.TrimSpace(match[2])
+	}
+	return colors
+}
+
+// colorRefPattern matches @name references in color values
+var colorRefPattern = regexp.MustCompile(`@(\w+)`)
+
+// maxColorRefDepth caps reference chains, so that pathological themes can't blow the stack.
+// Longer chains are reported like cycles.
+const maxColorRefDepth = 256
+
+// ExtractionReport describes problems found while extracting a palette
+type ExtractionReport struct {
+	Theme      string   `json:"theme"`
+	Unresolved []string `json:"unresolved,omitempty"` // names referring to undefined colors
+	Cyclic     []string `json:"cyclic,omitempty"`     // names taking part in reference cycles
+}
+
+// Confidence returns 1.0 for a clean extraction, less for every problematic definition
+func (r *ExtractionReport) Confidence(total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	bad := len(r.Unresolved) + len(r.Cyclic)
+	if bad > total {
+		bad = total
+	}
+	return 1.0 - float64(bad)/float64(total)
+}
+
+// resolveColorReferences resolves @color references in values, following each reference
+// depth-first and detecting cycles. Values that can't be resolved are kept as they are.
 func (ce *ColorExtractor) resolveColorReferences(colors map[string]string) map[string]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
 	resolved := make(map[string]string)
-	maxIterations := 10
-
-	for i := 0; i < maxIterations; i++ {
-		changed := false
-		for name, value := range colors {
-			if strings.Contains(value, "@") {
-				refPattern := regexp.MustCompile(`@(\w+)`)
-				matches := refPattern.FindStringSubmatch(value)
-				if len(matches) >= 2 {
-					refName := matches[1]
-					if refValue, exists := colors[refName]; exists {
-						newValue := strings.Replace(value, "@"+refName, refValue, -1)
-						if newValue != value {
-							colors[name] = newValue
-							changed = true
-						}
-					}
-				}
-			}
-			resolved[name] = colors[name]
+	unresolved := make(map[string]bool)
+	cyclic := make(map[string]bool)
+
+	var resolve func(name string, depth int) string
+	resolve = func(name string, depth int) string {
+		switch state[name] {
+		case done:
+			return resolved[name]
+		case visiting:
+			cyclic[name] = true
+			return colors[name]
 		}
-		if !changed {
-			break
+		if depth > maxColorRefDepth {
+			cyclic[name] = true
+			return colors[name]
 		}
+
+		state[name] = visiting
+		value := colorRefPattern.ReplaceAllStringFunc(colors[name], func(ref string) string {
+			refName := ref[1:]
+			if _, exists := colors[refName]; !exists {
+				unresolved[name] = true
+				return ref
+			}
+			refValue := resolve(refName, depth+1)
+			if cyclic[refName] {
+				cyclic[name] = true
+			}
+			return refValue
+		})
+		state[name] = done
+		resolved[name] = value
+		return value
+	}
+
+	// sorted, so that the report is stable
+	var names []string
+	for name := range colors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		resolve(name, 0)
 	}
 
+	ce.report.Unresolved = sortedKeys(unresolved)
+	ce.report.Cyclic = sortedKeys(cyclic)
 	return resolved
 }
 
+func sortedKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Report returns the report of the last extraction
+func (ce *ColorExtractor) Report() ExtractionReport {
+	return ce.report
+}
+
 // generateStandardPalette creates a standardized color palette
 func (ce *ColorExtractor) generateStandardPalette(colors map[string]string) *ColorPalette {
 	palette := &ColorPalette{
@@ -167,33 +279,10 @@ func (ce *ColorExtractor) generateStandardPalette(colors map[string]string) *Col
 		},
 	}
 
-	// Map GTK theme colors to standard palette
-	colorMapping := map[string]string{
-		"theme_bg_color":          "background",
-		"theme_fg_color":          "foreground",
-		"theme_base_color":        "background",
-		"theme_text_color":        "foreground",
-		"theme_selected_bg_color": "color4",
-		"warning_color":           "color3",
-		"error_color":             "color1",
-		"success_color":           "color2",
-	}
-
-	for gtkName, stdName := range colorMapping {
-		if value, exists := colors[gtkName]; exists {
-			normalized := ce.normalizeColor(value)
-			if stdName == "background" || stdName == "foreground" || stdName == "cursor" {
-				switch stdName {
-				case "background":
-					palette.Background = normalized
-				case "foreground":
-					palette.Foreground = normalized
-				case "cursor":
-					palette.Cursor = normalized
-				}
-			} else {
-				palette.Colors[stdName] = normalized
-			}
+	// Map GTK theme colors to standard palette, see color-mapping.json
+	for _, rule := range ce.rules {
+		if value, exists := rule.lookup(colors); exists {
+			palette.setSlot(rule.Slot, ce.normalizeColor(value))
 		}
 	}
 
@@ -226,48 +315,45 @@ func (ce *ColorExtractor) normalizeColor(color string) string {
 
 // TemplateManager manages color templates
 type TemplateManager struct {
-	configDir string
-	templates map[string]string
+	configDir    string
+	templates    map[string]string
+	destinations map[string]*DestinationOptions
+	reload       map[string]bool
+	skip         []SkipRule
+	extended     bool
+	// asks what to do with a destination file not generated by nwg-look; skipped, and not remembered, if nil
+	resolveConflict func(path string) string
+	consent         map[string]string
+	// asks before the first write to an application's files; allowed if nil, see consentGiven
+	askConsent  func(app string, paths []string) string
+	saveConsent func() error
+	// number of backup sets kept, see backups.go
+	backups int
+	// background opacity by application, see opacity.go
+	opacity map[string]float64
+	// templates left out, see templateOff
+	disabled []string
+	userOnly bool
+	// user's commands run after applying, see hooks.go
+	hooks *HooksConfig
+	// recolor open terminals after applying, see osc.go
+	liveTerminals bool
 }
 
 // NewTemplateManager creates a new template manager
 func NewTemplateManager() *TemplateManager {
-	configDir := filepath.Join(configHome(), "nwg-look/color-templates")
-	makeDir(configDir)
+	templatesDir := filepath.Join(configDir(), "color-templates")
+	if !readOnly {
+		makeDir(templatesDir)
+	}
 
 	tm := &TemplateManager{
-		configDir: configDir,
+		configDir: templatesDir,
 		templates: make(map[string]string),
 	}
-
-	tm.createDefaultTemplates()
 	return tm
 }
 
-// createDefaultTemplates creates default color templates
-func (tm *TemplateManager) createDefaultTemplates() {
-	templates := map[string]string{
-		"alacritty.yml":      tm.alacrittyTemplate(),
-		"waybar-colors.css":  tm.waybarTemplate(),
-		"kitty.conf":         tm.kittyTemplate(),
-		"rofi-colors.rasi":   tm.rofiTemplate(),
-		"dunst-colors.conf":  tm.dunstTemplate(),
-		"foot.ini":           tm.footTemplate(),
-		"termite-colors.ini": tm.termiteTemplate(),
-	}
-
-	for filename, content := range templates {
-		templateFile := filepath.Join(tm.configDir, filename)
-		if !pathExists(templateFile) {
-			if err := os.WriteFile(templateFile, []byte(content), 0644); err != nil {
-				log.Warnf("Failed to create template %s: %v", filename, err)
-			} else {
-				log.Debugf("Created template: %s", templateFile)
-			}
-		}
-	}
-}
-
 func (tm *TemplateManager) alacrittyTemplate() string {
 	return `# Alacritty colors - Generated by nwg-look
 colors:
@@ -311,9 +397,10 @@ func (tm *TemplateManager) waybarTemplate() string {
 @define-color color6 {color6};
 @define-color color7 {color7};
 @define-color color8 {color8};
+@define-color background-alpha {background-rgba};
 
 window#waybar {
-    background-color: @background;
+    background-color: @background-alpha;
     color: @foreground;
 }
 `
@@ -324,6 +411,7 @@ func (tm *TemplateManager) kittyTemplate() string {
 foreground {foreground}
 background {background}
 cursor {cursor}
+background_opacity {background-alpha}
 
 color0 {color0}
 color1 {color1}
@@ -341,7 +429,7 @@ color12 {color12}
 color13 {color13}
 color14 {color14}
 color15 {color15}
-`
+` + extendedTemplateLines("\ncolor%[1]d {color%[1]d}")
 }
 
 func (tm *TemplateManager) rofiTemplate() string {
@@ -381,6 +469,7 @@ func (tm *TemplateManager) footTemplate() string {
 [colors]
 foreground={foreground}
 background={background}
+alpha={background-alpha}
 
 regular0={color0}
 regular1={color1}
@@ -399,7 +488,7 @@ bright4={color12}
 bright5={color13}
 bright6={color14}
 bright7={color15}
-`
+` + extendedTemplateLines("\n%[1]d={color%[1]d}")
 }
 
 func (tm *TemplateManager) termiteTemplate() string {
@@ -428,98 +517,2145 @@ color15 = {color15}
 `
 }
 
+func (tm *TemplateManager) weztermTemplate() string {
+	return `# WezTerm colors - Generated by nwg-look
+[colors]
+foreground = "{foreground}"
+background = "{background}"
+cursor_bg = "{cursor}"
+cursor_border = "{cursor}"
+cursor_fg = "{background}"
+selection_bg = "{color8}"
+selection_fg = "{foreground}"
+
+ansi = [
+    "{color0}",
+    "{color1}",
+    "{color2}",
+    "{color3}",
+    "{color4}",
+    "{color5}",
+    "{color6}",
+    "{color7}",
+]
+brights = [
+    "{color8}",
+    "{color9}",
+    "{color10}",
+    "{color11}",
+    "{color12}",
+    "{color13}",
+    "{color14}",
+    "{color15}",
+]
+
+[metadata]
+name = "nwg-look"
+`
+}
+
+func (tm *TemplateManager) ghosttyTemplate() string {
+	return `# Ghostty colors - Generated by nwg-look
+background = {background}
+foreground = {foreground}
+cursor-color = {cursor}
+cursor-text = {background}
+selection-background = {color8}
+selection-foreground = {foreground}
+
+palette = 0={color0}
+palette = 1={color1}
+palette = 2={color2}
+palette = 3={color3}
+palette = 4={color4}
+palette = 5={color5}
+palette = 6={color6}
+palette = 7={color7}
+palette = 8={color8}
+palette = 9={color9}
+palette = 10={color10}
+palette = 11={color11}
+palette = 12={color12}
+palette = 13={color13}
+palette = 14={color14}
+palette = 15={color15}
+`
+}
+
+// vtrgbTemplate is a setvtrgb(8) palette: the red, green and blue components of the 16 console colors.
+// setvtrgb takes no comments.
+func (tm *TemplateManager) vtrgbTemplate() string {
+	return `{color0.red},{color1.red},{color2.red},{color3.red},{color4.red},{color5.red},{color6.red},{color7.red},{color8.red},{color9.red},{color10.red},{color11.red},{color12.red},{color13.red},{color14.red},{color15.red}
+{color0.green},{color1.green},{color2.green},{color3.green},{color4.green},{color5.green},{color6.green},{color7.green},{color8.green},{color9.green},{color10.green},{color11.green},{color12.green},{color13.green},{color14.green},{color15.green}
+{color0.blue},{color1.blue},{color2.blue},{color3.blue},{color4.blue},{color5.blue},{color6.blue},{color7.blue},{color8.blue},{color9.blue},{color10.blue},{color11.blue},{color12.blue},{color13.blue},{color14.blue},{color15.blue}
+`
+}
+
+func (tm *TemplateManager) zathuraTemplate() string {
+	return `# zathura colors - Generated by nwg-look
+set default-bg "{background}"
+set default-fg "{foreground}"
+
+set statusbar-bg "{color0}"
+set statusbar-fg "{foreground}"
+set inputbar-bg "{background}"
+set inputbar-fg "{foreground}"
+
+set notification-bg "{background}"
+set notification-fg "{foreground}"
+set notification-error-bg "{color1}"
+set notification-error-fg "{background}"
+set notification-warning-bg "{color3}"
+set notification-warning-fg "{background}"
+
+set highlight-color "{color3}"
+set highlight-active-color "{color4}"
+
+set completion-bg "{background}"
+set completion-fg "{foreground}"
+set completion-highlight-bg "{color4}"
+set completion-highlight-fg "{background}"
+
+set index-bg "{background}"
+set index-fg "{foreground}"
+set index-active-bg "{color4}"
+set index-active-fg "{background}"
+
+set recolor-lightcolor "{background}"
+set recolor-darkcolor "{foreground}"
+`
+}
+
+func (tm *TemplateManager) makoTemplate() string {
+	return `# mako colors - Generated by nwg-look
+background-color={background}
+text-color={foreground}
+border-color={color4}
+progress-color=over {color8}
+
+[urgency=low]
+border-color={color8}
+
+[urgency=normal]
+border-color={color4}
+
+[urgency=critical]
+border-color={color1}
+text-color={foreground}
+`
+}
+
+func (tm *TemplateManager) swayosdTemplate() string {
+	return `/* SwayOSD colors - Generated by nwg-look */
+/* Usage: @import url("nwg-colors.css"); at the top of ~/.config/swayosd/style.css */
+window#osd {
+    background: rgba({background.rgb}, 0.9);
+    border: 1px solid {color8};
+}
+
+window#osd label,
+window#osd image {
+    color: {foreground};
+}
+
+window#osd progressbar trough,
+window#osd segmented-progress {
+    background: {color8};
+}
+
+window#osd progressbar progress,
+window#osd segmented-progress .segment.active {
+    background: {color4};
+}
+`
+}
+
+func (tm *TemplateManager) wobTemplate() string {
+	return `# wob colors - Generated by nwg-look
+# Merged into ~/.config/wob/wob.ini, other settings are kept. Colors are RRGGBBAA.
+border_color = {color4.strip}ff
+background_color = {background.strip}ff
+bar_color = {foreground.strip}ff
+overflow_border_color = {color1.strip}ff
+overflow_background_color = {background.strip}ff
+overflow_bar_color = {color1.strip}ff
+`
+}
+
+func (tm *TemplateManager) avizoTemplate() string {
+	return `# avizo colors - Generated by nwg-look
+# Merged into ~/.config/avizo/config.ini, other settings are kept.
+[default]
+background = rgba({background.rgb}, 0.9)
+border-color = rgba({color8.rgb}, 1)
+bar-fg-color = rgba({color4.rgb}, 1)
+bar-bg-color = rgba({color8.rgb}, 0.6)
+`
+}
+
+func (tm *TemplateManager) swaylockTemplate() string {
+	return `# swaylock colors - Generated by nwg-look
+color={background.strip}
+inside-color={background.strip}
+inside-clear-color={background.strip}
+inside-caps-lock-color={background.strip}
+inside-ver-color={color4.strip}
+inside-wrong-color={color1.strip}
+ring-color={color8.strip}
+ring-clear-color={color3.strip}
+ring-caps-lock-color={color3.strip}
+ring-ver-color={color4.strip}
+ring-wrong-color={color1.strip}
+key-hl-color={color2.strip}
+bs-hl-color={color1.strip}
+caps-lock-key-hl-color={color2.strip}
+caps-lock-bs-hl-color={color1.strip}
+line-color=00000000
+line-clear-color=00000000
+line-caps-lock-color=00000000
+line-ver-color=00000000
+line-wrong-color=00000000
+separator-color=00000000
+text-color={foreground.strip}
+text-clear-color={foreground.strip}
+text-caps-lock-color={foreground.strip}
+text-ver-color={foreground.strip}
+text-wrong-color={foreground.strip}
+`
+}
+
+func (tm *TemplateManager) wofiTemplate() string {
+	return `/* wofi colors - Generated by nwg-look */
+window {
+    background-color: {background};
+    color: {foreground};
+    border: 2px solid {color4};
+}
+
+#outer-box, #inner-box, #scroll {
+    background-color: {background};
+}
+
+#input {
+    background-color: {background};
+    color: {foreground};
+    border: 1px solid {color8};
+}
+
+#input:focus {
+    border-color: {color4};
+}
+
+#entry, #text {
+    color: {foreground};
+}
+
+#entry:selected {
+    background-color: {color4};
+}
+
+#entry:selected #text {
+    color: {background};
+}
+`
+}
+
+func (tm *TemplateManager) fuzzelTemplate() string {
+	return `# fuzzel colors - Generated by nwg-look
+[colors]
+background={background.strip}ff
+text={foreground.strip}ff
+prompt={foreground.strip}ff
+placeholder={color8.strip}ff
+input={foreground.strip}ff
+match={color4.strip}ff
+selection={color4.strip}ff
+selection-text={background.strip}ff
+selection-match={foreground.strip}ff
+border={color4.strip}ff
+`
+}
+
+func (tm *TemplateManager) hyprlandTemplate() string {
+	return `# Hyprland colors - Generated by nwg-look
+$background = rgb({background.strip})
+$foreground = rgb({foreground.strip})
+$cursor = rgb({cursor.strip})
+$color0 = rgb({color0.strip})
+$color1 = rgb({color1.strip})
+$color2 = rgb({color2.strip})
+$color3 = rgb({color3.strip})
+$color4 = rgb({color4.strip})
+$color5 = rgb({color5.strip})
+$color6 = rgb({color6.strip})
+$color7 = rgb({color7.strip})
+$color8 = rgb({color8.strip})
+$color9 = rgb({color9.strip})
+$color10 = rgb({color10.strip})
+$color11 = rgb({color11.strip})
+$color12 = rgb({color12.strip})
+$color13 = rgb({color13.strip})
+$color14 = rgb({color14.strip})
+$color15 = rgb({color15.strip})
+
+# general { col.active_border = $active_border; col.inactive_border = $inactive_border }
+$active_border = rgb({color4.strip})
+$inactive_border = rgb({color8.strip})
+$group_border_active = rgb({color4.strip})
+$group_border_inactive = rgb({color8.strip})
+`
+}
+
+func (tm *TemplateManager) hyprlockTemplate() string {
+	return `# hyprlock colors - Generated by nwg-look
+# Usage: source = ~/.config/hypr/hyprlock-colors.conf at the top of ~/.config/hypr/hyprlock.conf,
+# then use the variables in your own sections, e.g.
+#
+# background {
+#     color = $background
+# }
+# input-field {
+#     outer_color = $outer_color
+#     inner_color = $inner_color
+#     font_color = $font_color
+#     check_color = $check_color
+#     fail_color = $fail_color
+#     capslock_color = $capslock_color
+# }
+# label {
+#     color = $foreground
+# }
+$background = rgb({background.strip})
+$foreground = rgb({foreground.strip})
+$accent = rgb({color4.strip})
+
+$outer_color = rgb({color8.strip})
+$inner_color = rgb({background.strip})
+$font_color = rgb({foreground.strip})
+$check_color = rgb({color4.strip})
+$fail_color = rgb({color1.strip})
+$capslock_color = rgb({color3.strip})
+$numlock_color = rgb({color3.strip})
+$bothlock_color = rgb({color3.strip})
+`
+}
+
+func (tm *TemplateManager) swayTemplate() string {
+	return `# sway colors - Generated by nwg-look
+set $background {background}
+set $foreground {foreground}
+set $accent {color4}
+set $inactive {color8}
+set $urgent {color1}
+
+# class                 border      background  text        indicator   child_border
+client.focused          {color4} {color4} {background} {color6} {color4}
+client.focused_inactive {color8} {color8} {foreground} {color8} {color8}
+client.unfocused        {background} {background} {color8} {background} {background}
+client.urgent           {color1} {color1} {background} {color1} {color1}
+client.placeholder      {background} {background} {foreground} {background} {background}
+client.background       {background}
+`
+}
+
+func (tm *TemplateManager) nwgDrawerTemplate() string {
+	return `/* nwg-drawer colors - Generated by nwg-look */
+window {
+    background-color: rgba({background.rgb}, 0.95);
+    color: {foreground};
+}
+
+#searchbox {
+    background: {color0};
+    color: {foreground};
+    border: 1px solid {color8};
+}
+
+#searchbox:focus {
+    border-color: {color4};
+}
+
+#category-button {
+    color: {foreground};
+}
+
+#category-button:hover, #category-button:checked {
+    background: {color4};
+    color: {background};
+}
+
+#pinned-box {
+    border-bottom: 1px dotted {color8};
+}
+
+button:hover, button:focus {
+    background: rgba({color4.rgb}, 0.3);
+}
+
+#math-label, #files-box {
+    color: {color4};
+}
+`
+}
+
+func (tm *TemplateManager) nwgMenuTemplate() string {
+	return `/* nwg-menu colors - Generated by nwg-look */
+window {
+    background-color: {background};
+    color: {foreground};
+    border: 1px solid {color8};
+}
+
+#searchbox {
+    background: {color0};
+    color: {foreground};
+    border: 1px solid {color8};
+}
+
+#searchbox:focus {
+    border-color: {color4};
+}
+
+button:hover, button:focus {
+    background: {color4};
+    color: {background};
+}
+
+#category-button:checked {
+    background: rgba({color4.rgb}, 0.3);
+}
+`
+}
+
+func (tm *TemplateManager) i3Template() string {
+	return `# i3 colors - Generated by nwg-look
+set $bg {background}
+set $fg {foreground}
+set $accent {color4}
+set $inactive {color8}
+set $urgent {color1}
+
+# class                 border      backgr.     text        indicator   child_border
+client.focused          {color4} {color4} {background} {color6} {color4}
+client.focused_inactive {color8} {color8} {foreground} {color8} {color8}
+client.unfocused        {background} {background} {color8} {background} {background}
+client.urgent           {color1} {color1} {background} {color1} {color1}
+client.placeholder      {background} {background} {foreground} {background} {background}
+client.background       {background}
+`
+}
+
+func (tm *TemplateManager) polybarTemplate() string {
+	return `; polybar colors - Generated by nwg-look
+[colors]
+background = {background}
+foreground = {foreground}
+primary = {color4}
+secondary = {color6}
+alert = {color1}
+disabled = {color8}
+color0 = {color0}
+color1 = {color1}
+color2 = {color2}
+color3 = {color3}
+color4 = {color4}
+color5 = {color5}
+color6 = {color6}
+color7 = {color7}
+color8 = {color8}
+color9 = {color9}
+color10 = {color10}
+color11 = {color11}
+color12 = {color12}
+color13 = {color13}
+color14 = {color14}
+color15 = {color15}
+`
+}
+
+func (tm *TemplateManager) vimTemplate() string {
+	return `" Vim colors - Generated by nwg-look
+" Usage: colorscheme nwg-look
+hi clear
+if exists('syntax_on')
+  syntax reset
+endif
+let g:colors_name = 'nwg-look'
+
+let g:nwg_colors = {
+      \ 'background': '{background}',
+      \ 'foreground': '{foreground}',
+      \ 'cursor': '{cursor}',
+      \ 'color0': '{color0}', 'color1': '{color1}', 'color2': '{color2}', 'color3': '{color3}',
+      \ 'color4': '{color4}', 'color5': '{color5}', 'color6': '{color6}', 'color7': '{color7}',
+      \ 'color8': '{color8}', 'color9': '{color9}', 'color10': '{color10}', 'color11': '{color11}',
+      \ 'color12': '{color12}', 'color13': '{color13}', 'color14': '{color14}', 'color15': '{color15}',
+      \ }
+
+let g:terminal_color_0 = '{color0}'
+let g:terminal_color_1 = '{color1}'
+let g:terminal_color_2 = '{color2}'
+let g:terminal_color_3 = '{color3}'
+let g:terminal_color_4 = '{color4}'
+let g:terminal_color_5 = '{color5}'
+let g:terminal_color_6 = '{color6}'
+let g:terminal_color_7 = '{color7}'
+let g:terminal_color_8 = '{color8}'
+let g:terminal_color_9 = '{color9}'
+let g:terminal_color_10 = '{color10}'
+let g:terminal_color_11 = '{color11}'
+let g:terminal_color_12 = '{color12}'
+let g:terminal_color_13 = '{color13}'
+let g:terminal_color_14 = '{color14}'
+let g:terminal_color_15 = '{color15}'
+let g:terminal_ansi_colors = map(range(16), 'g:terminal_color_' . v:val)
+
+hi Normal guibg={background} guifg={foreground}
+hi Cursor guibg={cursor} guifg={background}
+hi Visual guibg={color8}
+hi LineNr guifg={color8}
+hi CursorLineNr guifg={color4}
+hi StatusLine guibg={color4} guifg={background}
+hi StatusLineNC guibg={color0} guifg={color8}
+hi Comment guifg={color8}
+hi Constant guifg={color5}
+hi String guifg={color2}
+hi Identifier guifg={color6}
+hi Statement guifg={color4}
+hi PreProc guifg={color3}
+hi Type guifg={color3}
+hi Special guifg={color6}
+hi Error guibg={color1} guifg={background}
+hi Todo guibg={color3} guifg={background}
+`
+}
+
+func (tm *TemplateManager) helixTemplate() string {
+	return `# Helix theme - Generated by nwg-look
+# Usage: theme = "nwg-look" in config.toml
+"ui.background" = { bg = "background" }
+"ui.text" = "foreground"
+"ui.text.focus" = { fg = "foreground", modifiers = ["bold"] }
+"ui.cursor" = { fg = "background", bg = "cursor" }
+"ui.cursor.primary" = { fg = "background", bg = "cursor" }
+"ui.cursor.match" = { fg = "background", bg = "color8" }
+"ui.selection" = { bg = "color8" }
+"ui.selection.primary" = { bg = "color8" }
+"ui.linenr" = "color8"
+"ui.linenr.selected" = "color4"
+"ui.statusline" = { fg = "background", bg = "color4" }
+"ui.statusline.inactive" = { fg = "color8", bg = "color0" }
+"ui.popup" = { fg = "foreground", bg = "color0" }
+"ui.menu" = { fg = "foreground", bg = "color0" }
+"ui.menu.selected" = { fg = "background", bg = "color4" }
+"ui.window" = "color8"
+"ui.help" = { fg = "foreground", bg = "color0" }
+"ui.virtual.whitespace" = "color8"
+"ui.virtual.ruler" = { bg = "color0" }
+
+"comment" = { fg = "color8", modifiers = ["italic"] }
+"keyword" = "color4"
+"function" = "color6"
+"type" = "color3"
+"constant" = "color5"
+"string" = "color2"
+"variable" = "foreground"
+"operator" = "color6"
+"punctuation" = "foreground"
+
+"diagnostic.error" = { underline = { color = "color1", style = "curl" } }
+"diagnostic.warning" = { underline = { color = "color3", style = "curl" } }
+"diagnostic.info" = { underline = { color = "color4", style = "curl" } }
+"diagnostic.hint" = { underline = { color = "color6", style = "curl" } }
+"error" = "color1"
+"warning" = "color3"
+"info" = "color4"
+"hint" = "color6"
+"diff.plus" = "color2"
+"diff.minus" = "color1"
+"diff.delta" = "color3"
+
+[palette]
+background = "{background}"
+foreground = "{foreground}"
+cursor = "{cursor}"
+color0 = "{color0}"
+color1 = "{color1}"
+color2 = "{color2}"
+color3 = "{color3}"
+color4 = "{color4}"
+color5 = "{color5}"
+color6 = "{color6}"
+color7 = "{color7}"
+color8 = "{color8}"
+color9 = "{color9}"
+color10 = "{color10}"
+color11 = "{color11}"
+color12 = "{color12}"
+color13 = "{color13}"
+color14 = "{color14}"
+color15 = "{color15}"
+`
+}
+
+func (tm *TemplateManager) microTemplate() string {
+	return `# micro colorscheme - Generated by nwg-look
+# Usage: "colorscheme": "nwg-look" in ~/.config/micro/settings.json; export MICRO_TRUECOLOR=1 for exact colors
+color-link default "{foreground},{background}"
+color-link comment "{color8}"
+color-link identifier "{color4}"
+color-link constant "{color5}"
+color-link constant.string "{color2}"
+color-link constant.string.char "{color2}"
+color-link constant.number "{color3}"
+color-link statement "{color1}"
+color-link symbol "{color6}"
+color-link symbol.operator "{color6}"
+color-link preproc "{color13}"
+color-link type "{color3}"
+color-link special "{color12}"
+color-link underlined "underline {color4}"
+color-link error "bold {color9}"
+color-link todo "bold {color11}"
+color-link hlsearch "{background},{color3}"
+color-link statusline "{background},{color4}"
+color-link tabbar "{foreground},{color0}"
+color-link indent-char "{color8}"
+color-link line-number "{color8},{background}"
+color-link current-line-number "{color4},{background}"
+color-link diff-added "{color2}"
+color-link diff-modified "{color3}"
+color-link diff-deleted "{color1}"
+color-link gutter-error "{color9}"
+color-link gutter-warning "{color11}"
+color-link cursor-line "{color0}"
+color-link color-column "{color0}"
+color-link selection "{background},{color4}"
+color-link divider "{color8}"
+color-link scrollbar "{color8}"
+`
+}
+
+func (tm *TemplateManager) nanoTemplate() string {
+	return `# nano interface colors - Generated by nwg-look
+# Usage: include ~/.config/nano/nwg-look.nanorc in ~/.config/nano/nanorc (nano 7.0 or newer)
+set titlecolor bold,{background.short},{color4.short}
+set promptcolor {foreground.short},{color8.short}
+set statuscolor bold,{background.short},{color2.short}
+set errorcolor bold,{background.short},{color1.short}
+set spotlightcolor {background.short},{color3.short}
+set selectedcolor {background.short},{color5.short}
+set stripecolor ,{color0.short}
+set scrollercolor {color8.short}
+set numbercolor {color8.short}
+set keycolor bold,{color6.short}
+set functioncolor {foreground.short}
+set minicolor {background.short},{color4.short}
+`
+}
+
+func (tm *TemplateManager) vscodeTemplate() string {
+	return `{
+    "workbench.colorCustomizations": {
+        "editor.background": "{background}",
+        "editor.foreground": "{foreground}",
+        "editorCursor.foreground": "{cursor}",
+        "editor.selectionBackground": "{color8}",
+        "editorLineNumber.foreground": "{color8}",
+        "editorLineNumber.activeForeground": "{color4}",
+        "sideBar.background": "{background}",
+        "sideBar.foreground": "{foreground}",
+        "activityBar.background": "{background}",
+        "activityBar.foreground": "{foreground}",
+        "activityBarBadge.background": "{color4}",
+        "titleBar.activeBackground": "{background}",
+        "titleBar.activeForeground": "{foreground}",
+        "statusBar.background": "{color4}",
+        "statusBar.foreground": "{background}",
+        "tab.activeBackground": "{background}",
+        "tab.inactiveBackground": "{color0}",
+        "panel.background": "{background}",
+        "focusBorder": "{color4}",
+        "button.background": "{color4}",
+        "button.foreground": "{background}",
+        "terminal.background": "{background}",
+        "terminal.foreground": "{foreground}",
+        "terminalCursor.foreground": "{cursor}",
+        "terminal.ansiBlack": "{color0}",
+        "terminal.ansiRed": "{color1}",
+        "terminal.ansiGreen": "{color2}",
+        "terminal.ansiYellow": "{color3}",
+        "terminal.ansiBlue": "{color4}",
+        "terminal.ansiMagenta": "{color5}",
+        "terminal.ansiCyan": "{color6}",
+        "terminal.ansiWhite": "{color7}",
+        "terminal.ansiBrightBlack": "{color8}",
+        "terminal.ansiBrightRed": "{color9}",
+        "terminal.ansiBrightGreen": "{color10}",
+        "terminal.ansiBrightYellow": "{color11}",
+        "terminal.ansiBrightBlue": "{color12}",
+        "terminal.ansiBrightMagenta": "{color13}",
+        "terminal.ansiBrightCyan": "{color14}",
+        "terminal.ansiBrightWhite": "{color15}"
+    }
+}
+`
+}
+
+func (tm *TemplateManager) batTemplate() string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<!-- bat / delta theme - Generated by nwg-look -->
+<plist version="1.0">
+<dict>
+  <key>name</key>
+  <string>nwg-look</string>
+  <key>settings</key>
+  <array>
+    <dict>
+      <key>settings</key>
+      <dict>
+        <key>background</key><string>{background}</string>
+        <key>foreground</key><string>{foreground}</string>
+        <key>caret</key><string>{cursor}</string>
+        <key>selection</key><string>{color8}</string>
+        <key>lineHighlight</key><string>{color0}</string>
+        <key>gutterForeground</key><string>{color8}</string>
+      </dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Comment</string>
+      <key>scope</key><string>comment</string>
+      <key>settings</key><dict><key>foreground</key><string>{color8}</string><key>fontStyle</key><string>italic</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>String</string>
+      <key>scope</key><string>string</string>
+      <key>settings</key><dict><key>foreground</key><string>{color2}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Constant</string>
+      <key>scope</key><string>constant</string>
+      <key>settings</key><dict><key>foreground</key><string>{color5}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Keyword</string>
+      <key>scope</key><string>keyword, storage</string>
+      <key>settings</key><dict><key>foreground</key><string>{color4}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Type</string>
+      <key>scope</key><string>entity.name.type, support.type, storage.type</string>
+      <key>settings</key><dict><key>foreground</key><string>{color3}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Function</string>
+      <key>scope</key><string>entity.name.function, support.function</string>
+      <key>settings</key><dict><key>foreground</key><string>{color6}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Variable</string>
+      <key>scope</key><string>variable</string>
+      <key>settings</key><dict><key>foreground</key><string>{foreground}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Tag</string>
+      <key>scope</key><string>entity.name.tag</string>
+      <key>settings</key><dict><key>foreground</key><string>{color1}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Invalid</string>
+      <key>scope</key><string>invalid</string>
+      <key>settings</key><dict><key>foreground</key><string>{background}</string><key>background</key><string>{color1}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Inserted</string>
+      <key>scope</key><string>markup.inserted</string>
+      <key>settings</key><dict><key>foreground</key><string>{color2}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Deleted</string>
+      <key>scope</key><string>markup.deleted</string>
+      <key>settings</key><dict><key>foreground</key><string>{color1}</string></dict>
+    </dict>
+    <dict>
+      <key>name</key><string>Changed</string>
+      <key>scope</key><string>markup.changed</string>
+      <key>settings</key><dict><key>foreground</key><string>{color3}</string></dict>
+    </dict>
+  </array>
+</dict>
+</plist>
+`
+}
+
+func (tm *TemplateManager) deltaTemplate() string {
+	return `# delta colors - Generated by nwg-look
+# Usage: [include] path = ~/.config/delta/nwg-look.gitconfig
+[delta]
+    syntax-theme = nwg-look
+    file-style = "{color4}" bold
+    file-decoration-style = "{color4}" ul
+    hunk-header-style = file line-number syntax
+    hunk-header-decoration-style = "{color8}" box
+    minus-style = syntax
+    minus-emph-style = "{background}" "{color1}"
+    plus-style = syntax
+    plus-emph-style = "{background}" "{color2}"
+    line-numbers-minus-style = "{color1}"
+    line-numbers-plus-style = "{color2}"
+    line-numbers-zero-style = "{color8}"
+    line-numbers-left-style = "{color8}"
+    line-numbers-right-style = "{color8}"
+`
+}
+
+func (tm *TemplateManager) fzfTemplate() string {
+	return `# fzf colors - Generated by nwg-look
+# Usage: export FZF_DEFAULT_OPTS_FILE=~/.config/fzf/colors
+--color=bg:{background},fg:{foreground},hl:{color4}
+--color=bg+:{color0},fg+:{foreground},hl+:{color12}
+--color=info:{color3},prompt:{color4},pointer:{color5},marker:{color2}
+--color=spinner:{color6},header:{color8},border:{color8},gutter:{background}
+`
+}
+
+func (tm *TemplateManager) fishTemplate() string {
+	return `# fish colors - Generated by nwg-look
+# Sourced by fish from conf.d; universal variables reach running shells too
+set -U fish_color_normal {foreground.strip}
+set -U fish_color_command {color4.strip}
+set -U fish_color_keyword {color5.strip}
+set -U fish_color_quote {color2.strip}
+set -U fish_color_redirection {color6.strip}
+set -U fish_color_end {color6.strip}
+set -U fish_color_error {color1.strip}
+set -U fish_color_param {foreground.strip}
+set -U fish_color_valid_path --underline
+set -U fish_color_option {color3.strip}
+set -U fish_color_comment {color8.strip}
+set -U fish_color_selection --background={color8.strip}
+set -U fish_color_operator {color6.strip}
+set -U fish_color_escape {color5.strip}
+set -U fish_color_autosuggestion {color8.strip}
+set -U fish_color_cwd {color2.strip}
+set -U fish_color_cwd_root {color1.strip}
+set -U fish_color_user {color4.strip}
+set -U fish_color_host {color6.strip}
+set -U fish_color_host_remote {color3.strip}
+set -U fish_color_status {color1.strip}
+set -U fish_color_cancel --reverse
+set -U fish_color_search_match --background={color8.strip}
+set -U fish_color_history_current --bold
+set -U fish_pager_color_progress {background.strip} --background={color4.strip}
+set -U fish_pager_color_prefix {color4.strip} --bold
+set -U fish_pager_color_completion {foreground.strip}
+set -U fish_pager_color_description {color8.strip}
+set -U fish_pager_color_selected_background --background={color8.strip}
+`
+}
+
+func (tm *TemplateManager) zshTemplate() string {
+	return `# zsh-syntax-highlighting styles - Generated by nwg-look
+# Usage: source ~/.config/zsh/nwg-look-highlight.zsh in ~/.zshrc, after loading zsh-syntax-highlighting
+typeset -gA ZSH_HIGHLIGHT_STYLES
+ZSH_HIGHLIGHT_STYLES[default]='fg={foreground}'
+ZSH_HIGHLIGHT_STYLES[unknown-token]='fg={color1},bold'
+ZSH_HIGHLIGHT_STYLES[reserved-word]='fg={color5}'
+ZSH_HIGHLIGHT_STYLES[alias]='fg={color4}'
+ZSH_HIGHLIGHT_STYLES[suffix-alias]='fg={color4},underline'
+ZSH_HIGHLIGHT_STYLES[global-alias]='fg={color6}'
+ZSH_HIGHLIGHT_STYLES[builtin]='fg={color4}'
+ZSH_HIGHLIGHT_STYLES[function]='fg={color4}'
+ZSH_HIGHLIGHT_STYLES[command]='fg={color4}'
+ZSH_HIGHLIGHT_STYLES[precommand]='fg={color4},underline'
+ZSH_HIGHLIGHT_STYLES[hashed-command]='fg={color4}'
+ZSH_HIGHLIGHT_STYLES[autodirectory]='fg={color4},underline'
+ZSH_HIGHLIGHT_STYLES[arg0]='fg={color4}'
+ZSH_HIGHLIGHT_STYLES[commandseparator]='fg={color6}'
+ZSH_HIGHLIGHT_STYLES[redirection]='fg={color6}'
+ZSH_HIGHLIGHT_STYLES[path]='fg={foreground},underline'
+ZSH_HIGHLIGHT_STYLES[path_prefix]='fg={foreground},underline'
+ZSH_HIGHLIGHT_STYLES[globbing]='fg={color6}'
+ZSH_HIGHLIGHT_STYLES[history-expansion]='fg={color5}'
+ZSH_HIGHLIGHT_STYLES[single-hyphen-option]='fg={color3}'
+ZSH_HIGHLIGHT_STYLES[double-hyphen-option]='fg={color3}'
+ZSH_HIGHLIGHT_STYLES[back-quoted-argument]='fg={color5}'
+ZSH_HIGHLIGHT_STYLES[single-quoted-argument]='fg={color2}'
+ZSH_HIGHLIGHT_STYLES[double-quoted-argument]='fg={color2}'
+ZSH_HIGHLIGHT_STYLES[dollar-quoted-argument]='fg={color2}'
+ZSH_HIGHLIGHT_STYLES[dollar-double-quoted-argument]='fg={color6}'
+ZSH_HIGHLIGHT_STYLES[back-double-quoted-argument]='fg={color6}'
+ZSH_HIGHLIGHT_STYLES[assign]='fg={color5}'
+ZSH_HIGHLIGHT_STYLES[comment]='fg={color8}'
+`
+}
+
+func (tm *TemplateManager) ewwTemplate() string {
+	return `// eww colors - Generated by nwg-look
+// Usage: @import "nwg-colors"; in eww.scss
+
+$background: {background};
+$foreground: {foreground};
+$cursor: {cursor};
+
+$color0: {color0};
+$color1: {color1};
+$color2: {color2};
+$color3: {color3};
+$color4: {color4};
+$color5: {color5};
+$color6: {color6};
+$color7: {color7};
+$color8: {color8};
+$color9: {color9};
+$color10: {color10};
+$color11: {color11};
+$color12: {color12};
+$color13: {color13};
+$color14: {color14};
+$color15: {color15};
+
+$accent: {color4};
+$urgent: {color1};
+`
+}
+
+func (tm *TemplateManager) agsTemplate() string {
+	return `// AGS / Astal colors - Generated by nwg-look
+// Usage: @use "nwg-colors" as *; (or @import "nwg-colors";) in style.scss
+
+$background: {background};
+$foreground: {foreground};
+$cursor: {cursor};
+
+$color0: {color0};
+$color1: {color1};
+$color2: {color2};
+$color3: {color3};
+$color4: {color4};
+$color5: {color5};
+$color6: {color6};
+$color7: {color7};
+$color8: {color8};
+$color9: {color9};
+$color10: {color10};
+$color11: {color11};
+$color12: {color12};
+$color13: {color13};
+$color14: {color14};
+$color15: {color15};
+
+$accent: {color4};
+$urgent: {color1};
+`
+}
+
+func (tm *TemplateManager) nwgPanelTemplate() string {
+	return `/* nwg-panel colors - Generated by nwg-look */
+/* Usage: @import url("colors.css"); at the top of ~/.config/nwg-panel/style.css */
+window {
+    background-color: rgba({background.rgb}, 0.9);
+    color: {foreground};
+}
+
+label {
+    color: {foreground};
+}
+
+button {
+    color: {foreground};
+}
+
+button:hover {
+    background-color: rgba({color4.rgb}, 0.3);
+}
+
+#task-box, #workspaces-box {
+    border-color: {color8};
+}
+
+#workspace-focused, #task-box-focused {
+    background-color: rgba({color4.rgb}, 0.4);
+    color: {foreground};
+}
+
+#workspace-urgent {
+    background-color: {color1};
+    color: {background};
+}
+
+#controls-window {
+    background-color: {background};
+    border: 1px solid {color8};
+}
+
+progressbar progress, scale highlight {
+    background-color: {color4};
+}
+`
+}
+
+func (tm *TemplateManager) nwgDockTemplate() string {
+	return `/* nwg-dock colors - Generated by nwg-look */
+/* Usage: @import url("colors.css"); at the top of the dock's style.css */
+window {
+    background-color: rgba({background.rgb}, 0.85);
+    color: {foreground};
+    border-color: {color8};
+}
+
+#box {
+    color: {foreground};
+}
+
+#active {
+    border-bottom: solid 1px;
+    border-color: {color4};
+}
+
+button:hover {
+    background-color: rgba({color4.rgb}, 0.3);
+}
+`
+}
+
+func (tm *TemplateManager) nwgBarTemplate() string {
+	return `/* nwg-bar colors - Generated by nwg-look */
+/* Usage: @import url("colors.css"); at the top of ~/.config/nwg-bar/style.css */
+window {
+    background-color: rgba({background.rgb}, 0.85);
+}
+
+#outer-box {
+    margin: 0px;
+}
+
+#inner-box {
+    background-color: rgba({color0.rgb}, 0.9);
+    border: 1px solid {color8};
+    border-radius: 15px;
+}
+
+button, image {
+    background: none;
+    border-style: none;
+    box-shadow: none;
+    color: {foreground};
+}
+
+button:hover, button:focus {
+    background-color: rgba({color4.rgb}, 0.3);
+}
+`
+}
+
+func (tm *TemplateManager) wlogoutTemplate() string {
+	return `/* wlogout colors - Generated by nwg-look */
+/* Usage: @import url("colors.css"); at the top of ~/.config/wlogout/style.css */
+window {
+    background-color: rgba({background.rgb}, 0.85);
+}
+
+button {
+    color: {foreground};
+    background-color: {color0};
+    border: 2px solid {color8};
+    border-radius: 8px;
+}
+
+button:focus, button:active, button:hover {
+    background-color: rgba({color4.rgb}, 0.4);
+    border-color: {color4};
+    outline-style: none;
+}
+
+#lock:hover, #lock:focus {
+    border-color: {color4};
+}
+
+#logout:hover, #logout:focus {
+    border-color: {color2};
+}
+
+#suspend:hover, #suspend:focus, #hibernate:hover, #hibernate:focus {
+    border-color: {color6};
+}
+
+#shutdown:hover, #shutdown:focus, #reboot:hover, #reboot:focus {
+    border-color: {color1};
+}
+`
+}
+
+func (tm *TemplateManager) qutebrowserTemplate() string {
+	return `# qutebrowser colors - Generated by nwg-look
+# Usage: config.source('nwg-colors.py') in ~/.config/qutebrowser/config.py
+# pylint: disable=undefined-variable
+
+bg = '{background}'
+fg = '{foreground}'
+dim = '{color8}'
+surface = '{color0}'
+accent = '{color4}'
+success = '{color2}'
+warning = '{color3}'
+error = '{color1}'
+
+c.colors.completion.fg = fg
+c.colors.completion.odd.bg = surface
+c.colors.completion.even.bg = bg
+c.colors.completion.category.fg = accent
+c.colors.completion.category.bg = bg
+c.colors.completion.category.border.top = bg
+c.colors.completion.category.border.bottom = bg
+c.colors.completion.item.selected.fg = bg
+c.colors.completion.item.selected.bg = accent
+c.colors.completion.item.selected.border.top = accent
+c.colors.completion.item.selected.border.bottom = accent
+c.colors.completion.item.selected.match.fg = surface
+c.colors.completion.match.fg = warning
+c.colors.completion.scrollbar.fg = fg
+c.colors.completion.scrollbar.bg = bg
+
+c.colors.contextmenu.menu.bg = bg
+c.colors.contextmenu.menu.fg = fg
+c.colors.contextmenu.selected.bg = accent
+c.colors.contextmenu.selected.fg = bg
+
+c.colors.downloads.bar.bg = bg
+c.colors.downloads.start.fg = bg
+c.colors.downloads.start.bg = accent
+c.colors.downloads.stop.fg = bg
+c.colors.downloads.stop.bg = success
+c.colors.downloads.error.fg = error
+
+c.colors.hints.fg = bg
+c.colors.hints.bg = warning
+c.colors.hints.match.fg = fg
+c.colors.keyhint.fg = fg
+c.colors.keyhint.suffix.fg = warning
+c.colors.keyhint.bg = bg
+
+c.colors.messages.error.fg = bg
+c.colors.messages.error.bg = error
+c.colors.messages.error.border = error
+c.colors.messages.warning.fg = bg
+c.colors.messages.warning.bg = warning
+c.colors.messages.warning.border = warning
+c.colors.messages.info.fg = fg
+c.colors.messages.info.bg = bg
+c.colors.messages.info.border = bg
+
+c.colors.prompts.fg = fg
+c.colors.prompts.bg = surface
+c.colors.prompts.border = '1px solid ' + dim
+c.colors.prompts.selected.bg = accent
+c.colors.prompts.selected.fg = bg
+
+c.colors.statusbar.normal.fg = fg
+c.colors.statusbar.normal.bg = bg
+c.colors.statusbar.insert.fg = bg
+c.colors.statusbar.insert.bg = success
+c.colors.statusbar.passthrough.fg = bg
+c.colors.statusbar.passthrough.bg = accent
+c.colors.statusbar.private.fg = fg
+c.colors.statusbar.private.bg = surface
+c.colors.statusbar.command.fg = fg
+c.colors.statusbar.command.bg = bg
+c.colors.statusbar.caret.fg = bg
+c.colors.statusbar.caret.bg = '{color5}'
+c.colors.statusbar.progress.bg = accent
+c.colors.statusbar.url.fg = fg
+c.colors.statusbar.url.success.http.fg = fg
+c.colors.statusbar.url.success.https.fg = success
+c.colors.statusbar.url.error.fg = error
+c.colors.statusbar.url.warn.fg = warning
+c.colors.statusbar.url.hover.fg = '{color6}'
+
+c.colors.tabs.bar.bg = bg
+c.colors.tabs.indicator.start = accent
+c.colors.tabs.indicator.stop = success
+c.colors.tabs.indicator.error = error
+c.colors.tabs.odd.fg = fg
+c.colors.tabs.odd.bg = surface
+c.colors.tabs.even.fg = fg
+c.colors.tabs.even.bg = bg
+c.colors.tabs.selected.odd.fg = bg
+c.colors.tabs.selected.odd.bg = accent
+c.colors.tabs.selected.even.fg = bg
+c.colors.tabs.selected.even.bg = accent
+c.colors.tabs.pinned.odd.fg = fg
+c.colors.tabs.pinned.odd.bg = dim
+c.colors.tabs.pinned.even.fg = fg
+c.colors.tabs.pinned.even.bg = dim
+
+c.colors.webpage.bg = bg
+`
+}
+
+func (tm *TemplateManager) firefoxTemplate() string {
+	return `/* Firefox colors - Generated by nwg-look */
+/* Usage: @import "nwg-colors.css"; at the top of userChrome.css and/or userContent.css in this directory, */
+/* with toolkit.legacyUserProfileCustomizations.stylesheets set to true in about:config */
+:root {
+    --nwg-background: {background};
+    --nwg-foreground: {foreground};
+    --nwg-cursor: {cursor};
+    --nwg-accent: {color4};
+    --nwg-color0: {color0};
+    --nwg-color1: {color1};
+    --nwg-color2: {color2};
+    --nwg-color3: {color3};
+    --nwg-color4: {color4};
+    --nwg-color5: {color5};
+    --nwg-color6: {color6};
+    --nwg-color7: {color7};
+    --nwg-color8: {color8};
+    --nwg-color9: {color9};
+    --nwg-color10: {color10};
+    --nwg-color11: {color11};
+    --nwg-color12: {color12};
+    --nwg-color13: {color13};
+    --nwg-color14: {color14};
+    --nwg-color15: {color15};
+}
+`
+}
+
+func (tm *TemplateManager) chromeTemplate() string {
+	return `{
+    "manifest_version": 3,
+    "name": "nwg-look",
+    "description": "Colors generated by nwg-look",
+    "version": "1.0",
+    "theme": {
+        "colors": {
+            "frame": [{background.rgb}],
+            "frame_inactive": [{background.rgb}],
+            "frame_incognito": [{color0.rgb}],
+            "toolbar": [{color0.rgb}],
+            "toolbar_text": [{foreground.rgb}],
+            "toolbar_button_icon": [{foreground.rgb}],
+            "tab_text": [{foreground.rgb}],
+            "tab_background_text": [{color7.rgb}],
+            "tab_background_text_inactive": [{color8.rgb}],
+            "bookmark_text": [{foreground.rgb}],
+            "omnibox_background": [{background.rgb}],
+            "omnibox_text": [{foreground.rgb}],
+            "ntp_background": [{background.rgb}],
+            "ntp_text": [{foreground.rgb}],
+            "ntp_link": [{color4.rgb}],
+            "button_background": [{color4.rgb}]
+        }
+    }
+}
+`
+}
+
+func (tm *TemplateManager) lazygitTemplate() string {
+	return `# lazygit colors - Generated by nwg-look
+# Usage: export LG_CONFIG_FILE="$HOME/.config/lazygit/config.yml,$HOME/.config/lazygit/nwg-colors.yml"
+gui:
+  theme:
+    activeBorderColor:
+      - "{color4}"
+      - bold
+    inactiveBorderColor:
+      - "{color8}"
+    searchingActiveBorderColor:
+      - "{color3}"
+      - bold
+    optionsTextColor:
+      - "{color4}"
+    selectedLineBgColor:
+      - "{color0}"
+    inactiveViewSelectedLineBgColor:
+      - "{color0}"
+    cherryPickedCommitFgColor:
+      - "{color6}"
+    cherryPickedCommitBgColor:
+      - "{color0}"
+    markedBaseCommitFgColor:
+      - "{color5}"
+    markedBaseCommitBgColor:
+      - "{color0}"
+    unstagedChangesColor:
+      - "{color1}"
+    defaultFgColor:
+      - "{foreground}"
+`
+}
+
+func (tm *TemplateManager) mcTemplate() string {
+	return `# Midnight Commander skin - Generated by nwg-look
+# Usage: skin=nwg-look in the [Midnight-Commander] section of ~/.config/mc/ini, or mc -S nwg-look.
+# Needs mc 4.8.19 or newer, built with truecolor support, and COLORTERM=truecolor.
+[skin]
+    description = nwg-look
+    truecolors = true
+
+[Lines]
+    horiz = ─
+    vert = │
+    lefttop = ┌
+    righttop = ┐
+    leftbottom = └
+    rightbottom = ┘
+    topmiddle = ┬
+    bottommiddle = ┴
+    leftmiddle = ├
+    rightmiddle = ┤
+    cross = ┼
+    dhoriz = ─
+    dvert = │
+    dlefttop = ┌
+    drighttop = ┐
+    dleftbottom = └
+    drightbottom = ┘
+    dtopmiddle = ┬
+    dbottommiddle = ┴
+    dleftmiddle = ├
+    drightmiddle = ┤
+
+[core]
+    _default_ = {foreground};{background}
+    selected = {background};{color4}
+    marked = {color3};{background}
+    markselect = {color11};{color4}
+    gauge = {background};{color4}
+    input = {foreground};{color0}
+    inputunchanged = {color8};{color0}
+    inputmark = {background};{color4}
+    disabled = {color8};{background}
+    reverse = {background};{foreground}
+    commandlinemark = {background};{color4}
+    header = {color4};{background}
+    shadow = {color8};{color0}
+    frame = {color8};{background}
+
+[dialog]
+    _default_ = {foreground};{color0}
+    dfocus = {background};{color4}
+    dhotnormal = {color3};{color0}
+    dhotfocus = {color11};{color4}
+    dtitle = {color4};{color0}
+
+[error]
+    _default_ = {color15};{color1}
+    errdfocus = {background};{color15}
+    errdhotnormal = {color11};{color1}
+    errdhotfocus = {color1};{color15}
+    errdtitle = {color11};{color1}
+
+[filehighlight]
+    directory = {color4};
+    executable = {color2};
+    symlink = {color6};
+    stalelink = {color1};
+    device = {color5};
+    special = {color3};
+    core = {color1};
+    temp = {color8};
+    archive = {color5};
+    doc = {color7};
+    source = {color6};
+    media = {color13};
+    graph = {color13};
+    database = {color3};
+
+[menu]
+    _default_ = {foreground};{color0}
+    menusel = {background};{color4}
+    menuhot = {color3};{color0}
+    menuhotsel = {color11};{color4}
+    menuinactive = {color8};{color0}
+
+[popupmenu]
+    _default_ = {foreground};{color0}
+    menusel = {background};{color4}
+    menutitle = {color4};{color0}
+
+[buttonbar]
+    hotkey = {foreground};{background}
+    button = {background};{color4}
+
+[statusbar]
+    _default_ = {background};{color4}
+
+[help]
+    _default_ = {foreground};{color0}
+    helpitalic = {color2};{color0}
+    helpbold = {color3};{color0}
+    helplink = {color4};{color0}
+    helpslink = {background};{color4}
+    helptitle = {color4};{color0}
+
+[editor]
+    _default_ = {foreground};{background}
+    editbold = {color3};{background}
+    editmarked = {background};{color4}
+    editwhitespace = {color8};{background}
+    editlinestate = {color8};{background}
+    bookmark = {background};{color1}
+    bookmarkfound = {background};{color2}
+    editrightmargin = {color8};{background}
+    editframe = {color8};
+    editframeactive = {color4};
+    editframedrag = {color3};
+
+[viewer]
+    _default_ = {foreground};{background}
+    viewbold = {color3};{background}
+    viewunderline = {color6};{background}
+    viewselected = {background};{color4}
+
+[diffviewer]
+    added = {foreground};{color2}
+    changedline = {color4};{color0}
+    changednew = {color1};{color0}
+    changed = {foreground};{color0}
+    removed = {foreground};{color1}
+    error = {color1};{foreground}
+
+[widget-common]
+    sort-sign-up = ↑
+    sort-sign-down = ↓
+
+[widget-panel]
+    hiddenfiles-sign-show = •
+    hiddenfiles-sign-hide = ○
+    history-prev-item-sign = «
+    history-next-item-sign = »
+    history-show-list-sign = ^
+    filename-scroll-left-char = «
+    filename-scroll-right-char = »
+
+[widget-scollbar]
+    first-vert-char = ↑
+    last-vert-char = ↓
+    first-horiz-char = «
+    last-horiz-char = »
+    current-char = ■
+    background-char = ▒
+`
+}
+
+func (tm *TemplateManager) newsboatTemplate() string {
+	return `# newsboat colors - Generated by nwg-look
+# Usage: include ~/.config/newsboat/nwg-colors in ~/.config/newsboat/config
+# newsboat takes no hex colors: these are terminal palette indexes, set by nwg-look in your terminal's colors
+color background          default   default
+color listnormal          color7    default
+color listnormal_unread   color15   default   bold
+color listfocus           color0    color4
+color listfocus_unread    color0    color4    bold
+color info                color0    color6    bold
+color title               color0    color6    bold
+color article             color7    default
+color end-of-text-marker  color8    default
+color hint-key            color3    color8    bold
+color hint-keys-delimiter color7    color8
+color hint-separator      color7    color8
+color hint-description    color7    color8
+
+highlight article "^(Feed|Title|Author|Date|Link):.*$" color4 default bold
+highlight article "https?://[^ ]+" color6 default underline
+highlight article "\\[[0-9]+\\]" color5 default bold
+`
+}
+
+func (tm *TemplateManager) ncmpcppTemplate() string {
+	return `# ncmpcpp colors - Generated by nwg-look
+# Usage: alias ncmpcpp='ncmpcpp -c ~/.config/ncmpcpp/config -c ~/.config/ncmpcpp/nwg-colors' (ncmpcpp 0.8 or newer)
+# ncmpcpp takes no hex colors: these are terminal palette colors, set by nwg-look in your terminal's colors
+colors_enabled = yes
+empty_tag_color = cyan
+header_window_color = blue
+volume_color = cyan
+state_line_color = black
+state_flags_color = magenta:b
+main_window_color = white
+color1 = white
+color2 = blue
+progressbar_color = black:b
+progressbar_elapsed_color = blue:b
+statusbar_color = white
+statusbar_time_color = cyan:b
+player_state_color = magenta:b
+alternative_ui_separator_color = black:b
+window_border_color = blue
+active_window_border = magenta
+current_item_prefix = $(blue)$r
+current_item_suffix = $/r$(end)
+current_item_inactive_column_prefix = $(magenta)$r
+current_item_inactive_column_suffix = $/r$(end)
+selected_item_prefix = $(magenta)
+selected_item_suffix = $(end)
+`
+}
+
+func (tm *TemplateManager) spicetifyTemplate() string {
+	return `; Spicetify color scheme - Generated by nwg-look
+; spicetify config current_theme nwg-look color_scheme nwg
+[nwg]
+text               = {foreground.strip}
+subtext            = {color7.strip}
+main               = {background.strip}
+main-elevated      = {color0.strip}
+highlight          = {color8.strip}
+highlight-elevated = {color8.strip}
+sidebar            = {color0.strip}
+player             = {color0.strip}
+card               = {color0.strip}
+shadow             = {background.strip}
+selected-row       = {color15.strip}
+button             = {color4.strip}
+button-active      = {color12.strip}
+button-disabled    = {color8.strip}
+tab-active         = {color8.strip}
+notification       = {color4.strip}
+notification-error = {color1.strip}
+misc               = {color8.strip}
+`
+}
+
+func (tm *TemplateManager) obsidianTemplate() string {
+	return `/* Obsidian colors - Generated by nwg-look */
+/* Usage: enable "nwg-look" in Settings > Appearance > CSS snippets */
+.theme-dark, .theme-light {
+    --background-primary: {background};
+    --background-primary-alt: {color0};
+    --background-secondary: {color0};
+    --background-secondary-alt: {color8};
+    --background-modifier-border: {color8};
+    --text-normal: {foreground};
+    --text-muted: {color7};
+    --text-faint: {color8};
+    --text-accent: {color4};
+    --text-accent-hover: {color12};
+    --text-on-accent: {background};
+    --text-selection: rgba({color4.rgb}, 0.3);
+    --interactive-accent: {color4};
+    --interactive-accent-hover: {color12};
+    --color-accent: {color4};
+    --color-red: {color1};
+    --color-green: {color2};
+    --color-yellow: {color3};
+    --color-blue: {color4};
+    --color-purple: {color5};
+    --color-cyan: {color6};
+    --caret-color: {cursor};
+}
+`
+}
+
+func (tm *TemplateManager) emacsTemplate() string {
+	return `;;; nwg-look-theme.el --- Generated by nwg-look -*- lexical-binding: t -*-
+;; Usage: (add-to-list 'custom-theme-load-path "~/.emacs.d/themes/")
+;;        (load-theme 'nwg-look t)
+
+(deftheme nwg-look "Colors synced by nwg-look.")
+
+(custom-theme-set-faces
+ 'nwg-look
+ '(default ((t (:background "{background}" :foreground "{foreground}"))))
+ '(cursor ((t (:background "{cursor}"))))
+ '(region ((t (:background "{color8}" :foreground "{foreground}"))))
+ '(highlight ((t (:background "{color0}"))))
+ '(hl-line ((t (:background "{color0}"))))
+ '(fringe ((t (:background "{background}"))))
+ '(line-number ((t (:foreground "{color8}"))))
+ '(line-number-current-line ((t (:foreground "{color4}"))))
+ '(mode-line ((t (:background "{color0}" :foreground "{foreground}" :box nil))))
+ '(mode-line-inactive ((t (:background "{background}" :foreground "{color8}" :box nil))))
+ '(minibuffer-prompt ((t (:foreground "{color4}" :weight bold))))
+ '(isearch ((t (:background "{color3}" :foreground "{background}"))))
+ '(lazy-highlight ((t (:background "{color8}" :foreground "{foreground}"))))
+ '(link ((t (:foreground "{color4}" :underline t))))
+ '(error ((t (:foreground "{color1}"))))
+ '(warning ((t (:foreground "{color3}"))))
+ '(success ((t (:foreground "{color2}"))))
+ '(font-lock-comment-face ((t (:foreground "{color8}" :slant italic))))
+ '(font-lock-string-face ((t (:foreground "{color2}"))))
+ '(font-lock-keyword-face ((t (:foreground "{color5}"))))
+ '(font-lock-function-name-face ((t (:foreground "{color4}"))))
+ '(font-lock-variable-name-face ((t (:foreground "{color6}"))))
+ '(font-lock-type-face ((t (:foreground "{color3}"))))
+ '(font-lock-constant-face ((t (:foreground "{color9}"))))
+ '(font-lock-builtin-face ((t (:foreground "{color12}")))))
+
+(custom-theme-set-variables
+ 'nwg-look
+ '(ansi-color-names-vector
+   ["{color0}" "{color1}" "{color2}" "{color3}" "{color4}" "{color5}" "{color6}" "{color7}"]))
+
+(provide-theme 'nwg-look)
+;;; nwg-look-theme.el ends here
+`
+}
+
+func (tm *TemplateManager) gtkSourceViewTemplate() string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!-- GtkSourceView style scheme - Generated by nwg-look -->
+<!-- Usage: pick "nwg-look" in gedit, GNOME Text Editor or Builder preferences -->
+<style-scheme id="nwg-look" name="nwg-look" version="1.0">
+  <author>nwg-look</author>
+  <description>Colors of the desktop palette, generated by nwg-look</description>
+
+  <color name="bg" value="{background}"/>
+  <color name="fg" value="{foreground}"/>
+  <color name="cursor" value="{cursor}"/>
+  <color name="black" value="{color0}"/>
+  <color name="red" value="{color1}"/>
+  <color name="green" value="{color2}"/>
+  <color name="yellow" value="{color3}"/>
+  <color name="blue" value="{color4}"/>
+  <color name="magenta" value="{color5}"/>
+  <color name="cyan" value="{color6}"/>
+  <color name="gray" value="{color8}"/>
+
+  <!-- Editor -->
+  <style name="text" foreground="fg" background="bg"/>
+  <style name="selection" foreground="bg" background="blue"/>
+  <style name="selection-unfocused" foreground="fg" background="gray"/>
+  <style name="cursor" foreground="cursor"/>
+  <style name="secondary-cursor" foreground="gray"/>
+  <style name="current-line" background="black"/>
+  <style name="line-numbers" foreground="gray" background="bg"/>
+  <style name="current-line-number" foreground="blue" bold="true"/>
+  <style name="bracket-match" foreground="bg" background="cyan" bold="true"/>
+  <style name="bracket-mismatch" foreground="bg" background="red" bold="true"/>
+  <style name="search-match" foreground="bg" background="yellow"/>
+  <style name="right-margin" foreground="gray" background="black"/>
+  <style name="draw-spaces" foreground="gray"/>
+  <style name="background-pattern" background="black"/>
+
+  <!-- Syntax -->
+  <style name="def:comment" foreground="gray" italic="true"/>
+  <style name="def:shebang" foreground="gray" bold="true"/>
+  <style name="def:doc-comment-element" foreground="gray" bold="true"/>
+  <style name="def:constant" foreground="magenta"/>
+  <style name="def:string" foreground="green"/>
+  <style name="def:special-char" foreground="cyan"/>
+  <style name="def:number" foreground="yellow"/>
+  <style name="def:boolean" foreground="magenta"/>
+  <style name="def:keyword" foreground="magenta" bold="true"/>
+  <style name="def:statement" foreground="red"/>
+  <style name="def:type" foreground="yellow"/>
+  <style name="def:identifier" foreground="blue"/>
+  <style name="def:function" foreground="blue"/>
+  <style name="def:builtin" foreground="cyan"/>
+  <style name="def:preprocessor" foreground="cyan"/>
+  <style name="def:operator" foreground="cyan"/>
+  <style name="def:error" foreground="red" underline="error"/>
+  <style name="def:warning" foreground="yellow"/>
+  <style name="def:note" foreground="bg" background="yellow" bold="true"/>
+  <style name="def:net-address" foreground="blue" underline="single"/>
+  <style name="def:heading" foreground="blue" bold="true"/>
+  <style name="def:emphasis" italic="true"/>
+  <style name="def:strong-emphasis" bold="true"/>
+
+  <!-- Diff -->
+  <style name="diff:added-line" foreground="green"/>
+  <style name="diff:removed-line" foreground="red"/>
+  <style name="diff:changed-line" foreground="yellow"/>
+  <style name="diff:location" foreground="cyan"/>
+</style-scheme>
+`
+}
+
+func (tm *TemplateManager) kateTemplate() string {
+	return `{
+    "metadata": {
+        "name": "nwg-look",
+        "revision": 1,
+        "license": "MIT",
+        "copyright": ["Generated by nwg-look from the desktop palette"]
+    },
+    "text-styles": {
+        "Normal": { "text-color": "{foreground}", "selected-text-color": "{background}" },
+        "Keyword": { "text-color": "{color5}", "selected-text-color": "{background}", "bold": true },
+        "Function": { "text-color": "{color4}", "selected-text-color": "{background}" },
+        "Variable": { "text-color": "{color12}", "selected-text-color": "{background}" },
+        "ControlFlow": { "text-color": "{color1}", "selected-text-color": "{background}", "bold": true },
+        "Operator": { "text-color": "{color6}", "selected-text-color": "{background}" },
+        "BuiltIn": { "text-color": "{color6}", "selected-text-color": "{background}" },
+        "Extension": { "text-color": "{color4}", "selected-text-color": "{background}", "bold": true },
+        "Preprocessor": { "text-color": "{color6}", "selected-text-color": "{background}" },
+        "Attribute": { "text-color": "{color3}", "selected-text-color": "{background}" },
+        "Char": { "text-color": "{color2}", "selected-text-color": "{background}" },
+        "SpecialChar": { "text-color": "{color14}", "selected-text-color": "{background}" },
+        "String": { "text-color": "{color2}", "selected-text-color": "{background}" },
+        "VerbatimString": { "text-color": "{color10}", "selected-text-color": "{background}" },
+        "SpecialString": { "text-color": "{color14}", "selected-text-color": "{background}" },
+        "Import": { "text-color": "{color5}", "selected-text-color": "{background}" },
+        "DataType": { "text-color": "{color3}", "selected-text-color": "{background}" },
+        "DecVal": { "text-color": "{color11}", "selected-text-color": "{background}" },
+        "BaseN": { "text-color": "{color11}", "selected-text-color": "{background}" },
+        "Float": { "text-color": "{color11}", "selected-text-color": "{background}" },
+        "Constant": { "text-color": "{color13}", "selected-text-color": "{background}" },
+        "Comment": { "text-color": "{color8}", "selected-text-color": "{background}", "italic": true },
+        "Documentation": { "text-color": "{color8}", "selected-text-color": "{background}" },
+        "Annotation": { "text-color": "{color12}", "selected-text-color": "{background}" },
+        "CommentVar": { "text-color": "{color13}", "selected-text-color": "{background}" },
+        "RegionMarker": { "text-color": "{color4}", "selected-text-color": "{background}", "background-color": "{color0}" },
+        "Information": { "text-color": "{color4}", "selected-text-color": "{background}" },
+        "Warning": { "text-color": "{color3}", "selected-text-color": "{background}" },
+        "Alert": { "text-color": "{color1}", "selected-text-color": "{background}", "background-color": "{color0}", "bold": true },
+        "Error": { "text-color": "{color1}", "selected-text-color": "{background}", "underline": true },
+        "Others": { "text-color": "{color10}", "selected-text-color": "{background}" }
+    },
+    "editor-colors": {
+        "BackgroundColor": "{background}",
+        "CodeFolding": "{color0}",
+        "BracketMatching": "{color8}",
+        "CurrentLine": "{color0}",
+        "IconBorder": "{background}",
+        "IndentationLine": "{color8}",
+        "LineNumbers": "{color8}",
+        "CurrentLineNumber": "{color4}",
+        "MarkBookmark": "{color4}",
+        "MarkBreakpointActive": "{color1}",
+        "MarkBreakpointReached": "{color3}",
+        "MarkBreakpointDisabled": "{color5}",
+        "MarkExecution": "{color8}",
+        "MarkWarning": "{color3}",
+        "MarkError": "{color1}",
+        "ModifiedLines": "{color3}",
+        "ReplaceHighlight": "{color2}",
+        "SavedLines": "{color2}",
+        "SearchHighlight": "{color3}",
+        "TextSelection": "{color4}",
+        "Separator": "{color8}",
+        "SpellChecking": "{color1}",
+        "TabMarker": "{color8}",
+        "TemplateBackground": "{color0}",
+        "TemplatePlaceholder": "{color8}",
+        "TemplateFocusedPlaceholder": "{color4}",
+        "TemplateReadOnlyPlaceholder": "{color1}",
+        "WordWrapMarker": "{color8}"
+    }
+}
+`
+}
+
 // ApplyColors applies colors to all templates
 func (tm *TemplateManager) ApplyColors(palette *ColorPalette, enabledApps map[string]bool) error {
-	destinations := map[string]string{
-		"alacritty.yml":      filepath.Join(configHome(), "alacritty/colors.yml"),
-		"waybar-colors.css":  filepath.Join(configHome(), "waybar/colors.css"),
-		"kitty.conf":         filepath.Join(configHome(), "kitty/theme.conf"),
-		"rofi-colors.rasi":   filepath.Join(configHome(), "rofi/colors.rasi"),
-		"dunst-colors.conf":  filepath.Join(configHome(), "dunst/dunstrc-colors"),
-		"foot.ini":           filepath.Join(configHome(), "foot/colors.ini"),
-		"termite-colors.ini": filepath.Join(configHome(), "termite/colors"),
-	}
-
-	appNames := map[string]string{
-		"alacritty.yml":      "alacritty",
-		"waybar-colors.css":  "waybar",
-		"kitty.conf":         "kitty",
-		"rofi-colors.rasi":   "rofi",
-		"dunst-colors.conf":  "dunst",
-		"foot.ini":           "foot",
-		"termite-colors.ini": "termite",
-	}
-
-	for templateName, destPath := range destinations {
-		appName := appNames[templateName]
-		
-		// Skip if app is disabled
-		if enabled, exists := enabledApps[appName]; exists && !enabled {
-			log.Debugf("Skipping %s (disabled)", appName)
+	return tm.applyColors(palette, enabledApps, nil, nil)
+}
+
+// applyColors renders the templates depending on the changed palette slots, or all of them if nil.
+// With dryRun set, diffs are printed there instead of writing files, see DryRunColors.
+func (tm *TemplateManager) applyColors(palette *ColorPalette, enabledApps map[string]bool, changed []string, dryRun io.Writer) error {
+	var written []string
+	manifest := loadManifest()
+	backups := newBackupSet()
+	skipApps, skipHooks := activeSkips(tm.skip)
+
+	// Collect the files of each application first, to ask for consent once per application
+	var targets []colorTarget
+	appPaths := make(map[string][]string)
+	for _, t := range colorTargets {
+		if changed != nil && !tm.dependsOn(manifest, t, changed) {
+			log.Debugf("Skipping %s (unchanged)", t.template)
+			continue
+		}
+		// Skip if app is disabled or not yet known to the user's config
+		if !enabledApps[t.app] {
+			log.Debugf("Skipping %s (disabled)", t.app)
 			continue
 		}
+		if skipApps[t.app] || skipApps[t.template] {
+			log.Infof("Skipping %s on this host/session", t.template)
+			continue
+		}
+		if tm.templateOff(t) {
+			log.Debugf("Skipping %s (template disabled)", t.template)
+			continue
+		}
+		if destPath := t.destPath(tm.destinations[t.template]); destPath != "" {
+			targets = append(targets, t)
+			appPaths[t.app] = append(appPaths[t.app], destPath)
+		}
+	}
+	allowed := make(map[string]bool)
+	outcomes := make(map[string]*AppOutcome)
+	for _, appName := range colorApps() {
+		if paths, ok := appPaths[appName]; ok {
+			if dryRun != nil {
+				allowed[appName] = tm.consent[appName] != consentNever
+			} else {
+				allowed[appName] = tm.consentGiven(appName, paths)
+			}
+			if allowed[appName] {
+				outcomes[appName] = &AppOutcome{Time: time.Now()}
+			}
+		}
+	}
 
-		templatePath := filepath.Join(tm.configDir, templateName)
-		if !pathExists(templatePath) {
-			log.Debugf("Template not found: %s", templatePath)
+	// Stage all outputs first, so that nothing is written if writing any of them fails. A template that can't be
+	// rendered, or a file of the user's that can't be merged into, only fails its own target.
+	var staged []stagedWrite
+	for _, t := range targets {
+		templateName := t.template
+		appName := t.app
+		opts := tm.destinations[templateName]
+		if !allowed[appName] {
 			continue
 		}
+		destPath := t.destPath(opts)
 
-		// Read template
-		content, err := os.ReadFile(templatePath)
+		rendered, ok := tm.render(t, palette)
+		if !ok {
+			log.Warnf("Skipping %s: template not usable", destPath)
+			outcomes[appName].fail("template %s not usable, see the log", templateName)
+			continue
+		}
+		output, err := t.outputFor(destPath, rendered, opts)
 		if err != nil {
-			log.Warnf("Failed to read template %s: %v", templateName, err)
+			log.Warnf("Failed to merge colors into %s, skipping it: %v", destPath, err)
+			outcomes[appName].fail("merging into %s: %v", destPath, err)
 			continue
 		}
 
-		// Apply colors
-		output := tm.fillTemplate(string(content), palette)
+		if dryRun != nil {
+			tm.printDiff(dryRun, manifest, t, destPath, output)
+			continue
+		}
 
-		// Create destination directory
-		destDir := filepath.Dir(destPath)
-		makeDir(destDir)
+		// Don't clobber files created or edited by the user; merged and injected files are shared by design
+		alongside, keepBak := false, false
+		if !t.shared(opts) && manifest.isConflict(destPath, output) {
+			switch tm.conflictDecision(manifest, destPath) {
+			case conflictSkip:
+				log.Infof("Skipping %s: not generated by nwg-look", destPath)
+				continue
+			case conflictAlongside:
+				destPath += ".nwg-look"
+				alongside = true
+			default:
+				keepBak = true
+			}
+		}
+		// Identical output isn't written, so that the mtime stays and the application isn't reloaded
+		unchanged := false
+		if current, err := os.ReadFile(destPath); err == nil && bytes.Equal(current, output) {
+			log.Debugf("Skipping %s (unchanged)", destPath)
+			unchanged = true
+		}
+		staged = append(staged, stagedWrite{t, destPath, output, opts, alongside, keepBak, unchanged})
+	}
 
-		// Write to destination
-		if err := os.WriteFile(destPath, []byte(output), 0644); err != nil {
-			log.Warnf("Failed to write %s: %v", destPath, err)
+	if dryRun != nil {
+		return nil
+	}
+	errs := tm.commitWrites(staged, manifest, backups, outcomes)
+	for appName, outcome := range outcomes {
+		if len(errs) > 0 {
+			outcome.fail("nothing written: %v", errs[0])
+		}
+		manifest.Outcomes[appName] = outcome
+	}
+	if len(errs) > 0 {
+		if err := manifest.save(); err != nil {
+			log.Warnf("Failed to save %s: %v", manifestFile(), err)
+		}
+		return fmt.Errorf("no files changed: %w", errors.Join(errs...))
+	}
+	files := make(map[string][]string)
+	for _, w := range staged {
+		if w.alongside || w.unchanged {
+			continue
+		}
+		if !isIn(written, w.t.app) {
+			written = append(written, w.t.app)
+		}
+		files[w.t.app] = append(files[w.t.app], w.path)
+	}
+	if err := manifest.save(); err != nil {
+		log.Warnf("Failed to save %s: %v", manifestFile(), err)
+	}
+	if err := backups.save(tm.backups); err != nil {
+		log.Warnf("Failed to save backups: %v", err)
+	}
+
+	for _, appName := range written {
+		if skipHooks[appName] {
+			log.Infof("Not reloading %s on this host/session", appName)
+			continue
+		}
+		reloadApp(appName, files[appName], tm.reload)
+	}
+	if tm.liveTerminals && len(written) > 0 {
+		if skipHooks[hookTerminals] {
+			log.Infof("Not recoloring open terminals on this host/session")
 		} else {
-			log.Infof("✓ Applied colors to %s", destPath)
+			recolorTerminals(palette, tm.extended)
 		}
 	}
+	tm.hooks.runHooks(written, files, skipHooks)
 
 	return nil
 }
 
-// fillTemplate replaces placeholders with actual colors
-func (tm *TemplateManager) fillTemplate(template string, palette *ColorPalette) string {
-	output := template
-	
-	// Replace main colors
-	output = strings.ReplaceAll(output, "{background}", palette.Background)
-	output = strings.ReplaceAll(output, "{foreground}", palette.Foreground)
-	output = strings.ReplaceAll(output, "{cursor}", palette.Cursor)
+// stagedWrite is a rendered output, about to be written to path
+type stagedWrite struct {
+	t         colorTarget
+	path      string
+	output    []byte
+	opts      *DestinationOptions
+	alongside bool
+	keepBak   bool // the user's file is overwritten, keep a copy as <path>.bak too
+	unchanged bool // the file holds the output already
+}
+
+// commitWrites writes the staged outputs, backing up each destination first. If any write fails, the files
+// written so far are put back from the backups, and the errors returned.
+func (tm *TemplateManager) commitWrites(staged []stagedWrite, manifest *Manifest, backups *BackupSet, outcomes map[string]*AppOutcome) []error {
+	for _, w := range staged {
+		if w.unchanged {
+			continue
+		}
+		if w.keepBak {
+			if err := backupFile(w.path); err != nil {
+				log.Warnf("Failed to back up %s: %v", w.path, err)
+				outcomes[w.t.app].fail("backing up %s: %v", w.path, err)
+				backups.discard()
+				return []error{fmt.Errorf("backing up %s: %w", w.path, err)}
+			}
+			log.Infof("Backed up %s.bak", w.path)
+		}
+		if err := backups.add(w.path, manifest); err != nil {
+			log.Warnf("Failed to back up %s: %v", w.path, err)
+			outcomes[w.t.app].fail("backing up %s: %v", w.path, err)
+			backups.discard()
+			return []error{fmt.Errorf("backing up %s: %w", w.path, err)}
+		}
+	}
 
-	// Replace color0-color15
+	var done []stagedWrite
+	var errs []error
+	for _, w := range staged {
+		if w.unchanged {
+			continue
+		}
+		makeDir(filepath.Dir(w.path))
+		if err := writeFileAtomic(w.path, w.output, defaultDestinationMode); err != nil {
+			log.Warnf("Failed to write %s: %v", w.path, err)
+			outcomes[w.t.app].fail("writing %s: %v", w.path, err)
+			errs = append(errs, fmt.Errorf("writing %s: %w", w.path, err))
+			break
+		}
+		done = append(done, w)
+		if err := applyFileOptions(w.path, w.opts); err != nil {
+			log.Warnf("Failed to set permissions on %s: %v", w.path, err)
+			outcomes[w.t.app].fail("setting permissions on %s: %v", w.path, err)
+			errs = append(errs, fmt.Errorf("setting permissions on %s: %w", w.path, err))
+			break
+		}
+	}
+
+	if len(errs) > 0 {
+		for i := len(done) - 1; i >= 0; i-- {
+			w := done[i]
+			if err := backups.restoreFile(w.path); err != nil {
+				log.Warnf("Failed to roll back %s: %v", w.path, err)
+				errs = append(errs, fmt.Errorf("rolling back %s: %w", w.path, err))
+			} else {
+				log.Infof("Rolled back %s", w.path)
+			}
+		}
+		backups.discard()
+		return errs
+	}
+
+	for _, w := range staged {
+		if w.unchanged {
+			// permissions may have been changed in the config since
+			if err := applyFileOptions(w.path, w.opts); err != nil {
+				log.Warnf("Failed to set permissions on %s: %v", w.path, err)
+			}
+			log.Infof("✓ %s up to date", w.path)
+		} else {
+			log.Infof("✓ Applied colors to %s", w.path)
+		}
+		manifest.Files[w.path] = contentHash(w.output)
+		if record := tm.renderRecord(w.t); record != nil {
+			manifest.Renders[w.t.template] = record
+		}
+		outcomes[w.t.app].Files = append(outcomes[w.t.app].Files, w.path)
+	}
+	return nil
+}
+
+// DryRunColors renders the templates as ApplyColors would, and prints unified diffs against the destination
+// files to w instead of writing them. Nothing is asked, written or reloaded.
+func (tm *TemplateManager) DryRunColors(palette *ColorPalette, enabledApps map[string]bool, w io.Writer) error {
+	return tm.applyColors(palette, enabledApps, nil, w)
+}
+
+// printDiff prints what writing the output would change, noting files that would need a conflict decision
+func (tm *TemplateManager) printDiff(w io.Writer, manifest *Manifest, t colorTarget, path string, output []byte) {
+	current, err := os.ReadFile(path)
+	oldName := path
+	if err != nil {
+		oldName = "/dev/null"
+	}
+	diff := unifiedDiff(oldName, path, string(current), string(output))
+	if diff == "" {
+		return
+	}
+	if !t.shared(tm.destinations[t.template]) && manifest.isConflict(path, output) {
+		decision, ok := manifest.Decisions[path]
+		if !ok {
+			decision = "ask"
+		}
+		fmt.Fprintf(w, "# %s was not generated by nwg-look (on apply: %s)\n", path, decision)
+	}
+	fmt.Fprint(w, diff)
+}
+
+// conflictDecision returns the remembered decision for the file, or asks for one
+func (tm *TemplateManager) conflictDecision(manifest *Manifest, path string) string {
+	if decision, ok := manifest.Decisions[path]; ok {
+		return decision
+	}
+	// nobody to ask, e.g. from the CLI or the rotation timer: left alone, and asked about in the GUI later
+	if tm.resolveConflict == nil {
+		log.Warnf("%s was not generated by nwg-look, leaving it until you decide in the GUI", path)
+		return conflictSkip
+	}
+	decision := tm.resolveConflict(path)
+	manifest.Decisions[path] = decision
+	return decision
+}
+
+// render fills the target's template with colors; false if there's no usable template
+func (tm *TemplateManager) render(t colorTarget, palette *ColorPalette) (string, bool) {
+	content, source, err := tm.templateContent(t)
+	if err != nil {
+		log.Warnf("Failed to read template %s: %v", t.template, err)
+		return "", false
+	}
+	log.Debugf("Rendering %s from %s", t.template, source)
+	if err := checkTemplateCompat(t.template, string(content)); err != nil {
+		log.Warn(err)
+		return "", false
+	}
+
+	palette = tm.destinations[t.template].corrected(palette)
+	return applyAccessibility(t.template, tm.fillTemplate(string(content), palette, tm.opacityFor(t.app)), palette), true
+}
+
+// placeholderPattern matches {name} and {name.modifier}, e.g. {color4} or {background.strip}
+var placeholderPattern = regexp.MustCompile(`\{(\w+)(?:\.(\w+))?\}`)
+
+// fillTemplate replaces placeholders with actual colors, and the opacity placeholders with the given opacity
+func (tm *TemplateManager) fillTemplate(template string, palette *ColorPalette, opacity float64) string {
+	values := map[string]string{
+		"background": palette.Background,
+		"foreground": palette.Foreground,
+		"cursor":     palette.Cursor,
+	}
+	// color0-color15
 	for name, value := range palette.Colors {
-		placeholder := "{" + name + "}"
-		output = strings.ReplaceAll(output, placeholder, value)
+		values[name] = value
 	}
+	if tm.extended {
+		for name, value := range extendedColors(palette) {
+			values[name] = value
+		}
+	} else {
+		template = dropExtendedLines(template)
+	}
+	template = fillOpacity(template, values, opacity)
 
-	return output
+	return placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		parts := placeholderPattern.FindStringSubmatch(match)
+		value, ok := values[parts[1]]
+		if !ok {
+			return match
+		}
+		if formatted, ok := formatColor(value, parts[2]); ok {
+			return formatted
+		}
+		return match
+	})
+}
+
+// extendedPlaceholderPattern matches {color16} to {color255}, with or without a modifier
+var extendedPlaceholderPattern = regexp.MustCompile(`\{color(1[6-9]|[2-9]\d|1\d\d|2[0-4]\d|25[0-5])(\.\w+)?\}`)
+
+// dropExtendedLines leaves out lines using the extended palette, so that its entries stay unset
+// in the target instead of being rendered with unfilled placeholders
+func dropExtendedLines(template string) string {
+	if !extendedPlaceholderPattern.MatchString(template) {
+		return template
+	}
+	var lines []string
+	for _, line := range strings.Split(template, "\n") {
+		if !extendedPlaceholderPattern.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// extendedTemplateLines formats a line for each of color16-color255, e.g. "\ncolor%[1]d {color%[1]d}";
+// a blank line separates them from the base palette
+func extendedTemplateLines(format string) string {
+	var sb strings.Builder
+	for i := 16; i < 256; i++ {
+		sb.WriteString(fmt.Sprintf(format, i))
+	}
+	return sb.String() + "\n"
+}
+
+// formatColor applies a placeholder modifier: "" (as is), "strip" (no leading #), "rgb" (r,g,b),
+// "short" (#rgb, e.g. for nano), or "red", "green" and "blue" (one decimal component, e.g. for setvtrgb)
+func formatColor(value, modifier string) (string, bool) {
+	switch modifier {
+	case "":
+		return value, true
+	case "strip":
+		return strings.TrimPrefix(value, "#"), true
+	case "rgb":
+		r, g, b, err := hexToRGB(value)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("%d,%d,%d", r, g, b), true
+	case "short":
+		r, g, b, err := hexToRGB(value)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprintf("#%x%x%x", (r*15+127)/255, (g*15+127)/255, (b*15+127)/255), true
+	case "red", "green", "blue":
+		r, g, b, err := hexToRGB(value)
+		if err != nil {
+			return "", false
+		}
+		return fmt.Sprint(map[string]int{"red": r, "green": g, "blue": b}[modifier]), true
+	}
+	return "", false
 }
 
 // ColorSyncManager manages the color synchronization feature
 type ColorSyncManager struct {
-	extractor *ColorExtractor
-	templates *TemplateManager
-	config    *ColorSyncConfig
+	extractor  *ColorExtractor
+	templates  *TemplateManager
+	config     *ColorSyncConfig
 	configFile string
+	// no color-sync.json existed, see detectAppearance
+	firstRun bool
 }
 
 // NewColorSyncManager creates a new color sync manager
 func NewColorSyncManager() *ColorSyncManager {
-	configFile := filepath.Join(configHome(), "nwg-look/color-sync.json")
-	
+	configFile := filepath.Join(configDir(), "color-sync.json")
+
 	csm := &ColorSyncManager{
 		extractor:  NewColorExtractor(),
 		templates:  NewTemplateManager(),
@@ -527,9 +2663,21 @@ func NewColorSyncManager() *ColorSyncManager {
 	}
 
 	csm.loadConfig()
+	// the user's copies are all that's rendered then, see templateOff
+	if !csm.config.UserTemplatesOnly && !readOnly {
+		csm.templates.pruneDefaultCopies()
+	}
 	return csm
 }
 
+// initColorSync initializes the color sync manager
+func initColorSync() {
+	registerCommunityTemplates()
+	registerLocalTemplates()
+	colorSyncManager = NewColorSyncManager()
+	log.Debug("Color sync manager initialized")
+}
+
 // loadConfig loads the color sync configuration
 func (csm *ColorSyncManager) loadConfig() {
 	if pathExists(csm.configFile) {
@@ -538,6 +2686,18 @@ func (csm *ColorSyncManager) loadConfig() {
 			var config ColorSyncConfig
 			if err := json.Unmarshal(data, &config); err == nil {
 				csm.config = &config
+				validateDestinations(csm.config.Destinations)
+				csm.templates.destinations = csm.config.Destinations
+				csm.templates.reload = csm.config.Reload
+				csm.templates.skip = csm.config.Skip
+				csm.templates.extended = csm.config.ExtendedPalette
+				csm.templates.backups = csm.config.Backups
+				csm.templates.opacity = csm.config.Opacity
+				csm.templates.disabled = csm.config.DisabledTemplates
+				csm.templates.userOnly = csm.config.UserTemplatesOnly
+				csm.templates.hooks = csm.config.Hooks
+				csm.templates.liveTerminals = csm.config.LiveTerminals
+				csm.initConsent()
 				log.Debug("Loaded color sync config")
 				return
 			}
@@ -545,31 +2705,31 @@ func (csm *ColorSyncManager) loadConfig() {
 	}
 
 	// Default configuration
+	csm.firstRun = !pathExists(csm.configFile)
 	csm.config = &ColorSyncConfig{
-		Enabled:   true,
-		AutoApply: true,
-		Applications: map[string]bool{
-			"alacritty": true,
-			"waybar":    true,
-			"kitty":     true,
-			"rofi":      true,
-			"dunst":     true,
-			"foot":      true,
-			"termite":   false,
-		},
+		Enabled:      true,
+		AutoApply:    true,
+		Applications: make(map[string]bool),
+	}
+	for _, t := range colorTargets {
+		csm.config.Applications[t.app] = t.enabled
 	}
+	csm.initConsent()
 	csm.saveConfig()
 }
 
 // saveConfig saves the color sync configuration
 func (csm *ColorSyncManager) saveConfig() error {
+	if readOnly {
+		return nil
+	}
 	data, err := json.MarshalIndent(csm.config, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	configDir := filepath.Dir(csm.configFile)
-	makeDir(configDir)
+	dir := filepath.Dir(csm.configFile)
+	makeDir(dir)
 
 	return os.WriteFile(csm.configFile, data, 0644)
 }
@@ -598,12 +2758,88 @@ func (csm *ColorSyncManager) ApplyTheme(themeName string) error {
 	// Save to config
 	csm.config.LastTheme = themeName
 	csm.config.LastColors = palette
+	csm.config.LastApplied = time.Now()
 	csm.saveConfig()
+	csm.refreshAccents(palette)
+	recordHistory(historyColors, "theme "+themeName)
+	csm.syncGreetd()
+	csm.syncSddm()
+	syncVtrgb()
+
+	// the palette comes from the theme: let its own colors show again
+	if csm.config.GtkReverseSync {
+		if err := ResetGtkColors(); err != nil {
+			log.Warnf("Failed to reset GTK named colors: %v", err)
+		}
+	}
 
 	log.Info("✓ Successfully applied colors!")
 	return nil
 }
 
+// DryRunTheme prints the diffs applying the theme's colors would make, see DryRunColors
+func (csm *ColorSyncManager) DryRunTheme(themeName string, w io.Writer) error {
+	palette, err := csm.extractor.ExtractColors(themeName)
+	if err != nil {
+		return fmt.Errorf("failed to extract colors: %w", err)
+	}
+	return csm.templates.DryRunColors(palette, csm.config.Applications, w)
+}
+
+// ApplyPalette applies an imported palette to all templates
+func (csm *ColorSyncManager) ApplyPalette(palette *ColorPalette, source string) error {
+	if !csm.config.Enabled {
+		return fmt.Errorf("color sync is disabled")
+	}
+	log.Infof(">>> Applying palette from %s", source)
+
+	if err := csm.templates.ApplyColors(palette, csm.config.Applications); err != nil {
+		return fmt.Errorf("failed to apply colors: %w", err)
+	}
+
+	csm.config.LastTheme = source
+	csm.config.LastColors = palette
+	csm.config.LastApplied = time.Now()
+	csm.saveConfig()
+	csm.refreshAccents(palette)
+	recordHistory(historyColors, source)
+	csm.syncGreetd()
+	csm.syncSddm()
+	syncVtrgb()
+
+	if csm.config.GtkReverseSync {
+		if err := ExportGtkColors(palette); err != nil {
+			log.Warnf("Failed to export GTK named colors: %v", err)
+		}
+	}
+
+	log.Info("✓ Successfully applied colors!")
+	return nil
+}
+
+// refreshAccents updates per-workspace accents on Hyprland; the sway watcher follows palette changes by itself
+func (csm *ColorSyncManager) refreshAccents(palette *ColorPalette) {
+	ac := csm.config.Accents
+	if ac == nil || ac.Mode != "workspace" || os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") == "" {
+		return
+	}
+	if _, skipHooks := activeSkips(csm.config.Skip); skipHooks["accents"] {
+		return
+	}
+	if err := applyHyprlandAccents(palette, ac.Workspaces); err != nil {
+		log.Warn(err)
+	}
+}
+
+// gtkOverrides returns the GTK named color overrides, importing them from the user's gtk.css on first use
+func (csm *ColorSyncManager) gtkOverrides() map[string]string {
+	if csm.config.GtkOverrides == nil {
+		csm.config.GtkOverrides = importGtkOverrides()
+		csm.saveConfig()
+	}
+	return csm.config.GtkOverrides
+}
+
 // IsEnabled returns whether color sync is enabled
 func (csm *ColorSyncManager) IsEnabled() bool {
 	return csm.config.Enabled
@@ -626,6 +2862,41 @@ func (csm *ColorSyncManager) SetAutoApply(autoApply bool) {
 	csm.saveConfig()
 }
 
+// IsGtkReverseSync returns whether imported palettes are exported to GTK
+func (csm *ColorSyncManager) IsGtkReverseSync() bool {
+	return csm.config.GtkReverseSync
+}
+
+// SetGtkReverseSync turns exporting imported palettes to GTK on or off
+func (csm *ColorSyncManager) SetGtkReverseSync(reverse bool) {
+	csm.config.GtkReverseSync = reverse
+	csm.saveConfig()
+}
+
+// IsExtendedPalette returns whether {color16}-{color255} are filled
+func (csm *ColorSyncManager) IsExtendedPalette() bool {
+	return csm.config.ExtendedPalette
+}
+
+// SetExtendedPalette turns the extended 256-color palette on or off
+func (csm *ColorSyncManager) SetExtendedPalette(extended bool) {
+	csm.config.ExtendedPalette = extended
+	csm.templates.extended = extended
+	csm.saveConfig()
+}
+
+// IsLiveTerminals returns whether open terminals are recolored on apply
+func (csm *ColorSyncManager) IsLiveTerminals() bool {
+	return csm.config.LiveTerminals
+}
+
+// SetLiveTerminals turns recoloring open terminals on or off
+func (csm *ColorSyncManager) SetLiveTerminals(live bool) {
+	csm.config.LiveTerminals = live
+	csm.templates.liveTerminals = live
+	csm.saveConfig()
+}
+
 // IsAppEnabled returns whether an app is enabled for sync
 func (csm *ColorSyncManager) IsAppEnabled(appName string) bool {
 	enabled, exists := csm.config.Applications[appName]
@@ -640,8 +2911,7 @@ func (csm *ColorSyncManager) SetAppEnabled(appName string, enabled bool) {
 
 // GetApplications returns the list of supported applications
 func (csm *ColorSyncManager) GetApplications() []string {
-	apps := []string{"alacritty", "waybar", "kitty", "rofi", "dunst", "foot", "termite"}
-	return apps
+	return colorApps()
 }
 
 // ExportCurrentPalette exports the current palette to a file
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveColorReferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		colors     map[string]string
+		want       map[string]string // resolved values checked, others only in the report
+		unresolved []string
+		cyclic     []string
+	}{
+		{
+			name:   "plain values",
+			colors: map[string]string{"bg": "#101010", "fg": "#f0f0f0"},
+			want:   map[string]string{"bg": "#101010", "fg": "#f0f0f0"},
+		},
+		{
+			name: "chained references",
+			colors: map[string]string{
+				"base":    "#2e3440",
+				"bg":      "@base",
+				"view_bg": "@bg",
+				"popover": "@view_bg",
+			},
+			want: map[string]string{"bg": "#2e3440", "view_bg": "#2e3440", "popover": "#2e3440"},
+		},
+		{
+			name: "several references in one value",
+			colors: map[string]string{
+				"a":     "#ff0000",
+				"b":     "#0000ff",
+				"alias": "@b",
+				"mixed": "mix(@a, @alias, 0.5)",
+			},
+			want: map[string]string{"mixed": "mix(#ff0000, #0000ff, 0.5)"},
+		},
+		{
+			name:   "self cycle",
+			colors: map[string]string{"loop": "@loop", "ok": "#abcdef"},
+			want:   map[string]string{"ok": "#abcdef"},
+			cyclic: []string{"loop"},
+		},
+		{
+			name: "mutual cycle",
+			colors: map[string]string{
+				"a":    "@b",
+				"b":    "@a",
+				"uses": "@a",
+				"ok":   "#abcdef",
+			},
+			want:   map[string]string{"ok": "#abcdef"},
+			cyclic: []string{"a", "b", "uses"},
+		},
+		{
+			name: "unknown reference",
+			colors: map[string]string{
+				"bg":    "@missing",
+				"shade": "shade(@bg, 0.9)",
+				"ok":    "#abcdef",
+			},
+			want:       map[string]string{"bg": "@missing", "shade": "shade(@missing, 0.9)", "ok": "#abcdef"},
+			unresolved: []string{"bg"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := &ColorExtractor{}
+			got := ce.resolveColorReferences(tt.colors)
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%s = %q, want %q", name, got[name], want)
+				}
+			}
+			for _, name := range tt.cyclic {
+				if !strings.Contains(got[name], "@") {
+					t.Errorf("%s = %q, cyclic values should keep their references", name, got[name])
+				}
+			}
+			if report := ce.Report(); !reflect.DeepEqual(report.Unresolved, tt.unresolved) {
+				t.Errorf("unresolved = %v, want %v", report.Unresolved, tt.unresolved)
+			}
+			if report := ce.Report(); !reflect.DeepEqual(report.Cyclic, tt.cyclic) {
+				t.Errorf("cyclic = %v, want %v", report.Cyclic, tt.cyclic)
+			}
+		})
+	}
+}
+
+func TestResolveColorReferencesDepthLimit(t *testing.T) {
+	// names sort from the head of the chain, so that it's followed from there
+	last := maxColorRefDepth + 10
+	colors := map[string]string{fmt.Sprintf("c%04d", last): "#123456"}
+	for i := 0; i < last; i++ {
+		colors[fmt.Sprintf("c%04d", i)] = fmt.Sprintf("@c%04d", i+1)
+	}
+	ce := &ColorExtractor{}
+	got := ce.resolveColorReferences(colors)
+	if tail := fmt.Sprintf("c%04d", last-1); got[tail] != "#123456" {
+		t.Errorf("%s = %q, want #123456", tail, got[tail])
+	}
+	if len(ce.Report().Cyclic) == 0 {
+		t.Errorf("chain longer than %d not reported", maxColorRefDepth)
+	}
+}
+
+func BenchmarkResolveColorReferences(b *testing.B) {
+	// the shape of a large theme: base colors, and layers of aliases and shades referring to them
+	colors := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		colors[fmt.Sprintf("base%d", i)] = fmt.Sprintf("#%06x", i*0x020202)
+	}
+	for layer := 1; layer <= 5; layer++ {
+		for i := 0; i < 100; i++ {
+			prev := fmt.Sprintf("base%d", i)
+			if layer > 1 {
+				prev = fmt.Sprintf("l%d_%d", layer-1, i)
+			}
+			colors[fmt.Sprintf("l%d_%d", layer, i)] = fmt.Sprintf("shade(@%s, 0.9)", prev)
+		}
+	}
+	ce := &ColorExtractor{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ce.resolveColorReferences(colors)
+	}
+}
@@ -0,0 +1,139 @@
+// colormapping.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ColorMappingRule fills a palette slot from the first GTK color found in Sources.
+// A source is a color name, or a regular expression on color names when prefixed with "re:".
+type ColorMappingRule struct {
+	Slot    string   `json:"slot"`
+	Sources []string `json:"sources"`
+}
+
+func colorMappingFile() string {
+	return filepath.Join(configDir(), "color-mapping.json")
+}
+
+func defaultColorMapping() []ColorMappingRule {
+	return []ColorMappingRule{
+		{"background", []string{"theme_bg_color", "theme_base_color", "window_bg_color", "bg_color"}},
+		{"foreground", []string{"theme_fg_color", "theme_text_color", "window_fg_color", "fg_color"}},
+		{"cursor", []string{"cursor_color", "theme_fg_color", "theme_text_color"}},
+		{"color4", []string{"theme_selected_bg_color", "accent_bg_color", "accent_color", "selected_bg_color"}},
+		{"color3", []string{"warning_color"}},
+		{"color1", []string{"error_color"}},
+		{"color2", []string{"success_color"}},
+	}
+}
+
+// isPaletteSlot tells if the name is background, foreground, cursor or color0-color15
+func isPaletteSlot(name string) bool {
+	switch name {
+	case "background", "foreground", "cursor":
+		return true
+	}
+	for i := 0; i < 16; i++ {
+		if name == fmt.Sprintf("color%d", i) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadColorMapping reads user rules, creating the file with defaults on first run
+func loadColorMapping() []ColorMappingRule {
+	mappingFile := colorMappingFile()
+	if !pathExists(mappingFile) {
+		rules := defaultColorMapping()
+		if readOnly {
+			return rules
+		}
+		data, err := json.MarshalIndent(rules, "", "  ")
+		if err == nil {
+			makeDir(filepath.Dir(mappingFile))
+			if err := os.WriteFile(mappingFile, data, 0644); err != nil {
+				log.Warnf("Failed to create %s: %v", mappingFile, err)
+			}
+		}
+		return rules
+	}
+
+	data, err := os.ReadFile(mappingFile)
+	if err != nil {
+		log.Warnf("Failed to read %s: %v", mappingFile, err)
+		return defaultColorMapping()
+	}
+	var rules []ColorMappingRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		log.Warnf("Failed to parse %s, using defaults: %v", mappingFile, err)
+		return defaultColorMapping()
+	}
+
+	var valid []ColorMappingRule
+	for _, rule := range rules {
+		if !isPaletteSlot(rule.Slot) {
+			log.Warnf("Color mapping: unknown slot '%s' ignored", rule.Slot)
+			continue
+		}
+		valid = append(valid, rule)
+	}
+	log.Debugf("Loaded %v color mapping rules", len(valid))
+	return valid
+}
+
+// lookup returns the value of the first source found in colors, skipping unresolved references
+func (rule ColorMappingRule) lookup(colors map[string]string) (string, bool) {
+	usable := func(name string) bool {
+		value, exists := colors[name]
+		return exists && !strings.Contains(value, "@")
+	}
+	for _, source := range rule.Sources {
+		if strings.HasPrefix(source, "re:") {
+			re, err := regexp.Compile(strings.TrimPrefix(source, "re:"))
+			if err != nil {
+				log.Warnf("Color mapping: invalid regex '%s': %v", source, err)
+				continue
+			}
+			// sorted, so that the result doesn't depend on map order
+			var names []string
+			for name := range colors {
+				if re.MatchString(name) && usable(name) {
+					names = append(names, name)
+				}
+			}
+			if len(names) > 0 {
+				sort.Strings(names)
+				return colors[names[0]], true
+			}
+			continue
+		}
+		if usable(source) {
+			return colors[source], true
+		}
+	}
+	return "", false
+}
+
+// setSlot assigns a value to a palette slot
+func (p *ColorPalette) setSlot(slot, value string) {
+	switch slot {
+	case "background":
+		p.Background = value
+	case "foreground":
+		p.Foreground = value
+	case "cursor":
+		p.Cursor = value
+	default:
+		p.Colors[slot] = value
+	}
+}
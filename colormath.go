@@ -0,0 +1,188 @@
+// colormath.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// hexToRGB parses #rrggbb (or #rgb) into 0-255 components
+func hexToRGB(hex string) (int, int, int, error) {
+	h := strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) == 8 {
+		// drop alpha
+		h = h[:6]
+	}
+	if len(h) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid hex color: '%s'", hex)
+	}
+	v, err := strconv.ParseUint(h, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hex color: '%s'", hex)
+	}
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff), nil
+}
+
+func rgbToHex(r, g, b int) string {
+	return fmt.Sprintf("#%02x%02x%02x", clamp255(r), clamp255(g), clamp255(b))
+}
+
+func clamp255(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
+
+// mixColors blends a towards b by t (0.0 = a, 1.0 = b)
+func mixColors(a, b string, t float64) string {
+	r1, g1, b1, err := hexToRGB(a)
+	if err != nil {
+		return a
+	}
+	r2, g2, b2, err := hexToRGB(b)
+	if err != nil {
+		return a
+	}
+	mix := func(x, y int) int {
+		return int(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return rgbToHex(mix(r1, r2), mix(g1, g2), mix(b1, b2))
+}
+
+// relativeLuminance returns the WCAG relative luminance of a hex color
+func relativeLuminance(hex string) float64 {
+	r, g, b, err := hexToRGB(hex)
+	if err != nil {
+		return 0
+	}
+	lin := func(c int) float64 {
+		v := float64(c) / 255.0
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*lin(r) + 0.7152*lin(g) + 0.0722*lin(b)
+}
+
+// isDarkColor tells if text on this background should be light
+func isDarkColor(hex string) bool {
+	return relativeLuminance(hex) < 0.179
+}
+
+// contrastRatio returns the WCAG contrast ratio of two colors, 1 to 21
+func contrastRatio(a, b string) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// hexToHSL converts a hex color to hue (0-360), saturation and lightness (0-1)
+func hexToHSL(hex string) (float64, float64, float64, error) {
+	r, g, b, err := hexToRGB(hex)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	l := (max + min) / 2
+	if max == min {
+		return 0, 0, l, nil
+	}
+	d := max - min
+	s := d / (1 - math.Abs(2*l-1))
+	var h float64
+	switch max {
+	case rf:
+		h = math.Mod((gf-bf)/d, 6)
+	case gf:
+		h = (bf-rf)/d + 2
+	default:
+		h = (rf-gf)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l, nil
+}
+
+// hslToHex converts hue (0-360), saturation and lightness (0-1) to a hex color
+func hslToHex(h, s, l float64) string {
+	s = math.Max(0, math.Min(1, s))
+	l = math.Max(0, math.Min(1, l))
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	to255 := func(v float64) int {
+		return int(math.Round((v + m) * 255))
+	}
+	return rgbToHex(to255(r), to255(g), to255(b))
+}
+
+// extendedColors derives color16-color255 from the palette: xterm's 6x6x6 color cube, with the background,
+// colors 1-6 and the foreground at its corners instead of pure RGB, and a 24-step grayscale ramp from
+// the background to the foreground
+func extendedColors(p *ColorPalette) map[string]string {
+	c := p.Colors
+	colors := make(map[string]string, 240)
+	for r := 0; r < 6; r++ {
+		tr := float64(r) / 5
+		c0 := mixColors(p.Background, c["color1"], tr)
+		c1 := mixColors(c["color2"], c["color3"], tr)
+		c2 := mixColors(c["color4"], c["color5"], tr)
+		c3 := mixColors(c["color6"], p.Foreground, tr)
+		for g := 0; g < 6; g++ {
+			tg := float64(g) / 5
+			c4 := mixColors(c0, c1, tg)
+			c5 := mixColors(c2, c3, tg)
+			for b := 0; b < 6; b++ {
+				colors[fmt.Sprintf("color%d", 16+36*r+6*g+b)] = mixColors(c4, c5, float64(b)/5)
+			}
+		}
+	}
+	for i := 0; i < 24; i++ {
+		colors[fmt.Sprintf("color%d", 232+i)] = mixColors(p.Background, p.Foreground, float64(i+1)/25)
+	}
+	return colors
+}
+
+// correctColor applies gamma (out = in^(1/gamma)) and then brightness (a multiplier) to each channel
+func correctColor(hex string, gamma, brightness float64) string {
+	r, g, b, err := hexToRGB(hex)
+	if err != nil {
+		return hex
+	}
+	correct := func(c int) int {
+		v := math.Pow(float64(c)/255, 1/gamma) * brightness
+		return int(math.Round(v * 255))
+	}
+	return rgbToHex(correct(r), correct(g), correct(b))
+}
@@ -0,0 +1,318 @@
+// colortargets.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// colorTarget binds a template to its application and default destination
+type colorTarget struct {
+	template string
+	app      string
+	dest     string // relative to configHome() (or to targetBaseDirs), unless absolute or starting with "~/"
+	enabled  bool   // default state in a fresh config
+	// the default template; nil for community templates, see templatestore.go
+	content func(*TemplateManager) string
+}
+
+var colorTargets = []colorTarget{
+	{"alacritty.yml", "alacritty", "alacritty/colors.yml", true, (*TemplateManager).alacrittyTemplate},
+	{"waybar-colors.css", "waybar", "waybar/colors.css", true, (*TemplateManager).waybarTemplate},
+	{"kitty.conf", "kitty", "kitty/theme.conf", true, (*TemplateManager).kittyTemplate},
+	{"rofi-colors.rasi", "rofi", "rofi/colors.rasi", true, (*TemplateManager).rofiTemplate},
+	{"dunst-colors.conf", "dunst", "dunst/dunstrc-colors", true, (*TemplateManager).dunstTemplate},
+	{"foot.ini", "foot", "foot/colors.ini", true, (*TemplateManager).footTemplate},
+	{"termite-colors.ini", "termite", "termite/colors", false, (*TemplateManager).termiteTemplate},
+	{"wezterm.toml", "wezterm", "wezterm/colors/nwg-look.toml", false, (*TemplateManager).weztermTemplate},
+	{"ghostty", "ghostty", "ghostty/themes/nwg-look", false, (*TemplateManager).ghosttyTemplate},
+	{"vtrgb", "console", "vtrgb", false, (*TemplateManager).vtrgbTemplate},
+	{"zathura-colors", "zathura", "zathura/nwg-colors", false, (*TemplateManager).zathuraTemplate},
+	{"mako-colors", "mako", "mako/colors", false, (*TemplateManager).makoTemplate},
+	{"swayosd-colors.css", "swayosd", "swayosd/nwg-colors.css", false, (*TemplateManager).swayosdTemplate},
+	{"wob.ini", "wob", "wob/wob.ini", false, (*TemplateManager).wobTemplate},
+	{"avizo.ini", "avizo", "avizo/config.ini", false, (*TemplateManager).avizoTemplate},
+	{"swaylock-colors", "swaylock", "swaylock/colors", false, (*TemplateManager).swaylockTemplate},
+	{"wofi-colors.css", "wofi", "wofi/colors.css", false, (*TemplateManager).wofiTemplate},
+	{"fuzzel-colors.ini", "fuzzel", "fuzzel/colors.ini", false, (*TemplateManager).fuzzelTemplate},
+	{"hyprland-colors.conf", "hyprland", "hypr/colors.conf", false, (*TemplateManager).hyprlandTemplate},
+	{"hyprlock-colors.conf", "hyprlock", "hypr/hyprlock-colors.conf", false, (*TemplateManager).hyprlockTemplate},
+	{"sway-colors", "sway", "sway/colors", false, (*TemplateManager).swayTemplate},
+	{"nwg-drawer-colors.css", "nwg-drawer", "nwg-drawer/colors.css", false, (*TemplateManager).nwgDrawerTemplate},
+	{"nwg-menu-colors.css", "nwg-menu", "nwg-panel/menu-start-colors.css", false, (*TemplateManager).nwgMenuTemplate},
+	{"nwg-panel-colors.css", "nwg-panel", "nwg-panel/colors.css", false, (*TemplateManager).nwgPanelTemplate},
+	{"nwg-dock-colors.css", "nwg-dock", "nwg-dock/colors.css", false, (*TemplateManager).nwgDockTemplate},
+	{"nwg-dock-hyprland-colors.css", "nwg-dock-hyprland", "nwg-dock-hyprland/colors.css", false, (*TemplateManager).nwgDockTemplate},
+	{"nwg-bar-colors.css", "nwg-bar", "nwg-bar/colors.css", false, (*TemplateManager).nwgBarTemplate},
+	{"i3-colors", "i3", "i3/colors", false, (*TemplateManager).i3Template},
+	{"polybar-colors.ini", "polybar", "polybar/colors.ini", false, (*TemplateManager).polybarTemplate},
+	{"vim-colors.vim", "vim", "~/.vim/colors/nwg-look.vim", false, (*TemplateManager).vimTemplate},
+	{"helix.toml", "helix", "helix/themes/nwg-look.toml", false, (*TemplateManager).helixTemplate},
+	{"nwg-look.micro", "micro", "micro/colorschemes/nwg-look.micro", false, (*TemplateManager).microTemplate},
+	{"nwg-look.nanorc", "nano", "nano/nwg-look.nanorc", false, (*TemplateManager).nanoTemplate},
+	{"nwg-look-theme.el", "emacs", "~/.emacs.d/themes/nwg-look-theme.el", false, (*TemplateManager).emacsTemplate},
+	{"gtksourceview-4.xml", "gtksourceview", "~/.local/share/gtksourceview-4/styles/nwg-look.xml", false, (*TemplateManager).gtkSourceViewTemplate},
+	{"gtksourceview-5.xml", "gtksourceview", "~/.local/share/gtksourceview-5/styles/nwg-look.xml", false, (*TemplateManager).gtkSourceViewTemplate},
+	{"kate-theme.json", "kate", "~/.local/share/org.kde.syntax-highlighting/themes/nwg-look.theme", false, (*TemplateManager).kateTemplate},
+	{"vscode-colors.json", "vscode", "Code/User/settings.json", false, (*TemplateManager).vscodeTemplate},
+	{"bat.tmTheme", "bat", "bat/themes/nwg-look.tmTheme", false, (*TemplateManager).batTemplate},
+	{"delta.gitconfig", "delta", "delta/nwg-look.gitconfig", false, (*TemplateManager).deltaTemplate},
+	{"fzf-colors", "fzf", "fzf/colors", false, (*TemplateManager).fzfTemplate},
+	{"nwg-look-colors.fish", "fish", "fish/conf.d/nwg-look-colors.fish", false, (*TemplateManager).fishTemplate},
+	{"zsh-highlight.zsh", "zsh", "zsh/nwg-look-highlight.zsh", false, (*TemplateManager).zshTemplate},
+	{"lazygit-colors.yml", "lazygit", "lazygit/nwg-colors.yml", false, (*TemplateManager).lazygitTemplate},
+	{"mc-skin.ini", "mc", "~/.local/share/mc/skins/nwg-look.ini", false, (*TemplateManager).mcTemplate},
+	{"newsboat-colors", "newsboat", "newsboat/nwg-colors", false, (*TemplateManager).newsboatTemplate},
+	{"ncmpcpp-colors", "ncmpcpp", "ncmpcpp/nwg-colors", false, (*TemplateManager).ncmpcppTemplate},
+	{"spicetify-color.ini", "spicetify", "spicetify/Themes/nwg-look/color.ini", false, (*TemplateManager).spicetifyTemplate},
+	{"obsidian-snippet.css", "obsidian", ".obsidian/snippets/nwg-look.css", false, (*TemplateManager).obsidianTemplate},
+	{"eww-colors.scss", "eww", "eww/_nwg-colors.scss", false, (*TemplateManager).ewwTemplate},
+	{"ags-colors.scss", "ags", "ags/_nwg-colors.scss", false, (*TemplateManager).agsTemplate},
+	{"wlogout-colors.css", "wlogout", "wlogout/colors.css", false, (*TemplateManager).wlogoutTemplate},
+	{"qutebrowser-colors.py", "qutebrowser", "qutebrowser/nwg-colors.py", false, (*TemplateManager).qutebrowserTemplate},
+	{"firefox-colors.css", "firefox", "chrome/nwg-colors.css", false, (*TemplateManager).firefoxTemplate},
+	{"chrome-theme.json", "chrome", "chrome-theme/manifest.json", false, (*TemplateManager).chromeTemplate},
+}
+
+// DestinationOptions overrides where and how a template output is written
+type DestinationOptions struct {
+	Path  string `json:"path,omitempty"`
+	Mode  string `json:"mode,omitempty"`  // octal, e.g. "0600"
+	Owner string `json:"owner,omitempty"` // user name or uid
+	Group string `json:"group,omitempty"` // group name or gid
+	// Color correction at render time, for applications rendering colors differently
+	Gamma      float64 `json:"gamma,omitempty"`      // > 1 lightens midtones, < 1 darkens them
+	Brightness float64 `json:"brightness,omitempty"` // channel multiplier, e.g. 0.9
+	// "inject" writes between "nwg-look start" and "nwg-look end" markers of the file, see inject.go
+	Write string `json:"write,omitempty"`
+}
+
+const defaultDestinationMode os.FileMode = 0644
+
+// expandPath resolves "~/" and paths relative to the config home
+func expandPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[2:])
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(configHome(), path)
+}
+
+// targetBaseDirs resolve, at apply time, the directory the default destination is relative to
+var targetBaseDirs = map[string]func() (string, error){
+	"firefox-colors.css":   firefoxProfileDir,
+	"obsidian-snippet.css": obsidianVaultDir,
+	// nwg-look's own files, kept with its config, wherever that is
+	"vtrgb":             nwgConfigDir,
+	"chrome-theme.json": nwgConfigDir,
+}
+
+func nwgConfigDir() (string, error) {
+	return configDir(), nil
+}
+
+// destPath returns the output path, honouring a user override. Returns "" if the base directory
+// of the default destination can't be found.
+func (t colorTarget) destPath(opts *DestinationOptions) string {
+	if opts != nil && opts.Path != "" {
+		return expandPath(opts.Path)
+	}
+	if baseDir, ok := targetBaseDirs[t.template]; ok {
+		dir, err := baseDir()
+		if err != nil {
+			log.Warnf("Skipping %s: %v", t.template, err)
+			return ""
+		}
+		return filepath.Join(dir, t.dest)
+	}
+	return expandPath(t.dest)
+}
+
+// targetPath returns the output path of the template, "" if unknown
+func targetPath(template string) string {
+	for _, t := range colorTargets {
+		if t.template == template {
+			return t.destPath(colorSyncManager.config.Destinations[template])
+		}
+	}
+	return ""
+}
+
+// fileMode parses the octal mode, refusing special bits and world-writable files
+func (o *DestinationOptions) fileMode() (os.FileMode, error) {
+	if o == nil || o.Mode == "" {
+		return defaultDestinationMode, nil
+	}
+	v, err := strconv.ParseUint(o.Mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode '%s': expected octal, e.g. 0644", o.Mode)
+	}
+	mode := os.FileMode(v)
+	if mode&^os.ModePerm != 0 {
+		return 0, fmt.Errorf("invalid mode '%s': setuid, setgid and sticky bits are not allowed", o.Mode)
+	}
+	if mode&0002 != 0 {
+		return 0, fmt.Errorf("invalid mode '%s': world-writable files are not allowed", o.Mode)
+	}
+	if mode&0600 != 0600 {
+		return 0, fmt.Errorf("invalid mode '%s': the owner must be able to read and write", o.Mode)
+	}
+	return mode, nil
+}
+
+// ownership resolves owner and group to numeric ids, -1 meaning "leave unchanged"
+func (o *DestinationOptions) ownership() (int, int, error) {
+	uid, gid := -1, -1
+	if o == nil {
+		return uid, gid, nil
+	}
+	current, err := user.Current()
+	if err != nil {
+		return uid, gid, err
+	}
+	isRoot := current.Uid == "0"
+
+	if o.Owner != "" {
+		u, err := user.Lookup(o.Owner)
+		if err != nil {
+			u, err = user.LookupId(o.Owner)
+		}
+		if err != nil {
+			return uid, gid, fmt.Errorf("unknown owner '%s'", o.Owner)
+		}
+		if u.Uid != current.Uid && !isRoot {
+			return uid, gid, fmt.Errorf("owner '%s': only root may give files away", o.Owner)
+		}
+		uid, _ = strconv.Atoi(u.Uid)
+	}
+
+	if o.Group != "" {
+		g, err := user.LookupGroup(o.Group)
+		if err != nil {
+			g, err = user.LookupGroupId(o.Group)
+		}
+		if err != nil {
+			return uid, gid, fmt.Errorf("unknown group '%s'", o.Group)
+		}
+		if !isRoot {
+			groupIds, _ := current.GroupIds()
+			if !isIn(groupIds, g.Gid) {
+				return uid, gid, fmt.Errorf("group '%s': you are not a member", o.Group)
+			}
+		}
+		gid, _ = strconv.Atoi(g.Gid)
+	}
+	return uid, gid, nil
+}
+
+// corrected returns the palette with gamma and brightness correction applied, or the palette itself if none is set
+func (o *DestinationOptions) corrected(p *ColorPalette) *ColorPalette {
+	if o == nil || (o.Gamma == 0 || o.Gamma == 1) && (o.Brightness == 0 || o.Brightness == 1) {
+		return p
+	}
+	gamma, brightness := o.Gamma, o.Brightness
+	if gamma == 0 {
+		gamma = 1
+	}
+	if brightness == 0 {
+		brightness = 1
+	}
+	c := &ColorPalette{
+		Background: correctColor(p.Background, gamma, brightness),
+		Foreground: correctColor(p.Foreground, gamma, brightness),
+		Cursor:     correctColor(p.Cursor, gamma, brightness),
+		Colors:     make(map[string]string, len(p.Colors)),
+	}
+	for name, value := range p.Colors {
+		c.Colors[name] = correctColor(value, gamma, brightness)
+	}
+	return c
+}
+
+// validate checks the options without touching any file
+func (o *DestinationOptions) validate() error {
+	if o.Gamma != 0 && (o.Gamma < 0.2 || o.Gamma > 5) {
+		return fmt.Errorf("invalid gamma %v: expected 0.2 to 5", o.Gamma)
+	}
+	if o.Brightness != 0 && (o.Brightness < 0.2 || o.Brightness > 2) {
+		return fmt.Errorf("invalid brightness %v: expected 0.2 to 2", o.Brightness)
+	}
+	if o.Write != "" && o.Write != writeReplace && o.Write != writeInject {
+		return fmt.Errorf("invalid write '%s': expected %s or %s", o.Write, writeReplace, writeInject)
+	}
+	if _, err := o.fileMode(); err != nil {
+		return err
+	}
+	_, _, err := o.ownership()
+	return err
+}
+
+// validateDestinations drops invalid destination options, so that safe defaults apply
+func validateDestinations(destinations map[string]*DestinationOptions) {
+	for name, opts := range destinations {
+		if !isColorTemplate(name) {
+			log.Warnf("Destination options for unknown template '%s' ignored", name)
+			delete(destinations, name)
+			continue
+		}
+		if opts == nil {
+			log.Warnf("Destination options for '%s' ignored: null", name)
+			delete(destinations, name)
+			continue
+		}
+		if err := opts.validate(); err != nil {
+			log.Warnf("Destination options for '%s' ignored: %v", name, err)
+			delete(destinations, name)
+		}
+	}
+}
+
+// applyFileOptions sets the mode and ownership configured for a written destination
+func applyFileOptions(path string, opts *DestinationOptions) error {
+	// the mode is only enforced if set, existing files keep theirs otherwise
+	if opts != nil && opts.Mode != "" {
+		mode, err := opts.fileMode()
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+	}
+	uid, gid, err := opts.ownership()
+	if err != nil {
+		return err
+	}
+	if uid != -1 || gid != -1 {
+		return os.Chown(path, uid, gid)
+	}
+	return nil
+}
+
+func isColorTemplate(name string) bool {
+	for _, t := range colorTargets {
+		if t.template == name {
+			return true
+		}
+	}
+	return false
+}
+
+// colorApps returns application names in registry order, without duplicates
+func colorApps() []string {
+	var apps []string
+	for _, t := range colorTargets {
+		if !isIn(apps, t.app) {
+			apps = append(apps, t.app)
+		}
+	}
+	return apps
+}
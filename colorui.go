@@ -1,21 +1,22 @@
 // colorui.go
+//go:build !headless
+
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/gotk3/gotk3/cairo"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
 	log "github.com/sirupsen/logrus"
 )
 
-var colorSyncManager *ColorSyncManager
-
-// initColorSync initializes the color sync manager
-func initColorSync() {
-	colorSyncManager = NewColorSyncManager()
-	log.Debug("Color sync manager initialized")
-}
-
 // onThemeChanged is called when the GTK theme changes
 func onThemeChanged(themeName string) {
 	if colorSyncManager == nil {
@@ -31,9 +32,46 @@ func onThemeChanged(themeName string) {
 		if err := colorSyncManager.ApplyTheme(themeName); err != nil {
 			log.Warnf("Failed to apply theme colors: %v", err)
 		}
+		glib.IdleAdd(refreshAppBadges)
 	}()
 }
 
+// appBadges show the outcome of the last apply next to each application in the Color Sync tab
+var appBadges = make(map[string]*gtk.Label)
+
+func refreshAppBadges() {
+	for app, status := range appStatuses() {
+		if badge, ok := appBadges[app]; ok {
+			markup, tooltip := badgeMarkup(status)
+			badge.SetMarkup(markup)
+			badge.SetTooltipText(tooltip)
+		}
+	}
+}
+
+// badgeMarkup returns a short state for the grid, and the details for the tooltip
+func badgeMarkup(status AppStatus) (string, string) {
+	if status.Time == nil {
+		return "<small><span foreground='gray'>–</span></small>", "Not applied yet"
+	}
+	when := status.Time.Format("Jan 2 15:04")
+	if status.Time.Format("2006-01-02") == time.Now().Format("2006-01-02") {
+		when = status.Time.Format("15:04")
+	}
+	tooltip := fmt.Sprintf("Last applied %s\n%s", status.Time.Format("2006-01-02 15:04"), status.Detail)
+	switch status.State {
+	case statusOK:
+		return fmt.Sprintf("<small><span foreground='green'>✓ %s</span></small>", when), tooltip
+	case statusFailed:
+		return "<small><span foreground='red'>✗ failed</span></small>", tooltip
+	case statusMissing:
+		return "<small><span foreground='orange'>⚠ missing</span></small>", tooltip
+	case statusUnreferenced:
+		return "<small><span foreground='orange'>⚠ not included</span></small>", tooltip
+	}
+	return "<small><span foreground='gray'>skipped</span></small>", tooltip
+}
+
 // setUpColorSyncForm creates the color sync settings UI
 func setUpColorSyncForm() *gtk.Frame {
 	frame, _ := gtk.FrameNew(fmt.Sprintf("  %s  ", "Color Synchronization"))
@@ -83,6 +121,38 @@ func setUpColorSyncForm() *gtk.Frame {
 	autoBox.PackStart(autoSwitch, false, false, 0)
 	mainBox.PackStart(autoBox, false, false, 0)
 
+	// Extended palette
+	extendedBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	extendedLabel, _ := gtk.LabelNew("Extended 256-color palette (kitty, foot):")
+	extendedLabel.SetProperty("halign", gtk.ALIGN_START)
+	extendedLabel.SetTooltipText("Fill colors 16-255 with a color cube and grayscale ramp matching the palette, for TUI applications using them")
+	extendedBox.PackStart(extendedLabel, false, false, 0)
+
+	extendedSwitch, _ := gtk.SwitchNew()
+	extendedSwitch.SetActive(colorSyncManager.IsExtendedPalette())
+	extendedSwitch.Connect("state-set", func(s *gtk.Switch, state bool) {
+		colorSyncManager.SetExtendedPalette(state)
+		log.Infof("Color sync extended palette: %v", state)
+	})
+	extendedBox.PackStart(extendedSwitch, false, false, 0)
+	mainBox.PackStart(extendedBox, false, false, 0)
+
+	// Open terminals
+	liveBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	liveLabel, _ := gtk.LabelNew("Recolor open terminals:")
+	liveLabel.SetProperty("halign", gtk.ALIGN_START)
+	liveLabel.SetTooltipText("After applying, send the palette to terminals already open, as escape sequences (foot, alacritty, xterm and others)")
+	liveBox.PackStart(liveLabel, false, false, 0)
+
+	liveSwitch, _ := gtk.SwitchNew()
+	liveSwitch.SetActive(colorSyncManager.IsLiveTerminals())
+	liveSwitch.Connect("state-set", func(s *gtk.Switch, state bool) {
+		colorSyncManager.SetLiveTerminals(state)
+		log.Infof("Color sync live terminals: %v", state)
+	})
+	liveBox.PackStart(liveSwitch, false, false, 0)
+	mainBox.PackStart(liveBox, false, false, 0)
+
 	// Applications frame
 	appsFrame, _ := gtk.FrameNew("Applications")
 	appsFrame.SetProperty("margin-top", 12)
@@ -102,12 +172,27 @@ func setUpColorSyncForm() *gtk.Frame {
 		appName := app
 		cb, _ := gtk.CheckButtonNewWithLabel(capitalizeFirst(appName))
 		cb.SetActive(colorSyncManager.IsAppEnabled(appName))
+		if targets := appTemplates(appName); len(targets) > 1 {
+			var lines []string
+			for _, t := range targets {
+				lines = append(lines, fmt.Sprintf("%s → %s", t.template, t.dest))
+			}
+			cb.SetTooltipText("Templates:\n" + strings.Join(lines, "\n"))
+		}
 		cb.Connect("toggled", func() {
 			enabled := cb.GetActive()
 			colorSyncManager.SetAppEnabled(appName, enabled)
 			log.Debugf("App %s sync: %v", appName, enabled)
 		})
-		appsGrid.Attach(cb, col, row, 1, 1)
+		appBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+		appBox.PackStart(cb, false, false, 0)
+		badge, _ := gtk.LabelNew("")
+		appBox.PackStart(badge, false, false, 0)
+		appBadges[appName] = badge
+		if colorSyncManager.templates.usesOpacity(appName) {
+			appBox.PackStart(setUpOpacityScale(appName), false, false, 0)
+		}
+		appsGrid.Attach(appBox, col, row, 1, 1)
 
 		col++
 		if col > 2 {
@@ -116,27 +201,48 @@ func setUpColorSyncForm() *gtk.Frame {
 		}
 	}
 
+	refreshAppBadges()
+
+	appsBtnBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	appsBtnBox.SetProperty("halign", gtk.ALIGN_END)
+	appsBtnBox.SetProperty("margin-top", 6)
+	refreshBtn, _ := gtk.ButtonNewWithLabel("Refresh Status")
+	refreshBtn.SetTooltipText("Check again whether the generated files exist and are included by the applications' configs")
+	refreshBtn.Connect("clicked", refreshAppBadges)
+	appsBtnBox.PackStart(refreshBtn, false, false, 0)
+
+	storeBtn, _ := gtk.ButtonNewWithLabel("Get more templates…")
+	storeBtn.SetTooltipText("Install community templates for more applications")
+	storeBtn.Connect("clicked", showTemplateStoreDialog)
+	appsBtnBox.PackStart(storeBtn, false, false, 0)
+
+	backupsBtn, _ := gtk.ButtonNewWithLabel("Restore Backups…")
+	backupsBtn.SetTooltipText("Put back the files as they were before one of the last color applies")
+	backupsBtn.Connect("clicked", showBackupsDialog)
+	appsBtnBox.PackStart(backupsBtn, false, false, 0)
+	mainBox.PackStart(appsBtnBox, false, false, 0)
+
 	// Manual apply button
 	btnBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
 	btnBox.SetProperty("margin-top", 12)
-	
+
 	applyBtn, _ := gtk.ButtonNew()
 	applyBtn.SetLabel("Apply Colors Now")
 	applyBtn.SetProperty("hexpand", true)
-	
+
 	statusLabel, _ := gtk.LabelNew("")
 	statusLabel.SetProperty("halign", gtk.ALIGN_START)
 	statusLabel.SetLineWrap(true)
-	
+
 	applyBtn.Connect("clicked", func() {
 		themeName := gsettings.gtkTheme
 		if themeName == "" {
 			statusLabel.SetMarkup("<span foreground='red'>No theme selected</span>")
 			return
 		}
-		
+
 		statusLabel.SetMarkup(fmt.Sprintf("Applying colors from <b>%s</b>...", themeName))
-		
+
 		go func() {
 			err := colorSyncManager.ApplyTheme(themeName)
 			if err != nil {
@@ -144,75 +250,294 @@ func setUpColorSyncForm() *gtk.Frame {
 			} else {
 				statusLabel.SetMarkup("<span foreground='green'>✓ Colors applied successfully!</span>")
 			}
+			glib.IdleAdd(refreshAppBadges)
 		}()
 	})
-	
+
 	btnBox.PackStart(applyBtn, true, true, 0)
+
+	previewBtn, _ := gtk.ButtonNewWithLabel("Preview Changes")
+	previewBtn.SetTooltipText("Show what applying colors from the current theme would change in each file, without writing anything")
+	previewBtn.Connect("clicked", func() {
+		themeName := gsettings.gtkTheme
+		go func() {
+			var diffs bytes.Buffer
+			err := colorSyncManager.DryRunTheme(themeName, &diffs)
+			glib.IdleAdd(func() {
+				switch {
+				case err != nil:
+					statusLabel.SetMarkup(fmt.Sprintf("<span foreground='red'>✗ Error: %s</span>", err.Error()))
+				case diffs.Len() == 0:
+					statusLabel.SetMarkup("<span foreground='green'>✓ All color files are up to date</span>")
+				default:
+					showTemplatePreview("Changes", diffs.String())
+				}
+			})
+		}()
+	})
+	btnBox.PackStart(previewBtn, false, false, 0)
+
+	importBtn, _ := gtk.ButtonNewWithLabel("Import Palette…")
+	importBtn.SetTooltipText("Apply a palette from an nwg-look JSON export, a base16 scheme or pywal's colors.json")
+	importBtn.Connect("clicked", func() {
+		path := choosePaletteFile()
+		if path == "" {
+			return
+		}
+		palette, err := ImportPalette(path)
+		if err != nil {
+			statusLabel.SetMarkup(fmt.Sprintf("<span foreground='red'>✗ Error: %s</span>", err.Error()))
+			return
+		}
+		go func() {
+			err := colorSyncManager.ApplyPalette(palette, filepath.Base(path))
+			glib.IdleAdd(func() {
+				refreshAppBadges()
+				if err != nil {
+					statusLabel.SetMarkup(fmt.Sprintf("<span foreground='red'>✗ Error: %s</span>", err.Error()))
+				} else {
+					statusLabel.SetMarkup("<span foreground='green'>✓ Palette applied successfully!</span>")
+				}
+			})
+		}()
+	})
+	btnBox.PackStart(importBtn, false, false, 0)
+
+	gtkExportBtn, _ := gtk.ButtonNewWithLabel("Export to GTK")
+	gtkExportBtn.SetTooltipText("Define GTK named colors from the last palette in ~/.config/gtk-3.0/gtk.css and gtk-4.0/gtk.css")
+	gtkExportBtn.Connect("clicked", func() {
+		if err := ExportGtkColors(colorSyncManager.config.LastColors); err != nil {
+			statusLabel.SetMarkup(fmt.Sprintf("<span foreground='red'>✗ Error: %s</span>", err.Error()))
+		} else {
+			statusLabel.SetMarkup("<span foreground='green'>✓ GTK colors exported, restart GTK apps to see them</span>")
+		}
+	})
+	btnBox.PackStart(gtkExportBtn, false, false, 0)
 	mainBox.PackStart(btnBox, false, false, 0)
+
+	reverseCheck, _ := gtk.CheckButtonNewWithLabel("Export imported palettes to GTK")
+	reverseCheck.SetTooltipText("Palettes imported from a file, pywal or a pipeline also become GTK named colors, so that GTK applications adopt them. Applying a GTK theme brings its own colors back.")
+	reverseCheck.SetActive(colorSyncManager.IsGtkReverseSync())
+	reverseCheck.Connect("toggled", func() {
+		colorSyncManager.SetGtkReverseSync(reverseCheck.GetActive())
+	})
+	mainBox.PackStart(reverseCheck, false, false, 0)
+
+	greetdCheck, _ := gtk.CheckButtonNewWithLabel("Update the login screen (gtkgreet) on apply")
+	greetdCheck.SetTooltipText("Installs the gtkgreet style sheet and environment into /etc/greetd whenever they change, asking for authorization via polkit. See `nwg-look greetd install`.")
+	greetdCheck.SetActive(colorSyncManager.IsGreetdSync())
+	greetdCheck.Connect("toggled", func() {
+		colorSyncManager.SetGreetdSync(greetdCheck.GetActive())
+	})
+	mainBox.PackStart(greetdCheck, false, false, 0)
+
+	if pathExists(sddmThemesDir) {
+		sddmCheck, _ := gtk.CheckButtonNewWithLabel("Update the login screen (SDDM) on apply")
+		sddmCheck.SetTooltipText("Sets the colors in theme.conf.user of the current SDDM theme whenever they change, asking for authorization via polkit. Themes that don't read colors from their config are not affected.")
+		sddmCheck.SetActive(colorSyncManager.IsSddmSync())
+		sddmCheck.Connect("toggled", func() {
+			path := ""
+			if opts := colorSyncManager.config.Sddm; opts != nil {
+				path = opts.Path
+			}
+			colorSyncManager.SetSddmSync(sddmCheck.GetActive(), path)
+		})
+		mainBox.PackStart(sddmCheck, false, false, 0)
+	}
+
+	if names := colorSyncManager.pipelineNames(); len(names) > 0 {
+		pipelineBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+		pipelineBox.SetProperty("margin-top", 6)
+		pipelineLabel, _ := gtk.LabelNew("Pipeline:")
+		pipelineBox.PackStart(pipelineLabel, false, false, 0)
+
+		pipelineCombo, _ := gtk.ComboBoxTextNew()
+		for _, name := range names {
+			pipelineCombo.Append(name, name)
+		}
+		pipelineCombo.SetActive(0)
+		pipelineBox.PackStart(pipelineCombo, true, true, 0)
+
+		runBtn, _ := gtk.ButtonNewWithLabel("Run")
+		runBtn.SetTooltipText("Run the palette steps defined in \"pipelines\" in color-sync.json")
+		runBtn.Connect("clicked", func() {
+			name := pipelineCombo.GetActiveID()
+			statusLabel.SetMarkup(fmt.Sprintf("Running pipeline <b>%s</b>...", name))
+			go func() {
+				_, err := colorSyncManager.runPipeline(name)
+				glib.IdleAdd(func() {
+					if err != nil {
+						statusLabel.SetMarkup(fmt.Sprintf("<span foreground='red'>✗ Error: %s</span>", err.Error()))
+					} else {
+						statusLabel.SetMarkup("<span foreground='green'>✓ Pipeline finished</span>")
+					}
+				})
+			}()
+		})
+		pipelineBox.PackStart(runBtn, false, false, 0)
+		mainBox.PackStart(pipelineBox, false, false, 0)
+	}
 	mainBox.PackStart(statusLabel, false, false, 6)
 
 	// Current scheme info
 	if colorSyncManager.config.LastTheme != "" {
 		infoBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 6)
 		infoBox.SetProperty("margin-top", 12)
-		
+
 		sep, _ := gtk.SeparatorNew(gtk.ORIENTATION_HORIZONTAL)
 		infoBox.PackStart(sep, false, false, 6)
-		
+
 		infoLabel, _ := gtk.LabelNew("")
-		infoLabel.SetMarkup(fmt.Sprintf("<small>Last applied: <b>%s</b></small>", 
+		infoLabel.SetMarkup(fmt.Sprintf("<small>Last applied: <b>%s</b></small>",
 			colorSyncManager.config.LastTheme))
 		infoLabel.SetProperty("halign", gtk.ALIGN_START)
 		infoBox.PackStart(infoLabel, false, false, 0)
-		
+
 		if colorSyncManager.config.LastColors != nil {
 			palette := colorSyncManager.config.LastColors
 			colorBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
 			colorBox.SetProperty("margin-top", 6)
-			
+
 			// Show a few sample colors
-			samples := []struct{label, color string}{
-				{"BG", palette.Background},
-				{"FG", palette.Foreground},
-				{"R", palette.Colors["color1"]},
-				{"G", palette.Colors["color2"]},
-				{"B", palette.Colors["color4"]},
+			samples := []struct{ label, slot string }{
+				{"BG", "background"},
+				{"FG", "foreground"},
+				{"R", "color1"},
+				{"G", "color2"},
+				{"B", "color4"},
 			}
-			
+
 			for _, s := range samples {
+				// click a sample to copy its value, right-click to edit it
+				eventBox, _ := gtk.EventBoxNew()
+				color, slot := palette.slot(s.slot), s.slot
+				eventBox.SetTooltipText(fmt.Sprintf("%s, click to copy, right-click to edit", color))
 				box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 2)
-				
+				eventBox.Add(box)
+
 				lbl, _ := gtk.LabelNew(s.label)
 				lbl.SetMarkup(fmt.Sprintf("<small>%s</small>", s.label))
 				box.PackStart(lbl, false, false, 0)
-				
+
 				da, _ := gtk.DrawingAreaNew()
 				da.SetSizeRequest(40, 20)
-				da.Connect("draw", func(da *gtk.DrawingArea, cr *gtk.cairo.Context) {
+				da.Connect("draw", func(da *gtk.DrawingArea, cr *cairo.Context) {
 					// Parse hex color
-					r, g, b := parseHexColor(s.color)
+					r, g, b := parseHexColor(color)
 					cr.SetSourceRGB(r, g, b)
 					cr.Rectangle(0, 0, 40, 20)
 					cr.Fill()
 				})
 				box.PackStart(da, false, false, 0)
-				
-				colorBox.PackStart(box, false, false, 6)
+
+				eventBox.Connect("button-release-event", func(_ *gtk.EventBox, event *gdk.Event) {
+					if gdk.EventButtonNewFromEvent(event).Button() == gdk.BUTTON_SECONDARY {
+						edited := choosePaletteColor(slot, color)
+						if edited == "" {
+							return
+						}
+						color = edited
+						eventBox.SetTooltipText(fmt.Sprintf("%s, click to copy, right-click to edit", color))
+						da.QueueDraw()
+						statusLabel.SetMarkup(fmt.Sprintf("Setting <b>%s</b> to <b>%s</b>...", slot, color))
+						go func() {
+							err := colorSyncManager.SetPaletteColor(slot, edited)
+							glib.IdleAdd(func() {
+								refreshAppBadges()
+								if err != nil {
+									statusLabel.SetMarkup(fmt.Sprintf("<span foreground='red'>✗ Error: %s</span>", err.Error()))
+								} else {
+									statusLabel.SetMarkup(fmt.Sprintf("<span foreground='green'>✓ %s set to %s</span>", slot, edited))
+								}
+							})
+						}()
+						return
+					}
+					if err := copyToClipboard(color); err != nil {
+						statusLabel.SetMarkup(fmt.Sprintf("<span foreground='red'>✗ Error: %s</span>", err.Error()))
+					} else {
+						statusLabel.SetMarkup(fmt.Sprintf("Copied <b>%s</b>", color))
+					}
+				})
+
+				colorBox.PackStart(eventBox, false, false, 6)
 			}
-			
+
+			if clipboardHistoryAvailable() {
+				historyBtn, _ := gtk.ButtonNewWithLabel("To Clipboard History")
+				historyBtn.SetTooltipText("Add all palette colors to cliphist, to paste them from your clipboard picker")
+				historyBtn.SetProperty("valign", gtk.ALIGN_CENTER)
+				historyBtn.Connect("clicked", func() {
+					if err := storeInClipboardHistory(palette, "hex"); err != nil {
+						statusLabel.SetMarkup(fmt.Sprintf("<span foreground='red'>✗ Error: %s</span>", err.Error()))
+					} else {
+						statusLabel.SetMarkup("<span foreground='green'>✓ Palette added to the clipboard history</span>")
+					}
+				})
+				colorBox.PackEnd(historyBtn, false, false, 0)
+			}
+
 			infoBox.PackStart(colorBox, false, false, 0)
 		}
-		
+
 		mainBox.PackStart(infoBox, false, false, 0)
 	}
 
+	mainBox.PackStart(setUpRotationFrame(), false, false, 0)
+
 	// Help text
 	helpLabel, _ := gtk.LabelNew("")
 	helpLabel.SetMarkup(`<small><i>Tip: Include generated config files in your application configs:
 • Alacritty: import: - ~/.config/alacritty/colors.yml
 • Kitty: include ./theme.conf
 • Waybar: @import "colors.css"
-• Rofi: @import "colors.rasi"</i></small>`)
+• Rofi: @import "colors.rasi"
+• WezTerm: config.color_scheme = "nwg-look"
+• Ghostty: theme = nwg-look
+• Linux console: nwg-look console install
+• Zathura: include nwg-colors
+• Mako: include=~/.config/mako/colors
+• SwayOSD: @import url("nwg-colors.css"); in swayosd/style.css
+• wob: colors are set in wob/wob.ini, restart wob
+• avizo: colors are set in avizo/config.ini
+• Swaylock: swaylock -C ~/.config/swaylock/colors
+• Wofi: @import "colors.css"
+• Fuzzel: include=~/.config/fuzzel/colors.ini
+• Hyprland: source = ~/.config/hypr/colors.conf
+• hyprlock: source = ~/.config/hypr/hyprlock-colors.conf, then use $inner_color etc.
+• Sway: include ~/.config/sway/colors
+• nwg-drawer: @import url("colors.css"); in drawer.css
+• nwg-menu: @import url("menu-start-colors.css"); in nwg-panel/menu-start.css
+• i3: include ~/.config/i3/colors
+• Polybar: include-file = ~/.config/polybar/colors.ini
+• Vim: colorscheme nwg-look
+• Helix: theme = "nwg-look"
+• micro: "colorscheme": "nwg-look" in settings.json
+• nano: include ~/.config/nano/nwg-look.nanorc in nanorc
+• Emacs: (load-theme 'nwg-look t), with ~/.emacs.d/themes in custom-theme-load-path
+• GtkSourceView (gedit, GNOME Text Editor): pick the nwg-look style scheme
+• Kate / KWrite: pick the nwg-look color theme
+• VS Code: colors are merged into Code/User/settings.json
+• bat: --theme=nwg-look (the theme cache is rebuilt on apply)
+• delta: [include] path = ~/.config/delta/nwg-look.gitconfig
+• fzf: export FZF_DEFAULT_OPTS_FILE=~/.config/fzf/colors
+• fish: nothing to do, read from conf.d
+• zsh: source ~/.config/zsh/nwg-look-highlight.zsh
+• lazygit: add nwg-colors.yml to LG_CONFIG_FILE
+• Midnight Commander: skin=nwg-look in ~/.config/mc/ini
+• newsboat: include ~/.config/newsboat/nwg-colors
+• ncmpcpp: ncmpcpp -c ~/.config/ncmpcpp/config -c ~/.config/ncmpcpp/nwg-colors
+• Spicetify: spicetify config current_theme nwg-look color_scheme nwg
+• Obsidian: enable the nwg-look CSS snippet in Settings > Appearance
+• eww: @import "nwg-colors"; in eww.scss
+• AGS / Astal: @use "nwg-colors" as *; in style.scss
+• nwg-panel: @import url("colors.css"); in nwg-panel/style.css
+• nwg-dock, nwg-dock-hyprland: @import url("colors.css"); in style.css
+• nwg-bar: @import url("colors.css"); in nwg-bar/style.css
+• wlogout: @import url("colors.css"); in wlogout/style.css
+• qutebrowser: config.source('nwg-colors.py') in config.py
+• Firefox: @import "nwg-colors.css"; in <profile>/chrome/userChrome.css
+• Chromium / Chrome: load ` + glib.MarkupEscapeText(filepath.Dir(targetPath("chrome-theme.json"))) + ` as an unpacked extension, see nwg-look colors chrome</i></small>`)
 	helpLabel.SetLineWrap(true)
 	helpLabel.SetProperty("halign", gtk.ALIGN_START)
 	helpLabel.SetProperty("margin-top", 12)
@@ -221,16 +546,519 @@ func setUpColorSyncForm() *gtk.Frame {
 	return frame
 }
 
+// choosePaletteFile shows a file chooser for palette files, returns "" if cancelled
+func choosePaletteFile() string {
+	dialog, err := gtk.FileChooserDialogNewWith2Buttons("Import palette", nil, gtk.FILE_CHOOSER_ACTION_OPEN,
+		"Cancel", gtk.RESPONSE_CANCEL, "Open", gtk.RESPONSE_ACCEPT)
+	if err != nil {
+		log.Warn(err)
+		return ""
+	}
+	defer dialog.Destroy()
+
+	filter, _ := gtk.FileFilterNew()
+	filter.SetName("Palettes (*.json, *.yaml, *.yml)")
+	for _, pattern := range []string{"*.json", "*.yaml", "*.yml"} {
+		filter.AddPattern(pattern)
+	}
+	dialog.AddFilter(filter)
+
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		return dialog.GetFilename()
+	}
+	return ""
+}
+
+// askConflictResolution asks what to do with a destination file not generated by nwg-look.
+// Called from color sync goroutines: the dialog runs in the GTK main loop.
+func askConflictResolution(path string) string {
+	answer := make(chan string)
+	glib.IdleAdd(func() {
+		dialog := gtk.MessageDialogNew(nil, gtk.DIALOG_MODAL, gtk.MESSAGE_QUESTION, gtk.BUTTONS_NONE,
+			"%s was not generated by nwg-look.\nWhat should color sync do with it? Your choice will be remembered.", path)
+		dialog.AddButton("Skip", gtk.RESPONSE_REJECT)
+		dialog.AddButton("Write alongside", gtk.RESPONSE_APPLY)
+		dialog.AddButton("Overwrite (keep backup)", gtk.RESPONSE_ACCEPT)
+		response := dialog.Run()
+		dialog.Destroy()
+		switch response {
+		case gtk.RESPONSE_ACCEPT:
+			answer <- conflictOverwrite
+		case gtk.RESPONSE_APPLY:
+			answer <- conflictAlongside
+		default:
+			answer <- conflictSkip
+		}
+	})
+	return <-answer
+}
+
+// askConsent shows the files color sync is about to write for an application, before the first write
+func askConsent(app string, paths []string) string {
+	answer := make(chan string)
+	glib.IdleAdd(func() {
+		dialog := gtk.MessageDialogNew(nil, gtk.DIALOG_MODAL, gtk.MESSAGE_QUESTION, gtk.BUTTONS_NONE,
+			"Color sync is about to write %s configuration:\n\n%s\n\nAllow it?", app, strings.Join(paths, "\n"))
+		dialog.AddButton("Never", gtk.RESPONSE_REJECT)
+		dialog.AddButton("Ask each time", gtk.RESPONSE_APPLY)
+		dialog.AddButton("Always allow", gtk.RESPONSE_ACCEPT)
+		response := dialog.Run()
+		dialog.Destroy()
+		switch response {
+		case gtk.RESPONSE_ACCEPT:
+			answer <- consentAlways
+		case gtk.RESPONSE_APPLY:
+			answer <- consentAsk
+		case gtk.RESPONSE_REJECT:
+			answer <- consentNever
+		default:
+			answer <- ""
+		}
+	})
+	return <-answer
+}
+
+// choosePaletteColor lets the user pick a new value for a palette slot; "" if cancelled
+func choosePaletteColor(slot, current string) string {
+	dialog, _ := gtk.ColorChooserDialogNew(fmt.Sprintf("Edit %s", slot), nil)
+	defer dialog.Destroy()
+	dialog.SetUseAlpha(false)
+	r, g, b := parseHexColor(current)
+	dialog.SetRGBA(gdk.NewRGBA(r, g, b, 1))
+	if dialog.Run() != gtk.RESPONSE_OK {
+		return ""
+	}
+	rgba := dialog.GetRGBA()
+	return rgbToHex(int(rgba.GetRed()*255+0.5), int(rgba.GetGreen()*255+0.5), int(rgba.GetBlue()*255+0.5))
+}
+
+// setUpRotationFrame creates the profiles & theme rotation settings UI
+func setUpRotationFrame() *gtk.Frame {
+	rc := loadRotationConfig()
+
+	frame, _ := gtk.FrameNew("Profiles & Rotation")
+	frame.SetProperty("margin-top", 12)
+
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 6)
+	box.SetProperty("margin", 12)
+	frame.Add(box)
+
+	// Save current settings as a profile
+	profileBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	nameEntry, _ := gtk.EntryNew()
+	nameEntry.SetPlaceholderText("Profile name")
+	nameEntry.SetProperty("hexpand", true)
+	profileBox.PackStart(nameEntry, true, true, 0)
+
+	saveBtn, _ := gtk.ButtonNewWithLabel("Save current as profile")
+	profileBox.PackStart(saveBtn, false, false, 0)
+	box.PackStart(profileBox, false, false, 0)
+
+	// Compare with a saved profile, and switch to it
+	compareBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	profileCombo, _ := gtk.ComboBoxTextNew()
+	profileCombo.SetProperty("hexpand", true)
+	fillProfiles := func() {
+		profileCombo.RemoveAll()
+		for _, name := range listProfiles() {
+			profileCombo.Append(name, name)
+		}
+		profileCombo.SetActive(0)
+	}
+	fillProfiles()
+	compareBox.PackStart(profileCombo, true, true, 0)
+
+	compareBtn, _ := gtk.ButtonNewWithLabel("Compare…")
+	compareBtn.SetTooltipText("Show what switching to the profile would change")
+	compareBtn.Connect("clicked", func() {
+		if name := profileCombo.GetActiveID(); name != "" {
+			showProfileDiffDialog(name)
+		}
+	})
+	compareBox.PackStart(compareBtn, false, false, 0)
+	box.PackStart(compareBox, false, false, 0)
+
+	entriesLabel, _ := gtk.LabelNew("")
+	entriesLabel.SetLineWrap(true)
+	entriesLabel.SetProperty("halign", gtk.ALIGN_START)
+	updateEntries := func() {
+		if len(rc.Entries) == 0 {
+			entriesLabel.SetMarkup("<small>Rotation list is empty</small>")
+		} else {
+			entriesLabel.SetMarkup(fmt.Sprintf("<small>Rotation list: <b>%s</b></small>", strings.Join(rc.Entries, ", ")))
+		}
+	}
+	updateEntries()
+
+	saveBtn.Connect("clicked", func() {
+		name, _ := nameEntry.GetText()
+		name = strings.TrimSpace(name)
+		if err := saveProfile(profileFromGsettings(name)); err != nil {
+			log.Warn(err)
+			return
+		}
+		if !isIn(rc.Entries, name) {
+			rc.Entries = append(rc.Entries, name)
+			rc.save()
+			updateEntries()
+		}
+		nameEntry.SetText("")
+		fillProfiles()
+	})
+
+	// Rotation settings
+	rotBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+	rotLabel, _ := gtk.LabelNew("Rotate themes:")
+	rotBox.PackStart(rotLabel, false, false, 0)
+
+	rotSwitch, _ := gtk.SwitchNew()
+	rotSwitch.SetActive(rc.Enabled)
+	rotSwitch.Connect("state-set", func(s *gtk.Switch, state bool) {
+		rc.Enabled = state
+		rc.save()
+		log.Infof("Theme rotation enabled: %v", state)
+	})
+	rotBox.PackStart(rotSwitch, false, false, 0)
+
+	intervalCombo, _ := gtk.ComboBoxTextNew()
+	intervalCombo.Append("1h", "Hourly")
+	intervalCombo.Append("24h", "Daily")
+	intervalCombo.Append("168h", "Weekly")
+	if !intervalCombo.SetActiveID(rc.Interval) {
+		intervalCombo.Append(rc.Interval, rc.Interval)
+		intervalCombo.SetActiveID(rc.Interval)
+	}
+	intervalCombo.Connect("changed", func() {
+		rc.Interval = intervalCombo.GetActiveID()
+		rc.save()
+	})
+	rotBox.PackStart(intervalCombo, false, false, 0)
+
+	randomCb, _ := gtk.CheckButtonNewWithLabel("Random order")
+	randomCb.SetActive(rc.Random)
+	randomCb.Connect("toggled", func() {
+		rc.Random = randomCb.GetActive()
+		rc.save()
+	})
+	rotBox.PackStart(randomCb, false, false, 0)
+	box.PackStart(rotBox, false, false, 0)
+
+	btnBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	addBtn, _ := gtk.ButtonNewWithLabel("Add current theme")
+	addBtn.Connect("clicked", func() {
+		if !isIn(rc.Entries, gsettings.gtkTheme) {
+			rc.Entries = append(rc.Entries, gsettings.gtkTheme)
+			rc.save()
+			updateEntries()
+		}
+	})
+	btnBox.PackStart(addBtn, false, false, 0)
+
+	clearBtn, _ := gtk.ButtonNewWithLabel("Clear list")
+	clearBtn.Connect("clicked", func() {
+		rc.Entries = []string{}
+		rc.Index = -1
+		rc.save()
+		updateEntries()
+	})
+	btnBox.PackStart(clearBtn, false, false, 0)
+	box.PackStart(btnBox, false, false, 0)
+	box.PackStart(entriesLabel, false, false, 0)
+
+	return frame
+}
+
+// showProfileDiffDialog lists the differences between the current state and the profile,
+// and applies the profile if confirmed
+func showProfileDiffDialog(name string) {
+	p, err := resolveProfile(name)
+	if err != nil {
+		showMessage(gtk.MESSAGE_ERROR, err.Error())
+		return
+	}
+	items := diffProfile(p)
+
+	dialog, _ := gtk.DialogNew()
+	dialog.SetTitle(fmt.Sprintf("Profile '%s'", name))
+	dialog.SetDefaultSize(560, 480)
+	dialog.SetModal(true)
+	dialog.AddButton("Cancel", gtk.RESPONSE_CANCEL)
+	dialog.AddButton("Apply profile", gtk.RESPONSE_APPLY)
+
+	content, _ := dialog.GetContentArea()
+	scrolled, _ := gtk.ScrolledWindowNew(nil, nil)
+	scrolled.SetProperty("vexpand", true)
+	scrolled.SetProperty("margin", 6)
+	content.PackStart(scrolled, true, true, 0)
+
+	var lines []string
+	sections := []struct{ kind, title string }{
+		{"setting", "Settings"}, {"palette", "Palette"}, {"colors", "Color files"},
+	}
+	for _, section := range sections {
+		var sectionLines []string
+		for _, item := range items {
+			if item.Kind != section.kind {
+				continue
+			}
+			line := glib.MarkupEscapeText(item.Target)
+			switch {
+			case item.Status == auditMissing && item.Kind == "colors":
+				line += " <i>(new file)</i>"
+			case item.Status == auditMissing:
+				line += fmt.Sprintf(" <i>%s</i>", glib.MarkupEscapeText(item.Current))
+			case item.Kind == "palette":
+				line = fmt.Sprintf("%s: %s → %s", line, colorSwatchMarkup(item.Current), colorSwatchMarkup(item.Wanted))
+			case item.Wanted != "":
+				line += fmt.Sprintf(": %s → <b>%s</b>", glib.MarkupEscapeText(item.Current), glib.MarkupEscapeText(item.Wanted))
+			}
+			sectionLines = append(sectionLines, "  "+line)
+		}
+		if len(sectionLines) > 0 {
+			lines = append(lines, fmt.Sprintf("<b>%s</b>", section.title))
+			lines = append(lines, sectionLines...)
+			lines = append(lines, "")
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "Nothing would change")
+	}
+	label, _ := gtk.LabelNew("")
+	label.SetMarkup(strings.Join(lines, "\n"))
+	label.SetSelectable(true)
+	label.SetProperty("halign", gtk.ALIGN_START)
+	label.SetProperty("valign", gtk.ALIGN_START)
+	label.SetProperty("margin", 6)
+	scrolled.Add(label)
+
+	dialog.ShowAll()
+	response := dialog.Run()
+	dialog.Destroy()
+	if response == gtk.RESPONSE_APPLY {
+		go func() {
+			if err := applyProfile(p); err != nil {
+				log.Warn(err)
+			}
+		}()
+	}
+}
+
+// showTemplateStoreDialog lists community templates from the index, with preview, install and update
+func showTemplateStoreDialog() {
+	dialog, _ := gtk.DialogNew()
+	dialog.SetTitle("Community templates")
+	dialog.SetDefaultSize(600, 480)
+	dialog.SetModal(true)
+	dialog.AddButton("Close", gtk.RESPONSE_CLOSE)
+
+	content, _ := dialog.GetContentArea()
+	scrolled, _ := gtk.ScrolledWindowNew(nil, nil)
+	scrolled.SetProperty("vexpand", true)
+	scrolled.SetProperty("margin", 6)
+	content.PackStart(scrolled, true, true, 0)
+
+	listBox, _ := gtk.ListBoxNew()
+	listBox.SetSelectionMode(gtk.SELECTION_NONE)
+	scrolled.Add(listBox)
+
+	statusLabel, _ := gtk.LabelNew("Loading the template index…")
+	statusLabel.SetLineWrap(true)
+	statusLabel.SetProperty("halign", gtk.ALIGN_START)
+	statusLabel.SetProperty("margin", 6)
+	content.PackStart(statusLabel, false, false, 0)
+
+	changed := false
+
+	packBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	packBox.SetProperty("margin", 6)
+	packLabel, _ := gtk.LabelNew("Template pack:")
+	packBox.PackStart(packLabel, false, false, 0)
+	packEntry, _ := gtk.EntryNew()
+	packEntry.SetPlaceholderText("https://…/pack.tar.gz, https://…/repo.git or a local path")
+	packEntry.SetTooltipText("A tarball or git repository with templates listed in nwg-look-pack.json")
+	packBox.PackStart(packEntry, true, true, 0)
+	packBtn, _ := gtk.ButtonNewWithLabel("Install Pack")
+	packBtn.Connect("clicked", func() {
+		source, _ := packEntry.GetText()
+		source = strings.TrimSpace(source)
+		if source == "" {
+			return
+		}
+		statusLabel.SetText(fmt.Sprintf("Installing templates from %s…", source))
+		packBtn.SetSensitive(false)
+		go func() {
+			pack, contents, err := readTemplatePack(source)
+			// colorTargets are only changed on the main loop
+			glib.IdleAdd(func() {
+				var names []string
+				if err == nil {
+					names, err = colorSyncManager.installPackTemplates(pack, contents)
+				}
+				packBtn.SetSensitive(true)
+				if len(names) > 0 {
+					changed = true
+				}
+				if err != nil {
+					statusLabel.SetText(err.Error())
+					return
+				}
+				statusLabel.SetText(fmt.Sprintf("✓ Installed %s, colors will be written on the next apply", strings.Join(names, ", ")))
+			})
+		}()
+	})
+	packBox.PackStart(packBtn, false, false, 0)
+	content.PackStart(packBox, false, false, 0)
+	var index []CommunityTemplate
+	var rows []*gtk.ListBoxRow
+	var fill func()
+	fill = func() {
+		for _, r := range rows {
+			listBox.Remove(r)
+		}
+		rows = nil
+		for _, s := range templateStatuses(index) {
+			status := s
+			row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+			row.SetProperty("margin", 6)
+
+			label, _ := gtk.LabelNew("")
+			label.SetLineWrap(true)
+			label.SetProperty("halign", gtk.ALIGN_START)
+			markup := fmt.Sprintf("<b>%s</b> %s  <small>%s</small>", glib.MarkupEscapeText(status.App),
+				glib.MarkupEscapeText(status.Version), glib.MarkupEscapeText(status.Name))
+			if status.Description != "" {
+				markup += "\n" + glib.MarkupEscapeText(status.Description)
+			}
+			switch {
+			case status.ModifiedLocally:
+				markup += "\n<i>installed, edited locally</i>"
+			case status.UpdateAvailable:
+				markup += fmt.Sprintf("\n<i>installed: %s, update available</i>", glib.MarkupEscapeText(status.Installed))
+			case status.Installed != "":
+				markup += "\n<i>installed</i>"
+			}
+			label.SetMarkup(markup)
+			row.PackStart(label, true, true, 0)
+
+			previewBtn, _ := gtk.ButtonNewWithLabel("Preview")
+			previewBtn.SetProperty("valign", gtk.ALIGN_CENTER)
+			previewBtn.Connect("clicked", func() {
+				statusLabel.SetText(fmt.Sprintf("Downloading %s…", status.Name))
+				go func() {
+					text, err := downloadTemplate(status.CommunityTemplate)
+					glib.IdleAdd(func() {
+						if err != nil {
+							statusLabel.SetText(err.Error())
+							return
+						}
+						statusLabel.SetText("")
+						showTemplatePreview(status.Name, text)
+					})
+				}()
+			})
+			row.PackStart(previewBtn, false, false, 0)
+
+			if status.Installed == "" || status.UpdateAvailable && !status.ModifiedLocally {
+				action := "Install"
+				if status.Installed != "" {
+					action = "Update"
+				}
+				installBtn, _ := gtk.ButtonNewWithLabel(action)
+				installBtn.SetProperty("valign", gtk.ALIGN_CENTER)
+				installBtn.Connect("clicked", func() {
+					statusLabel.SetText(fmt.Sprintf("Installing %s…", status.Name))
+					go func() {
+						text, err := downloadTemplate(status.CommunityTemplate)
+						glib.IdleAdd(func() {
+							if err == nil {
+								err = colorSyncManager.installTemplateContent(status.CommunityTemplate, text)
+							}
+							if err != nil {
+								statusLabel.SetText(err.Error())
+								return
+							}
+							changed = true
+							statusLabel.SetText(fmt.Sprintf("✓ Installed %s, colors will be written on the next apply", status.Name))
+							fill()
+						})
+					}()
+				})
+				row.PackStart(installBtn, false, false, 0)
+			}
+			listBoxRow, _ := gtk.ListBoxRowNew()
+			listBoxRow.Add(row)
+			listBox.Add(listBoxRow)
+			rows = append(rows, listBoxRow)
+		}
+		listBox.ShowAll()
+	}
+
+	go func() {
+		idx, err := colorSyncManager.fetchTemplateIndex()
+		glib.IdleAdd(func() {
+			if err != nil {
+				statusLabel.SetText(fmt.Sprintf("Couldn't load the template index: %v", err))
+				return
+			}
+			index = idx
+			statusLabel.SetText(fmt.Sprintf("%v templates available", len(index)))
+			fill()
+		})
+	}()
+
+	dialog.ShowAll()
+	dialog.Run()
+	dialog.Destroy()
+	if changed {
+		// show checkboxes of new applications
+		displayColorSyncForm()
+	}
+}
+
+// showTemplatePreview shows the content of a downloaded template
+func showTemplatePreview(name, text string) {
+	dialog, _ := gtk.DialogNew()
+	dialog.SetTitle(name)
+	dialog.SetDefaultSize(560, 480)
+	dialog.SetModal(true)
+	dialog.AddButton("Close", gtk.RESPONSE_CLOSE)
+
+	content, _ := dialog.GetContentArea()
+	scrolled, _ := gtk.ScrolledWindowNew(nil, nil)
+	scrolled.SetProperty("vexpand", true)
+	scrolled.SetProperty("margin", 6)
+	content.PackStart(scrolled, true, true, 0)
+
+	view, _ := gtk.TextViewNew()
+	view.SetEditable(false)
+	view.SetMonospace(true)
+	buf, _ := view.GetBuffer()
+	buf.SetText(text)
+	scrolled.Add(view)
+
+	dialog.ShowAll()
+	dialog.Run()
+	dialog.Destroy()
+}
+
+// colorSwatchMarkup shows a color sample followed by its value
+func colorSwatchMarkup(color string) string {
+	if color == "" {
+		return "<i>none</i>"
+	}
+	return fmt.Sprintf("<span foreground='%s'>██</span> %s", glib.MarkupEscapeText(color), glib.MarkupEscapeText(color))
+}
+
 // parseHexColor converts hex color to RGB values (0.0-1.0)
 func parseHexColor(hex string) (float64, float64, float64) {
 	hex = strings.TrimPrefix(hex, "#")
 	if len(hex) != 6 {
 		return 0, 0, 0
 	}
-	
+
 	var r, g, b int
 	fmt.Sscanf(hex, "%02x%02x%02x", &r, &g, &b)
-	
+
 	return float64(r) / 255.0, float64(g) / 255.0, float64(b) / 255.0
 }
 
@@ -252,3 +1080,96 @@ func displayColorSyncForm() {
 	grid.ShowAll()
 	scrolledWindow.Hide()
 }
+
+// showBackupsDialog lists the backup sets of the last apply runs, with a button to restore each
+func showBackupsDialog() {
+	dialog, _ := gtk.DialogNew()
+	dialog.SetTitle("Backups")
+	dialog.SetDefaultSize(520, 400)
+	dialog.SetModal(true)
+	dialog.AddButton(voc["close"], gtk.RESPONSE_CLOSE)
+
+	content, _ := dialog.GetContentArea()
+	scrolled, _ := gtk.ScrolledWindowNew(nil, nil)
+	scrolled.SetProperty("vexpand", true)
+	scrolled.SetProperty("margin", 6)
+	content.PackStart(scrolled, true, true, 0)
+
+	list, _ := gtk.ListBoxNew()
+	list.SetSelectionMode(gtk.SELECTION_NONE)
+	scrolled.Add(list)
+
+	sets := listBackupSets()
+	if len(sets) == 0 {
+		label, _ := gtk.LabelNew("No backups yet")
+		label.SetProperty("margin", 12)
+		list.Add(label)
+	}
+	for _, b := range sets {
+		set := b
+		box, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+		box.SetProperty("margin", 6)
+		label, _ := gtk.LabelNew("")
+		label.SetMarkup(fmt.Sprintf("<b>Before %s</b>\n<small>%d files</small>",
+			set.Created.Format("2006-01-02 15:04:05"), len(set.Files)))
+		label.SetTooltipText(strings.Join(set.paths(), "\n"))
+		label.SetProperty("halign", gtk.ALIGN_START)
+		box.PackStart(label, true, true, 0)
+
+		btn, _ := gtk.ButtonNewWithLabel("Restore")
+		btn.SetProperty("valign", gtk.ALIGN_CENTER)
+		btn.Connect("clicked", func() {
+			dialog.Response(gtk.RESPONSE_CLOSE)
+			go func() {
+				restored, err := set.restore()
+				glib.IdleAdd(func() {
+					if err != nil {
+						showMessage(gtk.MESSAGE_ERROR, fmt.Sprintf("Restore failed: %v", err))
+					} else {
+						showMessage(gtk.MESSAGE_INFO, fmt.Sprintf("Restored %d files, reload the applications to see them", len(restored)))
+					}
+					refreshAppBadges()
+				})
+			}()
+		})
+		box.PackStart(btn, false, false, 0)
+		list.Add(box)
+	}
+
+	dialog.ShowAll()
+	dialog.Run()
+	dialog.Destroy()
+}
+
+// setUpOpacityScale returns a slider setting the application's background opacity. Colors are applied
+// once the slider stops moving, not on every step.
+func setUpOpacityScale(appName string) *gtk.Scale {
+	scale, _ := gtk.ScaleNewWithRange(gtk.ORIENTATION_HORIZONTAL, minOpacity, 1, 0.05)
+	scale.SetValue(colorSyncManager.Opacity(appName))
+	scale.SetDrawValue(false)
+	scale.SetSizeRequest(100, -1)
+	scale.SetTooltipText(fmt.Sprintf("Background opacity: %.2f", scale.GetValue()))
+
+	var pending glib.SourceHandle
+	scale.Connect("value-changed", func() {
+		scale.SetTooltipText(fmt.Sprintf("Background opacity: %.2f", scale.GetValue()))
+		if pending != 0 {
+			glib.SourceRemove(pending)
+		}
+		pending = glib.TimeoutAdd(400, func() bool {
+			pending = 0
+			value := fmt.Sprintf("%.2f", scale.GetValue())
+			go func() {
+				err := colorSyncManager.SetOpacity(appName, value)
+				glib.IdleAdd(func() {
+					if err != nil {
+						log.Warnf("Failed to set %s opacity: %v", appName, err)
+					}
+					refreshAppBadges()
+				})
+			}()
+			return false
+		})
+	})
+	return scale
+}
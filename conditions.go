@@ -0,0 +1,73 @@
+// conditions.go
+package main
+
+import (
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SkipRule disables targets and hooks on matching machines, so that one color-sync.json may be shared
+// across machines with dotfiles. A rule matches if both the hostname and the session match; an empty
+// list matches anything.
+type SkipRule struct {
+	Hosts    []string `json:"hosts,omitempty"`
+	Sessions []string `json:"sessions,omitempty"` // XDG_CURRENT_DESKTOP entries or XDG_SESSION_TYPE, e.g. "sway", "wayland"
+	Apps     []string `json:"apps,omitempty"`     // applications or template names not to write
+	Hooks    []string `json:"hooks,omitempty"`    // reloads and hooks not to run, by application name, "accents", "post-apply" and/or "terminals"
+}
+
+// currentSessions returns the lowercase names the session is known by
+func currentSessions() []string {
+	var names []string
+	for _, name := range strings.Split(os.Getenv("XDG_CURRENT_DESKTOP"), ":") {
+		if name != "" {
+			names = append(names, strings.ToLower(name))
+		}
+	}
+	if t := os.Getenv("XDG_SESSION_TYPE"); t != "" {
+		names = append(names, strings.ToLower(t))
+	}
+	return names
+}
+
+func (r SkipRule) matches(host string, sessions []string) bool {
+	if len(r.Hosts) > 0 && !isIn(r.Hosts, host) {
+		return false
+	}
+	if len(r.Sessions) > 0 {
+		for _, s := range r.Sessions {
+			if isIn(sessions, strings.ToLower(s)) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// activeSkips returns the apps and hooks disabled on this machine and session
+func activeSkips(rules []SkipRule) (apps, hooks map[string]bool) {
+	apps, hooks = make(map[string]bool), make(map[string]bool)
+	if len(rules) == 0 {
+		return
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		log.Warnf("Couldn't get hostname: %v", err)
+	}
+	sessions := currentSessions()
+	for _, r := range rules {
+		if !r.matches(host, sessions) {
+			continue
+		}
+		for _, app := range r.Apps {
+			apps[app] = true
+		}
+		for _, hook := range r.Hooks {
+			hooks[hook] = true
+		}
+	}
+	return
+}
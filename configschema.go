@@ -0,0 +1,437 @@
+// configschema.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// configKinds are the hand-edited files `nwg-look config schema` describes, by the type they're read into
+var configKinds = map[string]reflect.Type{
+	"color-sync": reflect.TypeOf(ColorSyncConfig{}),
+	"profile":    reflect.TypeOf(Profile{}),
+}
+
+// ConfigIssue is a problem found by `nwg-look config validate`
+type ConfigIssue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Path    string `json:"path"` // JSON pointer, e.g. /destinations/kitty.conf/mode
+	Message string `json:"message"`
+}
+
+func (i ConfigIssue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%s:%v:%v: %s", i.File, i.Line, i.Column, i.Message)
+	}
+	return fmt.Sprintf("%s:%v:%v: %s: %s", i.File, i.Line, i.Column, i.Path, i.Message)
+}
+
+const hexColorPattern = "^#[0-9a-fA-F]{6}$"
+
+// schemaConstraints narrow down what the Go types allow, by schema path: keys joined with dots,
+// "*" for map values and "[]" for array items
+func schemaConstraints(kind string) map[string]map[string]interface{} {
+	if kind == "profile" {
+		return map[string]map[string]interface{}{
+			"color-scheme":   {"enum": []string{"default", "prefer-dark", "prefer-light"}},
+			"cursor-size":    {"minimum": 0},
+			"wallpaper.path": {"minLength": 1},
+		}
+	}
+
+	apps := colorApps()
+	var templates []string
+	for _, t := range colorTargets {
+		templates = append(templates, t.template)
+	}
+	// opt-in reloads, and built-in ones that may be turned off
+	reloads := make(map[string]bool)
+	for _, m := range []map[string][]string{optInReloadCommands, appReloadCommands, appRespawnProcesses} {
+		for app := range m {
+			reloads[app] = true
+		}
+	}
+	for app := range sessionReloadCommands {
+		reloads[app] = true
+	}
+	for app := range appReloadSignals {
+		reloads[app] = true
+	}
+	for app := range appLiveReloads {
+		reloads[app] = true
+	}
+	hooks := append([]string{"accents", hookPostApply, hookTerminals}, apps...)
+	steps := append([]string{"extract", "import", "last", "apply", "export-gtk"}, transformNames()...)
+
+	return map[string]map[string]interface{}{
+		"last-colors.background":    {"pattern": hexColorPattern},
+		"last-colors.foreground":    {"pattern": hexColorPattern},
+		"last-colors.cursor":        {"pattern": hexColorPattern},
+		"last-colors.colors":        {"propertyNames": map[string]interface{}{"pattern": "^color([0-9]|1[0-5])$"}},
+		"last-colors.colors.*":      {"pattern": hexColorPattern},
+		"destinations":              {"propertyNames": map[string]interface{}{"enum": templates}},
+		"destinations.*.mode":       {"pattern": "^0?[0-7]{3}$"},
+		"destinations.*.write":      {"enum": []string{writeReplace, writeInject}},
+		"destinations.*.gamma":      {"minimum": 0, "maximum": 5},
+		"destinations.*.brightness": {"minimum": 0, "maximum": 2},
+		"reload":                    {"propertyNames": map[string]interface{}{"enum": sortedKeys(reloads)}},
+		"accents.mode":              {"enum": []string{"", "workspace", "output"}},
+		"accents.workspaces":        {"minimum": 0},
+		"pipelines.*[]":             {"pattern": fmt.Sprintf(`^\s*(%s)(\s|$)`, strings.Join(steps, "|"))},
+		"skip[].apps[]":             {"enum": append(append([]string{}, apps...), templates...)},
+		"skip[].hooks[]":            {"enum": hooks},
+		"consent":                   {"propertyNames": map[string]interface{}{"enum": apps}},
+		"consent.*":                 {"enum": []string{consentAlways, consentAsk, consentNever}},
+		"backups":                   {"minimum": 0, "maximum": 100},
+		"opacity":                   {"propertyNames": map[string]interface{}{"enum": apps}},
+		"opacity.*":                 {"minimum": minOpacity, "maximum": 1},
+		"disabled-templates[]":      {"enum": templates},
+		"hooks.post-apply[]":        {"minLength": 1},
+		"hooks.apps":                {"propertyNames": map[string]interface{}{"enum": apps}},
+		"hooks.apps.*[]":            {"minLength": 1},
+		"hooks.timeout":             {"minimum": 0, "maximum": 300},
+	}
+}
+
+// configSchema generates the JSON Schema of a config file from the Go type it's read into,
+// so that it can't drift from what nwg-look actually reads
+func configSchema(kind string) (map[string]interface{}, error) {
+	t, ok := configKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown config '%s', expected color-sync or profile", kind)
+	}
+	schema := schemaFor(t, "", schemaConstraints(kind))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = fmt.Sprintf("nwg-look %s", kind)
+	return schema, nil
+}
+
+func schemaFor(t reflect.Type, path string, constraints map[string]map[string]interface{}) map[string]interface{} {
+	s := make(map[string]interface{})
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		t, nullable = t.Elem(), true
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		s["type"], s["format"] = "string", "date-time"
+	case t.Kind() == reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			properties[name] = schemaFor(t.Field(i).Type, joinSchemaPath(path, name), constraints)
+		}
+		s["type"], s["properties"], s["additionalProperties"] = "object", properties, false
+	case t.Kind() == reflect.Map:
+		s["type"], s["additionalProperties"] = "object", schemaFor(t.Elem(), joinSchemaPath(path, "*"), constraints)
+		nullable = true
+	case t.Kind() == reflect.Slice:
+		s["type"], s["items"] = "array", schemaFor(t.Elem(), path+"[]", constraints)
+		nullable = true
+	case t.Kind() == reflect.String:
+		s["type"] = "string"
+	case t.Kind() == reflect.Bool:
+		s["type"] = "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		s["type"] = "integer"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s["type"] = "number"
+	}
+	// a nil map, slice or pointer is written as null
+	if nullable {
+		s["type"] = []string{s["type"].(string), "null"}
+	}
+	for key, value := range constraints[path] {
+		s[key] = value
+	}
+	return s
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// validateConfigFile checks a config file against its schema, and the values nwg-look would drop on load
+func validateConfigFile(path, kind string) ([]ConfigIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var issues []ConfigIssue
+	add := func(offset int, pointer, format string, args ...interface{}) {
+		line, column := lineColumn(data, offset)
+		issues = append(issues, ConfigIssue{path, line, column, pointer, fmt.Sprintf(format, args...)})
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		offset := 0
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			offset = int(syntaxErr.Offset)
+		}
+		add(offset, "", "%v", err)
+		return issues, nil
+	}
+	positions := jsonPositions(data)
+	schema, err := configSchema(kind)
+	if err != nil {
+		return nil, err
+	}
+	validateValue(schema, value, "", func(pointer, format string, args ...interface{}) {
+		add(positions[pointer], pointer, format, args...)
+	})
+
+	// checks the schema can't express: file modes, and owners existing on this machine
+	if kind == "color-sync" && len(issues) == 0 {
+		var config ColorSyncConfig
+		if err := json.Unmarshal(data, &config); err == nil {
+			for _, name := range sortedDestinationNames(config.Destinations) {
+				if opts := config.Destinations[name]; opts != nil {
+					if err := opts.validate(); err != nil {
+						pointer := "/destinations/" + escapePointer(name)
+						add(positions[pointer], pointer, "%v", err)
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line || issues[i].Line == issues[j].Line && issues[i].Column < issues[j].Column
+	})
+	return issues, nil
+}
+
+func sortedDestinationNames(destinations map[string]*DestinationOptions) []string {
+	var names []string
+	for name := range destinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateValue implements the part of JSON Schema configSchema uses
+func validateValue(schema map[string]interface{}, value interface{}, pointer string, add func(pointer, format string, args ...interface{})) {
+	if !matchesType(schema["type"], value) {
+		add(pointer, "expected %s, got %s", typeNames(schema["type"]), jsonTypeName(value))
+		return
+	}
+	switch v := value.(type) {
+	case string:
+		if enum, ok := schema["enum"].([]string); ok && !isIn(enum, v) {
+			if len(enum) > 8 {
+				add(pointer, "unknown value '%s', see `nwg-look config schema`", v)
+			} else {
+				add(pointer, "'%s' is not one of: %s", v, strings.Join(enum, ", "))
+			}
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(v) {
+			add(pointer, "'%s' doesn't match %s", v, pattern)
+		}
+		if minLength, ok := schema["minLength"].(int); ok && len(v) < minLength {
+			add(pointer, "must not be empty")
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				add(pointer, "'%s' is not an RFC 3339 date and time", v)
+			}
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if min, ok := schema["minimum"].(int); ok && f < float64(min) {
+			add(pointer, "%s is below the minimum of %v", v, min)
+		}
+		if max, ok := schema["maximum"].(int); ok && f > float64(max) {
+			add(pointer, "%s is above the maximum of %v", v, max)
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateValue(items, item, fmt.Sprintf("%s/%v", pointer, i), add)
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		names, _ := schema["propertyNames"].(map[string]interface{})
+		for _, key := range sortedJSONKeys(v) {
+			child := pointer + "/" + escapePointer(key)
+			if names != nil {
+				validateValue(names, key, child, add)
+			}
+			if property, ok := properties[key].(map[string]interface{}); ok {
+				validateValue(property, v[key], child, add)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					add(child, "unknown key '%s'%s", key, suggestKey(key, properties))
+				}
+			case map[string]interface{}:
+				validateValue(additional, v[key], child, add)
+			}
+		}
+	}
+}
+
+// suggestKey hints at a known key differing in case, or in dashes and underscores
+func suggestKey(key string, properties map[string]interface{}) string {
+	normalize := func(s string) string { return strings.ToLower(strings.ReplaceAll(s, "_", "-")) }
+	for name := range properties {
+		if normalize(name) == normalize(key) {
+			return fmt.Sprintf(", did you mean '%s'?", name)
+		}
+	}
+	return ""
+}
+
+func matchesType(schemaType interface{}, value interface{}) bool {
+	var types []string
+	switch t := schemaType.(type) {
+	case string:
+		types = []string{t}
+	case []string:
+		types = t
+	default:
+		return true
+	}
+	actual := jsonTypeName(value)
+	for _, t := range types {
+		if t == actual || t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func typeNames(schemaType interface{}) string {
+	if types, ok := schemaType.([]string); ok {
+		return strings.Join(types, " or ")
+	}
+	return fmt.Sprint(schemaType)
+}
+
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+func sortedJSONKeys(object map[string]interface{}) []string {
+	var keys []string
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointer escapes a key for a JSON pointer, see RFC 6901
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// jsonPositions maps JSON pointers to the offsets of their values in the document, for error locations
+func jsonPositions(data []byte) map[string]int {
+	positions := make(map[string]int)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var walk func(pointer string) error
+	walk = func(pointer string) error {
+		positions[pointer] = valueStart(data, int(dec.InputOffset()))
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := walk(pointer + "/" + escapePointer(key.(string))); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(fmt.Sprintf("%s/%v", pointer, i)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	walk("")
+	return positions
+}
+
+// valueStart skips what separates a value from the previous token
+func valueStart(data []byte, offset int) int {
+	for offset < len(data) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// lineColumn converts a byte offset to 1-based line and column numbers
+func lineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	return line, offset - bytes.LastIndexByte(before, '\n')
+}
+
+// configFiles returns the files `nwg-look config validate` checks by default, with their kinds
+func configFiles() map[string]string {
+	files := map[string]string{filepath.Join(configDir(), "color-sync.json"): "color-sync"}
+	profiles, _ := filepath.Glob(filepath.Join(profilesDir(), "*.json"))
+	for _, path := range profiles {
+		files[path] = "profile"
+	}
+	return files
+}
+
+// configKind tells what a file given to `nwg-look config validate` is, by its location
+func configKind(path string) string {
+	if filepath.Dir(filepath.Clean(path)) == filepath.Clean(profilesDir()) ||
+		filepath.Base(filepath.Dir(path)) == "profiles" {
+		return "profile"
+	}
+	return "color-sync"
+}
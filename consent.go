@@ -0,0 +1,93 @@
+// consent.go
+package main
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// per-application consent to writing its config files, remembered in color-sync.json
+const (
+	consentAlways = "always" // write without asking
+	consentAsk    = "ask"    // ask on each apply
+	consentNever  = "never"  // don't write, as if the application was disabled
+)
+
+// consentChoices are the values accepted by `nwg-look colors consent`, "reset" forgets the choice
+var consentChoices = []string{consentAlways, consentAsk, consentNever, "reset"}
+
+// describeWrites lists the files an apply is about to write, telling new files from existing ones
+func describeWrites(paths []string) []string {
+	var lines []string
+	for _, path := range paths {
+		if pathExists(path) {
+			lines = append(lines, fmt.Sprintf("modify  %s", path))
+		} else {
+			lines = append(lines, fmt.Sprintf("create  %s", path))
+		}
+	}
+	return lines
+}
+
+// consentGiven tells if the application's files may be written. On the first apply, or on each one if the user
+// chose to be asked, askConsent shows the paths. Without askConsent (command line) the first apply goes ahead:
+// running it is consent enough, and nothing is remembered, so that the GUI still asks later.
+func (tm *TemplateManager) consentGiven(app string, paths []string) bool {
+	decision := tm.consent[app]
+	switch decision {
+	case consentAlways:
+		return true
+	case consentNever:
+		log.Infof("Skipping %s: writing its config is not allowed", app)
+		return false
+	}
+
+	if tm.askConsent == nil {
+		if decision == consentAsk {
+			log.Infof("Skipping %s: asks each time, apply from the GUI or run `nwg-look colors consent %s always`", app, app)
+			return false
+		}
+		return true
+	}
+
+	answer := tm.askConsent(app, describeWrites(paths))
+	if answer != "" && answer != decision && tm.consent != nil {
+		tm.consent[app] = answer
+		if tm.saveConsent != nil {
+			if err := tm.saveConsent(); err != nil {
+				log.Warnf("Failed to save consent for %s: %v", app, err)
+			}
+		}
+	}
+	if answer != consentAlways && answer != consentAsk {
+		log.Infof("Skipping %s: not allowed", app)
+		return false
+	}
+	return true
+}
+
+// initConsent shares the remembered choices with the template manager, which saves the ones made in the dialog
+func (csm *ColorSyncManager) initConsent() {
+	if csm.config.Consent == nil {
+		csm.config.Consent = make(map[string]string)
+	}
+	csm.templates.consent = csm.config.Consent
+	csm.templates.saveConsent = csm.saveConfig
+}
+
+// SetConsent remembers the choice for the application, or forgets it on "reset"
+func (csm *ColorSyncManager) SetConsent(app, choice string) error {
+	if !isIn(csm.GetApplications(), app) {
+		return fmt.Errorf("unknown application '%s'", app)
+	}
+	if !isIn(consentChoices, choice) {
+		return fmt.Errorf("unknown choice '%s', expected always, ask, never or reset", choice)
+	}
+	if choice == "reset" {
+		delete(csm.config.Consent, app)
+	} else {
+		csm.config.Consent[app] = choice
+	}
+	return csm.saveConfig()
+}
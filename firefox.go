@@ -0,0 +1,83 @@
+// firefox.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// firefoxDirs are where Firefox keeps profiles.ini: native, Flatpak and Snap installs
+func firefoxDirs() []string {
+	home := os.Getenv("HOME")
+	return []string{
+		filepath.Join(home, ".mozilla/firefox"),
+		filepath.Join(home, ".var/app/org.mozilla.firefox/.mozilla/firefox"),
+		filepath.Join(home, "snap/firefox/common/.mozilla/firefox"),
+	}
+}
+
+// parseIniSections reads an ini file into section name -> key -> value
+func parseIniSections(path string) (map[string]map[string]string, []string, error) {
+	lines, err := loadTextFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	sections := make(map[string]map[string]string)
+	var order []string
+	current := ""
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = line[1 : len(line)-1]
+			sections[current] = make(map[string]string)
+			order = append(order, current)
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && current != "" {
+			sections[current][strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return sections, order, nil
+}
+
+// firefoxProfileDir returns the directory of the default Firefox profile. The profile Firefox starts with
+// is in the [Install…] section; older profiles.ini files only mark it with Default=1 in its [Profile…] section.
+func firefoxProfileDir() (string, error) {
+	for _, dir := range firefoxDirs() {
+		sections, order, err := parseIniSections(filepath.Join(dir, "profiles.ini"))
+		if err != nil {
+			continue
+		}
+		resolve := func(path string, relative bool) string {
+			if relative {
+				return filepath.Join(dir, path)
+			}
+			return path
+		}
+		for _, name := range order {
+			if strings.HasPrefix(name, "Install") && sections[name]["Default"] != "" {
+				return resolve(sections[name]["Default"], !filepath.IsAbs(sections[name]["Default"])), nil
+			}
+		}
+		var first string
+		for _, name := range order {
+			s := sections[name]
+			if !strings.HasPrefix(name, "Profile") || s["Path"] == "" {
+				continue
+			}
+			path := resolve(s["Path"], s["IsRelative"] != "0")
+			if s["Default"] == "1" {
+				return path, nil
+			}
+			if first == "" {
+				first = path
+			}
+		}
+		if first != "" {
+			return first, nil
+		}
+	}
+	return "", fmt.Errorf("no Firefox profile found")
+}
@@ -0,0 +1,23 @@
+// fontreload.go
+//go:build !headless
+
+package main
+
+// #cgo pkg-config: fontconfig pangoft2 pangocairo
+// #include <fontconfig/fontconfig.h>
+// #include <pango/pangocairo.h>
+// #include <pango/pangofc-fontmap.h>
+//
+// static void reload_font_config() {
+//     FcInitReinitialize();
+//     PangoFontMap *fm = pango_cairo_font_map_get_default();
+//     if (PANGO_IS_FC_FONT_MAP(fm)) {
+//         pango_fc_font_map_set_config(PANGO_FC_FONT_MAP(fm), NULL);
+//     }
+// }
+import "C"
+
+// reloadFontConfig makes fontconfig and Pango see newly installed fonts without restarting
+func reloadFontConfig() {
+	C.reload_font_config()
+}
@@ -0,0 +1,188 @@
+// fonts.go
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var fontExtensions = []string{".ttf", ".otf", ".ttc", ".otc", ".pcf", ".woff", ".woff2"}
+
+func userFontsDir() string {
+	return filepath.Join(dataHome(), "fonts")
+}
+
+func isFontFile(name string) bool {
+	return isIn(fontExtensions, strings.ToLower(filepath.Ext(name)))
+}
+
+// archiveBaseName strips archive extensions: "Foo-1.0.tar.gz" -> "Foo-1.0"
+func archiveBaseName(path string) string {
+	name := filepath.Base(path)
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tar.bz2", ".tgz", ".tbz2", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// installFont copies a font file, or font files found in an archive, into the user fonts dir.
+// Returns the directory the fonts were placed in and the installed file paths.
+func installFont(path string) (string, []string, error) {
+	var installed []string
+	var err error
+
+	lower := strings.ToLower(path)
+	destDir := userFontsDir()
+	switch {
+	case isFontFile(path):
+		var dest string
+		dest, err = copyFontFile(path, destDir)
+		installed = append(installed, dest)
+	case strings.HasSuffix(lower, ".zip"):
+		destDir = filepath.Join(destDir, archiveBaseName(path))
+		installed, err = extractFontsZip(path, destDir)
+	case strings.HasSuffix(lower, ".tar"), strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"),
+		strings.HasSuffix(lower, ".tar.bz2"), strings.HasSuffix(lower, ".tbz2"):
+		destDir = filepath.Join(destDir, archiveBaseName(path))
+		installed, err = extractFontsTar(path, destDir)
+	default:
+		return "", nil, fmt.Errorf("unsupported file type: %s", filepath.Base(path))
+	}
+	if err != nil {
+		return "", installed, err
+	}
+	if len(installed) == 0 {
+		return "", nil, fmt.Errorf("no font files found in %s", filepath.Base(path))
+	}
+	log.Infof("Installed %v font file(s) into %s", len(installed), destDir)
+	return destDir, installed, nil
+}
+
+func copyFontFile(src, destDir string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	return writeFontFile(in, filepath.Base(src), destDir)
+}
+
+// writeFontFile writes a font under destDir, using the base name only, so that archive entries can't escape it
+func writeFontFile(r io.Reader, name, destDir string) (string, error) {
+	makeDir(destDir)
+	dest := filepath.Join(destDir, filepath.Base(name))
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, r); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func extractFontsZip(path, destDir string) ([]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var installed []string
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !isFontFile(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return installed, err
+		}
+		dest, err := writeFontFile(rc, f.Name, destDir)
+		rc.Close()
+		if err != nil {
+			return installed, err
+		}
+		installed = append(installed, dest)
+	}
+	return installed, nil
+}
+
+func extractFontsTar(path, destDir string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, "gz"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(lower, "bz2"):
+		r = bzip2.NewReader(file)
+	}
+
+	var installed []string
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return installed, err
+		}
+		if hdr.Typeflag != tar.TypeReg || !isFontFile(hdr.Name) {
+			continue
+		}
+		dest, err := writeFontFile(tr, hdr.Name, destDir)
+		if err != nil {
+			return installed, err
+		}
+		installed = append(installed, dest)
+	}
+	return installed, nil
+}
+
+// fontFamilies lists the families defined in the given font files
+func fontFamilies(files []string) []string {
+	var families []string
+	if _, err := exec.LookPath("fc-scan"); err != nil {
+		return families
+	}
+	for _, f := range files {
+		out, err := exec.Command("fc-scan", "--format", "%{family[0]}\n", f).Output()
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !isIn(families, line) {
+				families = append(families, line)
+			}
+		}
+	}
+	sort.Strings(families)
+	return families
+}
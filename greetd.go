@@ -0,0 +1,184 @@
+// greetd.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// greetdDir is where greetd keeps its config, and gtkgreet its style sheet
+const greetdDir = "/etc/greetd"
+
+// gtkgreet files generated from the current settings
+const (
+	greetdEnvFile = "nwg-look.env"
+	gtkgreetCss   = "gtkgreet.css"
+)
+
+// splitFontName splits a GTK font description, e.g. "Noto Sans Bold 11" -> "Noto Sans Bold", 11
+func splitFontName(fontName string) (string, int) {
+	fields := strings.Fields(fontName)
+	if len(fields) > 1 {
+		if size, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+			return strings.Join(fields[:len(fields)-1], " "), size
+		}
+	}
+	return fontName, 0
+}
+
+// greetdEnv returns environment variables making the greeter session use the same theme and cursor.
+// No comments here: the file is meant to be expanded with `env $(cat nwg-look.env)`.
+func greetdEnv() []string {
+	theme := gsettings.gtkTheme
+	if gsettings.colorScheme == "prefer-dark" && !strings.HasSuffix(strings.ToLower(theme), "dark") {
+		theme += ":dark"
+	}
+	return []string{
+		fmt.Sprintf("GTK_THEME=%s", theme),
+		fmt.Sprintf("XCURSOR_THEME=%s", gsettings.cursorTheme),
+		fmt.Sprintf("XCURSOR_SIZE=%v", gsettings.cursorSize),
+	}
+}
+
+// gtkgreetStyle returns the gtkgreet style sheet: the font, and the synced palette if any
+func gtkgreetStyle(p *ColorPalette) []string {
+	family, size := splitFontName(gsettings.fontName)
+	lines := []string{"/* Generated by nwg-look, do not edit this file. */", "window, label, entry, button {"}
+	lines = append(lines, fmt.Sprintf("    font-family: \"%s\";", family))
+	if size > 0 {
+		lines = append(lines, fmt.Sprintf("    font-size: %vpt;", size))
+	}
+	lines = append(lines, "}")
+	if p != nil {
+		lines = append(lines,
+			"",
+			"window {",
+			fmt.Sprintf("    background-color: %s;", p.Background),
+			fmt.Sprintf("    color: %s;", p.Foreground),
+			"}",
+			"",
+			"entry {",
+			fmt.Sprintf("    background-color: %s;", p.Colors["color0"]),
+			fmt.Sprintf("    color: %s;", p.Foreground),
+			fmt.Sprintf("    border-color: %s;", p.Colors["color8"]),
+			"}",
+			"",
+			"entry:focus, button:hover {",
+			fmt.Sprintf("    border-color: %s;", p.Colors["color4"]),
+			"}")
+	}
+	return lines
+}
+
+// exportGreetd writes the gtkgreet environment file and style sheet into dir, and returns their paths
+func exportGreetd(dir string) ([]string, error) {
+	makeDir(dir)
+	files := map[string][]string{
+		greetdEnvFile: greetdEnv(),
+		gtkgreetCss:   gtkgreetStyle(colorSyncManager.config.LastColors),
+	}
+	var paths []string
+	for _, name := range []string{greetdEnvFile, gtkgreetCss} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(files[name], "\n")+"\n"), 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// installGreetd exports the files and copies them to /etc/greetd, authorized with polkit
+func installGreetd() error {
+	if _, err := exec.LookPath("pkexec"); err != nil {
+		return fmt.Errorf("pkexec not found, copy the files from %s to %s manually", filepath.Join(cacheDir(), "greetd"), greetdDir)
+	}
+	paths, err := exportGreetd(filepath.Join(cacheDir(), "greetd"))
+	if err != nil {
+		return err
+	}
+	// a style sheet of the user's own is kept as gtkgreet.css.bak, installed along with ours
+	if current, ok := foreignGtkgreetCss(); ok {
+		backup := filepath.Join(cacheDir(), "greetd", gtkgreetCss+".bak")
+		if err := os.WriteFile(backup, current, 0644); err != nil {
+			return err
+		}
+		paths = append(paths, backup)
+		log.Infof("Backing up %s as %s.bak", filepath.Join(greetdDir, gtkgreetCss), gtkgreetCss)
+	}
+	args := append([]string{"install", "-m", "0644", "-D", "-t", greetdDir}, paths...)
+	out, err := exec.Command("pkexec", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("installing into %s failed: %v %s", greetdDir, err, string(out))
+	}
+	log.Infof("✓ Installed %s and %s into %s", greetdEnvFile, gtkgreetCss, greetdDir)
+	return nil
+}
+
+// foreignGtkgreetCss returns the installed gtkgreet style sheet if it wasn't written by nwg-look
+func foreignGtkgreetCss() ([]byte, bool) {
+	current, err := os.ReadFile(filepath.Join(greetdDir, gtkgreetCss))
+	if err != nil || bytes.Contains(current, []byte("Generated by nwg-look")) {
+		return nil, false
+	}
+	return current, true
+}
+
+// greetdUpToDate tells if the files installed in /etc/greetd are the same as the exported ones
+func greetdUpToDate(paths []string) bool {
+	for _, path := range paths {
+		exported, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		installed, err := os.ReadFile(filepath.Join(greetdDir, filepath.Base(path)))
+		if err != nil || !bytes.Equal(exported, installed) {
+			return false
+		}
+	}
+	return true
+}
+
+// syncGreetd installs the gtkgreet files again after colors were applied, if opted in. Files that didn't
+// change are not installed again, so that polkit only asks when the login screen would look different,
+// and a style sheet of the user's own is left alone.
+func (csm *ColorSyncManager) syncGreetd() {
+	if !csm.config.Greetd {
+		return
+	}
+	paths, err := exportGreetd(filepath.Join(cacheDir(), "greetd"))
+	if err != nil {
+		log.Warnf("Failed to export gtkgreet files: %v", err)
+		return
+	}
+	if greetdUpToDate(paths) {
+		log.Debugf("gtkgreet files in %s are up to date", greetdDir)
+		return
+	}
+	if _, ok := foreignGtkgreetCss(); ok {
+		log.Warnf("Not updating the login screen: %s wasn't written by nwg-look, run `nwg-look greetd install` to replace it",
+			filepath.Join(greetdDir, gtkgreetCss))
+		return
+	}
+	if err := installGreetd(); err != nil {
+		log.Warnf("Failed to update the login screen: %v", err)
+	}
+}
+
+// IsGreetdSync returns whether the gtkgreet files are installed on apply
+func (csm *ColorSyncManager) IsGreetdSync() bool {
+	return csm.config.Greetd
+}
+
+// SetGreetdSync turns installing the gtkgreet files on apply on or off
+func (csm *ColorSyncManager) SetGreetdSync(sync bool) {
+	csm.config.Greetd = sync
+	csm.saveConfig()
+}
@@ -0,0 +1,346 @@
+// gtkcolors.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	gtkCssBlockStart = "/* nwg-look colors start */"
+	gtkCssBlockEnd   = "/* nwg-look colors end */"
+)
+
+// DeriveGtkColors maps a palette back onto GTK3 / libadwaita named colors
+func DeriveGtkColors(p *ColorPalette) map[string]string {
+	bg := p.Background
+	fg := p.Foreground
+	accent := p.Colors["color4"]
+	accentFg := "#ffffff"
+	if !isDarkColor(accent) {
+		accentFg = "#000000"
+	}
+	headerbar := mixColors(bg, fg, 0.06)
+	view := mixColors(bg, fg, 0.02)
+	borders := mixColors(bg, fg, 0.18)
+
+	return map[string]string{
+		// GTK3 theme colors
+		"theme_bg_color":                    bg,
+		"theme_fg_color":                    fg,
+		"theme_base_color":                  view,
+		"theme_text_color":                  fg,
+		"theme_selected_bg_color":           accent,
+		"theme_selected_fg_color":           accentFg,
+		"insensitive_bg_color":              bg,
+		"insensitive_fg_color":              mixColors(fg, bg, 0.5),
+		"borders":                           borders,
+		"theme_unfocused_bg_color":          bg,
+		"theme_unfocused_fg_color":          fg,
+		"theme_unfocused_base_color":        view,
+		"theme_unfocused_text_color":        fg,
+		"theme_unfocused_selected_bg_color": accent,
+		"theme_unfocused_selected_fg_color": accentFg,
+		// libadwaita / GTK4
+		"accent_color":       accent,
+		"accent_bg_color":    accent,
+		"accent_fg_color":    accentFg,
+		"window_bg_color":    bg,
+		"window_fg_color":    fg,
+		"view_bg_color":      view,
+		"view_fg_color":      fg,
+		"headerbar_bg_color": headerbar,
+		"headerbar_fg_color": fg,
+		"card_bg_color":      headerbar,
+		"card_fg_color":      fg,
+		"popover_bg_color":   headerbar,
+		"popover_fg_color":   fg,
+		"dialog_bg_color":    headerbar,
+		"dialog_fg_color":    fg,
+		// state colors
+		"warning_color":     p.Colors["color3"],
+		"error_color":       p.Colors["color1"],
+		"success_color":     p.Colors["color2"],
+		"destructive_color": p.Colors["color1"],
+	}
+}
+
+// gtkCssBlock renders the @define-color block written into user gtk.css files;
+// overrides take precedence over colors derived from the palette. With no palette, only overrides are defined.
+func gtkCssBlock(p *ColorPalette, overrides map[string]string) []string {
+	colors := make(map[string]string)
+	if p != nil {
+		colors = DeriveGtkColors(p)
+	}
+	for name, value := range overrides {
+		colors[name] = value
+	}
+	var names []string
+	for name := range colors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{gtkCssBlockStart, "/* Generated by nwg-look, edits inside this block will be lost */"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("@define-color %s %s;", name, colors[name]))
+	}
+	lines = append(lines, gtkCssBlockEnd)
+	return lines
+}
+
+var defineColorPattern = regexp.MustCompile(`^\s*@define-color\s+([\w-]+)\s+([^;]+);`)
+
+// parseDefineColors returns @define-color definitions found outside the managed block
+func parseDefineColors(lines []string) map[string]string {
+	colors := make(map[string]string)
+	inside := false
+	for _, line := range lines {
+		switch strings.TrimSpace(line) {
+		case gtkCssBlockStart:
+			inside = true
+			continue
+		case gtkCssBlockEnd:
+			inside = false
+			continue
+		}
+		if m := defineColorPattern.FindStringSubmatch(line); m != nil && !inside {
+			colors[m[1]] = strings.TrimSpace(m[2])
+		}
+	}
+	return colors
+}
+
+// importGtkOverrides reads the user's own named colors from ~/.config/gtk-3.0/gtk.css,
+// so that the first export preserves them instead of overriding them
+func importGtkOverrides() map[string]string {
+	cssFile := filepath.Join(configHome(), "gtk-3.0", "gtk.css")
+	lines, err := loadTextFile(cssFile)
+	if err != nil {
+		return make(map[string]string)
+	}
+	overrides := parseDefineColors(lines)
+	if len(overrides) > 0 {
+		log.Infof("Imported %v named color overrides from %s", len(overrides), cssFile)
+	}
+	return overrides
+}
+
+// replaceCssBlock swaps the managed block in existing lines, or appends it.
+// Definitions of colors the block overrides are moved into it.
+func replaceCssBlock(existing, block []string, overrides map[string]string) []string {
+	var out []string
+	inside, replaced := false, false
+	for _, line := range existing {
+		if m := defineColorPattern.FindStringSubmatch(line); m != nil && !inside {
+			if _, moved := overrides[m[1]]; moved {
+				continue
+			}
+		}
+		switch {
+		case strings.TrimSpace(line) == gtkCssBlockStart:
+			inside = true
+		case strings.TrimSpace(line) == gtkCssBlockEnd:
+			inside = false
+			if !replaced {
+				out = append(out, block...)
+				replaced = true
+			}
+		case !inside:
+			out = append(out, line)
+		}
+	}
+	if !replaced {
+		for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+			out = out[:len(out)-1]
+		}
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		out = append(out, block...)
+	}
+	return out
+}
+
+// managedCssBlock returns the managed color block of a gtk.css file, nil if it has none
+func managedCssBlock(cssFile string) []string {
+	lines, err := loadTextFile(cssFile)
+	if err != nil {
+		return nil
+	}
+	var block []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == gtkCssBlockStart || block != nil {
+			block = append(block, line)
+		}
+		if block != nil && strings.TrimSpace(line) == gtkCssBlockEnd {
+			return block
+		}
+	}
+	return nil
+}
+
+// writeGtkCss updates the managed color block in a gtk.css file, keeping user rules around it
+func writeGtkCss(cssFile string, block []string, overrides map[string]string) error {
+	var existing []string
+
+	// ~/.config/gtk-4.0/gtk.css may be a symlink to the theme (see linkGtk4Stuff):
+	// never write through it, import the theme file instead. linkGtk4Stuff puts the block
+	// back when it replaces the file, with the import of the theme selected then.
+	if target, err := os.Readlink(cssFile); err == nil {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(cssFile), target)
+		}
+		existing = []string{fmt.Sprintf("@import url(\"file://%s\");", target)}
+		if err := os.Remove(cssFile); err != nil {
+			return err
+		}
+	} else if pathExists(cssFile) {
+		lines, err := loadTextFile(cssFile)
+		if err != nil {
+			return err
+		}
+		existing = lines
+	}
+
+	makeDir(filepath.Dir(cssFile))
+	lines := replaceCssBlock(existing, block, overrides)
+	return os.WriteFile(cssFile, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// ExportGtkColors writes the palette as named color overrides into the user gtk-3.0 and gtk-4.0 gtk.css
+func ExportGtkColors(p *ColorPalette) error {
+	if p == nil {
+		return fmt.Errorf("no palette to export")
+	}
+	return writeGtkColors(p)
+}
+
+// ResetGtkColors drops the palette from the managed blocks, keeping the user's own named colors
+func ResetGtkColors() error {
+	return writeGtkColors(nil)
+}
+
+// writeGtkColors updates the managed blocks; with no palette, files without a block are left alone
+func writeGtkColors(p *ColorPalette) error {
+	overrides := colorSyncManager.gtkOverrides()
+	block := gtkCssBlock(p, overrides)
+	for _, dir := range []string{"gtk-3.0", "gtk-4.0"} {
+		cssFile := filepath.Join(configHome(), dir, "gtk.css")
+		if p == nil {
+			if lines, err := loadTextFile(cssFile); err != nil || !isIn(lines, gtkCssBlockStart) {
+				continue
+			}
+		}
+		if err := writeGtkCss(cssFile, block, overrides); err != nil {
+			return fmt.Errorf("failed to write %s: %w", cssFile, err)
+		}
+		if p != nil {
+			log.Infof("✓ Exported GTK named colors to %s", cssFile)
+		} else {
+			log.Infof("✓ Reset GTK named colors in %s", cssFile)
+		}
+	}
+	return nil
+}
+
+// ImportPalette loads a palette from an nwg-look JSON export or a base16 YAML scheme
+func ImportPalette(path string) (*ColorPalette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var palette ColorPalette
+	if err := json.Unmarshal(data, &palette); err == nil && palette.Background != "" {
+		if palette.Cursor == "" {
+			palette.Cursor = palette.Foreground
+		}
+		if palette.Colors == nil {
+			palette.Colors = make(map[string]string)
+		}
+		return &palette, nil
+	}
+	if palette, ok := parsePywal(data); ok {
+		return palette, nil
+	}
+
+	return parseBase16(string(data))
+}
+
+// pywalColors is the layout of pywal's colors.json
+type pywalColors struct {
+	Special struct {
+		Background string `json:"background"`
+		Foreground string `json:"foreground"`
+		Cursor     string `json:"cursor"`
+	} `json:"special"`
+	Colors map[string]string `json:"colors"`
+}
+
+// parsePywal reads a palette generated by pywal (~/.cache/wal/colors.json)
+func parsePywal(data []byte) (*ColorPalette, bool) {
+	var wal pywalColors
+	if err := json.Unmarshal(data, &wal); err != nil || wal.Special.Background == "" || len(wal.Colors) == 0 {
+		return nil, false
+	}
+	palette := &ColorPalette{
+		Background: wal.Special.Background,
+		Foreground: wal.Special.Foreground,
+		Cursor:     wal.Special.Cursor,
+		Colors:     wal.Colors,
+	}
+	if palette.Cursor == "" {
+		palette.Cursor = palette.Foreground
+	}
+	return palette, true
+}
+
+// parseBase16 reads a base16 scheme (base00 ... base0F) using the base16-shell ANSI mapping
+func parseBase16(content string) (*ColorPalette, error) {
+	base := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.TrimSpace(parts[1])
+		if i := strings.Index(value, " "); i > 0 {
+			// trailing comment
+			value = value[:i]
+		}
+		value = strings.Trim(value, `"'`)
+		if strings.HasPrefix(key, "base0") && len(key) == 6 {
+			if _, _, _, err := hexToRGB(value); err == nil {
+				base[key] = "#" + strings.ToLower(strings.TrimPrefix(value, "#"))
+			}
+		}
+	}
+	if len(base) < 16 {
+		return nil, fmt.Errorf("not a palette file: expected nwg-look JSON or base16 scheme")
+	}
+
+	order := []string{"base00", "base08", "base0b", "base0a", "base0d", "base0e", "base0c", "base05",
+		"base03", "base08", "base0b", "base0a", "base0d", "base0e", "base0c", "base07"}
+	palette := &ColorPalette{
+		Background: base["base00"],
+		Foreground: base["base05"],
+		Cursor:     base["base05"],
+		Colors:     make(map[string]string),
+	}
+	for i, key := range order {
+		palette.Colors[fmt.Sprintf("color%d", i)] = base[key]
+	}
+	return palette, nil
+}
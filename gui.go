@@ -0,0 +1,473 @@
+// gui.go
+//go:build !headless
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	log "github.com/sirupsen/logrus"
+)
+
+// GTK side of the program, left out when built with the headless tag
+
+var (
+	gtkSettings           *gtk.Settings
+	viewport              *gtk.Viewport
+	scrolledWindow        *gtk.ScrolledWindow
+	listBox               *gtk.ListBox
+	menuBar               *gtk.MenuBar
+	themeSettingsSelector *gtk.Grid
+	grid                  *gtk.Grid
+	preview               *gtk.Frame
+	cursorSizeSelector    *gtk.Box
+	rowToFocus            *gtk.ListBoxRow
+	voc                   map[string]string
+)
+
+func displayThemes() {
+	destroyContent()
+	rowToFocus = nil
+
+	listBox = setUpThemeListBox(gsettings.gtkTheme)
+	viewport.Add(listBox)
+	menuBar.Deactivate()
+	if rowToFocus != nil {
+		rowToFocus.GrabFocus()
+	}
+
+	preview = setUpWidgetsPreview()
+	grid.Attach(preview, 1, 1, 1, 1)
+
+	themeSettingsSelector = setUpThemeSettingsForm(gsettings.fontName)
+	themeSettingsSelector.SetProperty("vexpand", true)
+	themeSettingsSelector.SetProperty("valign", gtk.ALIGN_START)
+	grid.Attach(themeSettingsSelector, 1, 2, 1, 1)
+
+	viewport.ShowAll()
+	grid.ShowAll()
+}
+
+func displayIconThemes() {
+	destroyContent()
+	rowToFocus = nil
+
+	listBox = setUpIconThemeListBox(gsettings.iconTheme)
+	viewport.Add(listBox)
+	menuBar.Deactivate()
+	if rowToFocus != nil {
+		rowToFocus.GrabFocus()
+	}
+
+	preview = setUpIconsPreview()
+	grid.Attach(preview, 1, 1, 1, 1)
+
+	viewport.ShowAll()
+	grid.ShowAll()
+}
+
+func displayCursorThemes() {
+	destroyContent()
+	rowToFocus = nil
+
+	listBox = setUpCursorThemeListBox(gsettings.cursorTheme)
+	viewport.Add(listBox)
+	menuBar.Deactivate()
+	if rowToFocus != nil {
+		rowToFocus.GrabFocus()
+	}
+
+	preview = setUpCursorsPreview(cursorThemes[gsettings.cursorTheme])
+	grid.Attach(preview, 1, 1, 1, 1)
+
+	cursorSizeSelector = setUpCursorSizeSelector()
+	grid.Attach(cursorSizeSelector, 1, 2, 1, 1)
+
+	viewport.ShowAll()
+	grid.ShowAll()
+}
+
+func displayFontSettingsForm() {
+	destroyContent()
+
+	preview = setUpFontSettingsForm()
+	grid.Attach(preview, 0, 1, 1, 1)
+	menuBar.Deactivate()
+	grid.ShowAll()
+	scrolledWindow.Hide()
+}
+
+func displayOtherSettingsForm() {
+	destroyContent()
+
+	preview = setUpOtherSettingsForm()
+	grid.Attach(preview, 0, 1, 1, 1)
+	menuBar.Deactivate()
+	grid.ShowAll()
+	scrolledWindow.Hide()
+}
+
+func displayProgramSettingsForm() {
+	destroyContent()
+
+	preview = setUpProgramSettingsForm()
+	grid.Attach(preview, 0, 1, 1, 1)
+	menuBar.Deactivate()
+	grid.ShowAll()
+	scrolledWindow.Hide()
+}
+
+func destroyContent() {
+	if listBox != nil {
+		listBox.Destroy()
+	}
+	if preview != nil {
+		preview.Destroy()
+	}
+	if themeSettingsSelector != nil {
+		themeSettingsSelector.Destroy()
+	}
+	if cursorSizeSelector != nil {
+		cursorSizeSelector.Destroy()
+	}
+}
+
+// runGUI builds the main window and runs the GTK main loop
+func runGUI() {
+	lang := detectLang()
+	log.Infof("lang: %s", lang)
+	voc = loadVocabulary(lang)
+
+	cursorThemes, cursorThemeNames = getCursorThemes()
+
+	gtk.Init(nil)
+	if isRTLLanguage(lang) {
+		setTextDirection(gtk.TEXT_DIR_RTL)
+	}
+
+	// update gtkConfig from gtk-3.0/settings.ini
+	if preferences.ExportSettingsIni {
+		loadGtkConfig()
+	}
+
+	gtkSettings, _ = gtk.SettingsGetDefault()
+	colorSyncManager.templates.resolveConflict = askConflictResolution
+	colorSyncManager.templates.askConsent = askConsent
+
+	gladeFile := ""
+	for _, d := range dataDirs {
+		gladeFile = filepath.Join(d, "/nwg-look/main.glade")
+		if pathExists(gladeFile) {
+			break
+		}
+	}
+
+	builder, _ := gtk.BuilderNewFromFile(gladeFile)
+	win, _ := getWindow(builder, "window")
+
+	win.Connect("destroy", func() {
+		gtk.MainQuit()
+	})
+
+	win.Connect("key-release-event", func(window *gtk.Window, event *gdk.Event) bool {
+		key := &gdk.EventKey{Event: event}
+		if key.KeyVal() == gdk.KEY_Escape {
+			gtk.MainQuit()
+			return true
+		}
+		// Ctrl+Shift+D mirrors the window, to check layouts for right-to-left languages
+		ctrlShift := uint(gdk.CONTROL_MASK | gdk.SHIFT_MASK)
+		if key.State()&ctrlShift == ctrlShift && (key.KeyVal() == gdk.KEY_D || key.KeyVal() == gdk.KEY_d) {
+			toggleTextDirection()
+			return true
+		}
+		return false
+	})
+
+	viewport, _ = getViewPort(builder, "viewport-list")
+	scrolledWindow, _ = getScrolledWindow(builder, "scrolled-window")
+	grid, _ = getGrid(builder, "grid")
+
+	// health check banner above the main grid
+	win.Remove(grid)
+	mainBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	mainBox.PackStart(setUpHealthBanner(), false, false, 0)
+	mainBox.PackStart(grid, true, true, 0)
+	win.Add(mainBox)
+
+	menuBar, _ = getMenuBar(builder, "menubar")
+
+	item1, _ := getMenuItem(builder, "item-widgets")
+	item1.SetLabel(voc["widgets"])
+	item1.Connect("button-release-event", displayThemes)
+
+	item2, _ := getMenuItem(builder, "item-icons")
+	item2.SetLabel(voc["icon-theme"])
+	item2.Connect("button-release-event", displayIconThemes)
+
+	item3, _ := getMenuItem(builder, "item-cursors")
+	item3.SetLabel(voc["mouse-cursor"])
+	item3.Connect("button-release-event", displayCursorThemes)
+
+	item4, _ := getMenuItem(builder, "item-font")
+	item4.SetLabel(voc["font"])
+	item4.Connect("button-release-event", displayFontSettingsForm)
+
+	item5, _ := getMenuItem(builder, "item-other")
+	item5.SetLabel(voc["other"])
+	item5.Connect("button-release-event", displayOtherSettingsForm)
+
+	item6, _ := getMenuItem(builder, "item-preferences")
+	item6.SetLabel(voc["preferences"])
+	item6.Connect("button-release-event", displayProgramSettingsForm)
+
+	item7, _ := getMenuItem(builder, "item-color-sync")
+	item7.SetLabel("Color Sync")
+	item7.Connect("button-release-event", displayColorSyncForm)
+
+	item8, _ := getMenuItem(builder, "item-help")
+	item8.SetLabel("Help")
+	item8.Connect("button-release-event", showHelpWindow)
+
+	btnClose, _ := getButton(builder, "btn-close")
+	btnClose.SetLabel(voc["close"])
+	btnClose.Connect("clicked", func() {
+		gtk.MainQuit()
+	})
+
+	btnApply, _ := getButton(builder, "btn-apply")
+	btnApply.SetLabel(voc["apply"])
+	btnApply.Connect("clicked", func() {
+		applyGsettings()
+		saveGsettingsBackup()
+		recordHistory(historyTheme, gsettings.gtkTheme)
+
+		if preferences.ExportSettingsIni {
+			saveGtkIni3()
+		}
+		if preferences.ExportGtkRc20 {
+			saveGtkRc20()
+		}
+		if preferences.ExportIndexTheme {
+			saveIndexTheme()
+		}
+		if preferences.ExportXsettingsd {
+			saveXsettingsd()
+		}
+		if preferences.ExportGtk4Symlinks {
+			linkGtk4Stuff()
+			saveGtkIni4()
+		}
+		savePreferences()
+
+		// Apply color sync if enabled
+		if colorSyncManager != nil && colorSyncManager.IsEnabled() {
+			go func() {
+				if err := colorSyncManager.ApplyTheme(gsettings.gtkTheme); err != nil {
+					log.Warnf("Failed to sync colors: %v", err)
+				}
+			}()
+		}
+
+		// settings propagate asynchronously
+		go func() {
+			time.Sleep(time.Second)
+			if msg := verifyTheme(); msg != "" {
+				glib.IdleAdd(func() {
+					showMessage(gtk.MESSAGE_WARNING, msg)
+				})
+			}
+		}()
+	})
+	verLabel, _ := getLabel(builder, "version-label")
+	verLabel.SetMarkup(fmt.Sprintf("<b>nwg-look</b> v%s <a href='https://github.com/nwg-piotr/nwg-look'>GitHub</a>", version))
+
+	displayThemes()
+
+	win.ShowAll()
+
+	if colorSyncManager.firstRun {
+		go func() {
+			if a := detectAppearance(); a != nil {
+				glib.IdleAdd(func() {
+					offerAppearanceImport(a)
+				})
+			}
+		}()
+	} else if palette, modified, ok := colorSyncManager.pendingPywalPalette(); ok {
+		glib.IdleAdd(func() {
+			offerPywalPalette(palette, modified)
+		})
+	}
+
+	gtk.Main()
+}
+
+// runProbe is the probe process side: prints the settings and colors GTK resolved, as JSON
+func runProbe() {
+	gtk.Init(nil)
+	probe := ThemeProbe{}
+
+	settings, err := gtk.SettingsGetDefault()
+	if err == nil {
+		for prop, dest := range map[string]*string{
+			"gtk-theme-name":        &probe.GtkTheme,
+			"gtk-icon-theme-name":   &probe.IconTheme,
+			"gtk-cursor-theme-name": &probe.CursorTheme,
+			"gtk-font-name":         &probe.FontName,
+		} {
+			if v, err := settings.GetProperty(prop); err == nil {
+				*dest, _ = v.(string)
+			}
+		}
+	}
+
+	win, err := gtk.OffscreenWindowNew()
+	if err == nil {
+		label, _ := gtk.LabelNew("probe")
+		win.Add(label)
+		win.ShowAll()
+		if sc, err := label.GetStyleContext(); err == nil {
+			if c, ok := sc.LookupColor("theme_bg_color"); ok {
+				probe.BgColor = rgbaToHex(c.GetRed(), c.GetGreen(), c.GetBlue())
+			}
+			if c, ok := sc.LookupColor("theme_fg_color"); ok {
+				probe.FgColor = rgbaToHex(c.GetRed(), c.GetGreen(), c.GetBlue())
+			}
+		}
+	}
+
+	data, _ := json.Marshal(probe)
+	fmt.Println(string(data))
+}
+
+// afterInstall runs cache triggers for a freshly installed asset, and refreshes the UI lists
+func afterInstall(kind, dir string) {
+	switch kind {
+	case assetFont:
+		if err := refreshFontCache(dir); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	if gtkSettings != nil {
+		glib.IdleAdd(func() {
+			refreshAssetLists(kind)
+		})
+	}
+}
+
+// refreshAssetLists rescans installed assets in-process and redisplays the affected list
+func refreshAssetLists(kind string) {
+	switch kind {
+	case assetFont:
+		reloadFontConfig()
+		// the default font chooser lives on the widgets page
+		displayThemes()
+	}
+}
+
+// Assert types to gtk.Builder objects
+func getWindow(b *gtk.Builder, id string) (*gtk.Window, error) {
+	obj, err := b.GetObject(id)
+	if err != nil {
+		return nil, err
+	}
+
+	window, ok := obj.(*gtk.Window)
+	if !ok {
+		return nil, err
+	}
+	return window, nil
+}
+
+func getScrolledWindow(b *gtk.Builder, id string) (*gtk.ScrolledWindow, error) {
+	obj, err := b.GetObject(id)
+	if err != nil {
+		return nil, err
+	}
+
+	window, ok := obj.(*gtk.ScrolledWindow)
+	if !ok {
+		return nil, err
+	}
+	return window, nil
+}
+
+func getViewPort(b *gtk.Builder, id string) (*gtk.Viewport, error) {
+	obj, err := b.GetObject(id)
+	if err != nil {
+		return nil, err
+	}
+	viewport, ok := obj.(*gtk.Viewport)
+	if !ok {
+		return nil, err
+	}
+	return viewport, nil
+}
+
+func getButton(b *gtk.Builder, id string) (*gtk.Button, error) {
+	obj, err := b.GetObject(id)
+	if err != nil {
+		return nil, err
+	}
+	btn, ok := obj.(*gtk.Button)
+	if !ok {
+		return nil, err
+	}
+	return btn, nil
+}
+
+func getGrid(b *gtk.Builder, id string) (*gtk.Grid, error) {
+	obj, err := b.GetObject(id)
+	if err != nil {
+		return nil, err
+	}
+	grid, ok := obj.(*gtk.Grid)
+	if !ok {
+		return nil, err
+	}
+	return grid, nil
+}
+
+func getLabel(b *gtk.Builder, id string) (*gtk.Label, error) {
+	obj, err := b.GetObject(id)
+	if err != nil {
+		return nil, err
+	}
+	label, ok := obj.(*gtk.Label)
+	if !ok {
+		return nil, err
+	}
+	return label, nil
+}
+
+func getMenuBar(b *gtk.Builder, id string) (*gtk.MenuBar, error) {
+	obj, err := b.GetObject(id)
+	if err != nil {
+		return nil, err
+	}
+	menuBar, ok := obj.(*gtk.MenuBar)
+	if !ok {
+		return nil, err
+	}
+	return menuBar, nil
+}
+
+func getMenuItem(b *gtk.Builder, id string) (*gtk.MenuItem, error) {
+	obj, err := b.GetObject(id)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := obj.(*gtk.MenuItem)
+	if !ok {
+		return nil, err
+	}
+	return item, nil
+}
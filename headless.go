@@ -0,0 +1,23 @@
+// headless.go
+//go:build headless
+
+package main
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Built with the headless tag: no GTK, for machines without GTK development libraries (e.g. building
+// dotfiles in CI). The command line interface works, GUI entry points only explain why they don't.
+
+func runGUI() {
+	log.Error("nwg-look was built without the GUI (headless tag), only subcommands and flags work, see nwg-look -h")
+	os.Exit(1)
+}
+
+func runProbe() {
+	log.Error("The theme probe needs GTK, and nwg-look was built without it (headless tag)")
+	os.Exit(1)
+}
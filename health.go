@@ -0,0 +1,74 @@
+// health.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// healthProblem is a missing prerequisite, which would make applying settings silently do nothing
+type healthProblem struct {
+	problem string
+	hint    string
+}
+
+// commandOutput runs a command with a timeout, as D-Bus activation may hang on broken sessions
+func commandOutput(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+// healthCheck verifies what nwg-look relies on: gsettings and its schema, a writable dconf,
+// XDG dirs, a detectable compositor, and the desktop portal
+func healthCheck() []healthProblem {
+	var problems []healthProblem
+	add := func(problem, hint string) {
+		problems = append(problems, healthProblem{problem, hint})
+	}
+
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		add("gsettings not found", "Install glib2 (the package providing the gsettings command).")
+	} else if _, err := commandOutput("gsettings", "list-keys", "org.gnome.desktop.interface"); err != nil {
+		add("org.gnome.desktop.interface schema not available", "Install gsettings-desktop-schemas.")
+	} else if backend := os.Getenv("GSETTINGS_BACKEND"); backend == "memory" {
+		add("GSETTINGS_BACKEND=memory: settings are lost on exit", "Unset GSETTINGS_BACKEND, and install dconf.")
+	} else if out, _ := commandOutput("gsettings", "writable", "org.gnome.desktop.interface", "gtk-theme"); out != "true" {
+		add("gsettings are not writable", "Install dconf, and check that ~/.config/dconf is owned by you.")
+	}
+
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir == "" || !pathExists(dir) {
+		add("XDG_RUNTIME_DIR missing", "Start the session through a login manager or elogind/systemd-logind.")
+	}
+	if !pathExists(configHome()) {
+		add(fmt.Sprintf("%s doesn't exist", configHome()), fmt.Sprintf("Create it: mkdir -p %s", configHome()))
+	}
+
+	if os.Getenv("WAYLAND_DISPLAY") == "" && os.Getenv("DISPLAY") == "" {
+		add("no graphical session detected", "Neither WAYLAND_DISPLAY nor DISPLAY is set.")
+	} else if os.Getenv("XDG_CURRENT_DESKTOP") == "" && os.Getenv("SWAYSOCK") == "" &&
+		os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") == "" && os.Getenv("I3SOCK") == "" {
+		add("compositor not detected", "Export XDG_CURRENT_DESKTOP (e.g. sway, Hyprland) from your compositor config, "+
+			"and import it into the D-Bus/systemd environment.")
+	}
+
+	if _, err := exec.LookPath("gdbus"); err == nil {
+		if _, err := commandOutput("gdbus", "introspect", "--session", "--dest", "org.freedesktop.portal.Desktop",
+			"--object-path", "/org/freedesktop/portal/desktop"); err != nil {
+			add("xdg-desktop-portal not running", "Install xdg-desktop-portal-gtk, so that GTK 4 and Flatpak applications "+
+				"get the color scheme.")
+		}
+	}
+
+	for _, p := range problems {
+		log.Warnf("Health check: %s. %s", p.problem, p.hint)
+	}
+	return problems
+}
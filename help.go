@@ -0,0 +1,240 @@
+// help.go
+//go:build !headless
+
+package main
+
+import (
+	"embed"
+	"path"
+	"strings"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	log "github.com/sirupsen/logrus"
+)
+
+// Built-in guides, so that features are discoverable without leaving the program
+//
+//go:embed docs/*.md
+var helpDocs embed.FS
+
+// helpGuides in the order they're listed in the Help window
+var helpGuides = []string{"color-sync.md", "templates.md", "profiles.md", "cli.md"}
+
+type helpGuide struct {
+	title   string
+	content string
+}
+
+func loadHelpGuides() []helpGuide {
+	var guides []helpGuide
+	for _, name := range helpGuides {
+		data, err := helpDocs.ReadFile(path.Join("docs", name))
+		if err != nil {
+			log.Warnf("Missing help guide %s", name)
+			continue
+		}
+		content := string(data)
+		title := strings.TrimSuffix(name, ".md")
+		if first, _, _ := strings.Cut(content, "\n"); strings.HasPrefix(first, "# ") {
+			title = strings.TrimPrefix(first, "# ")
+		}
+		guides = append(guides, helpGuide{title, content})
+	}
+	return guides
+}
+
+func createHelpTags(buf *gtk.TextBuffer) {
+	buf.CreateTag("h1", map[string]interface{}{"weight": 700, "scale": 1.5, "pixels-below-lines": 6})
+	buf.CreateTag("h2", map[string]interface{}{"weight": 700, "scale": 1.2, "pixels-above-lines": 12, "pixels-below-lines": 4})
+	buf.CreateTag("bold", map[string]interface{}{"weight": 700})
+	buf.CreateTag("code", map[string]interface{}{"family": "monospace"})
+	buf.CreateTag("block", map[string]interface{}{"family": "monospace", "left-margin": 24})
+	buf.CreateTag("match", map[string]interface{}{"background": "yellow", "foreground": "black"})
+}
+
+// insertInline inserts a line of text, rendering `code` and **bold** spans
+func insertInline(buf *gtk.TextBuffer, text string) {
+	for len(text) > 0 {
+		i := strings.IndexAny(text, "`*")
+		if i < 0 {
+			buf.Insert(buf.GetEndIter(), text)
+			return
+		}
+		marker, tag := "`", "code"
+		if text[i] == '*' {
+			marker, tag = "**", "bold"
+		}
+		end := -1
+		if strings.HasPrefix(text[i:], marker) {
+			end = strings.Index(text[i+len(marker):], marker)
+		}
+		if end < 0 {
+			buf.Insert(buf.GetEndIter(), text[:i+1])
+			text = text[i+1:]
+			continue
+		}
+		buf.Insert(buf.GetEndIter(), text[:i])
+		buf.InsertWithTagByName(buf.GetEndIter(), text[i+len(marker):i+len(marker)+end], tag)
+		text = text[i+2*len(marker)+end:]
+	}
+}
+
+// renderMarkdown fills the buffer with the subset of markdown the guides use: headings, paragraphs,
+// bullet lists, code blocks and inline code / bold
+func renderMarkdown(buf *gtk.TextBuffer, md string) {
+	buf.SetText("")
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			insertInline(buf, strings.Join(paragraph, " "))
+			buf.Insert(buf.GetEndIter(), "\n\n")
+			paragraph = nil
+		}
+	}
+	inBlock := false
+	for _, line := range strings.Split(md, "\n") {
+		switch {
+		case strings.HasPrefix(line, "```"):
+			flush()
+			if inBlock {
+				buf.Insert(buf.GetEndIter(), "\n")
+			}
+			inBlock = !inBlock
+		case inBlock:
+			buf.InsertWithTagByName(buf.GetEndIter(), line+"\n", "block")
+		case strings.HasPrefix(line, "# "):
+			flush()
+			buf.InsertWithTagByName(buf.GetEndIter(), line[2:]+"\n", "h1")
+		case strings.HasPrefix(line, "## "):
+			flush()
+			buf.InsertWithTagByName(buf.GetEndIter(), line[3:]+"\n", "h2")
+		case strings.HasPrefix(line, "- "):
+			flush()
+			buf.Insert(buf.GetEndIter(), "  • ")
+			insertInline(buf, line[2:])
+			buf.Insert(buf.GetEndIter(), "\n")
+		case strings.TrimSpace(line) == "":
+			flush()
+		default:
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	flush()
+}
+
+// highlightMatches marks all occurrences of query, and scrolls to the first one
+func highlightMatches(view *gtk.TextView, buf *gtk.TextBuffer, query string) {
+	if query == "" {
+		return
+	}
+	var first *gtk.TextIter
+	iter := buf.GetStartIter()
+	for {
+		start, end, ok := iter.ForwardSearch(query, gtk.TEXT_SEARCH_CASE_INSENSITIVE, nil)
+		if !ok {
+			break
+		}
+		buf.ApplyTagByName("match", start, end)
+		if first == nil {
+			first = start
+		}
+		iter = end
+	}
+	if first != nil {
+		view.ScrollToIter(first, 0.1, false, 0, 0)
+	}
+}
+
+func showHelpWindow() {
+	guides := loadHelpGuides()
+
+	win, _ := gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
+	win.SetTitle("nwg-look help")
+	win.SetDefaultSize(900, 640)
+	win.Connect("key-release-event", func(w *gtk.Window, event *gdk.Event) bool {
+		key := &gdk.EventKey{Event: event}
+		if key.KeyVal() == gdk.KEY_Escape {
+			w.Destroy()
+			return true
+		}
+		return false
+	})
+
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 6)
+	box.SetProperty("margin", 6)
+	win.Add(box)
+
+	searchEntry, _ := gtk.SearchEntryNew()
+	searchEntry.SetPlaceholderText("Search guides")
+	box.PackStart(searchEntry, false, false, 0)
+
+	paned, _ := gtk.PanedNew(gtk.ORIENTATION_HORIZONTAL)
+	box.PackStart(paned, true, true, 0)
+
+	listBox, _ := gtk.ListBoxNew()
+	listBox.SetSizeRequest(200, 0)
+	for _, g := range guides {
+		row, _ := gtk.ListBoxRowNew()
+		label, _ := gtk.LabelNew(g.title)
+		label.SetProperty("halign", gtk.ALIGN_START)
+		label.SetProperty("margin", 6)
+		row.Add(label)
+		listBox.Add(row)
+	}
+	paned.Pack1(listBox, false, false)
+
+	view, _ := gtk.TextViewNew()
+	view.SetEditable(false)
+	view.SetCursorVisible(false)
+	view.SetWrapMode(gtk.WRAP_WORD)
+	view.SetLeftMargin(12)
+	view.SetRightMargin(12)
+	buf, _ := view.GetBuffer()
+	createHelpTags(buf)
+	scrolled, _ := gtk.ScrolledWindowNew(nil, nil)
+	scrolled.Add(view)
+	paned.Pack2(scrolled, true, false)
+
+	query := func() string {
+		text, _ := searchEntry.GetText()
+		return strings.TrimSpace(text)
+	}
+	matches := func(g helpGuide) bool {
+		q := strings.ToLower(query())
+		return q == "" || strings.Contains(strings.ToLower(g.content), q)
+	}
+
+	listBox.SetFilterFunc(func(row *gtk.ListBoxRow) bool {
+		return matches(guides[row.GetIndex()])
+	})
+	show := func(i int) {
+		renderMarkdown(buf, guides[i].content)
+		highlightMatches(view, buf, query())
+	}
+	listBox.Connect("row-selected", func(lb *gtk.ListBox, row *gtk.ListBoxRow) {
+		if row != nil {
+			show(row.GetIndex())
+		}
+	})
+	searchEntry.Connect("search-changed", func() {
+		listBox.InvalidateFilter()
+		if row := listBox.GetSelectedRow(); row != nil && matches(guides[row.GetIndex()]) {
+			// no "row-selected" for the row already selected
+			show(row.GetIndex())
+			return
+		}
+		for i, g := range guides {
+			if matches(g) {
+				listBox.SelectRow(listBox.GetRowAtIndex(i))
+				return
+			}
+		}
+		buf.SetText("Nothing found")
+	})
+
+	if len(guides) > 0 {
+		listBox.SelectRow(listBox.GetRowAtIndex(0))
+	}
+	win.ShowAll()
+}
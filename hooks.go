@@ -0,0 +1,80 @@
+// hooks.go
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HooksConfig holds shell commands run after colors were applied, in addition to the built-in reloads
+type HooksConfig struct {
+	// run once after an apply that wrote files
+	PostApply []string `json:"post-apply,omitempty"`
+	// run after the application's files were written, e.g. "pkill -SIGUSR2 waybar"
+	Apps map[string][]string `json:"apps,omitempty"`
+	// seconds a command may take before it's killed, defaultHookTimeout if unset
+	Timeout int `json:"timeout,omitempty"`
+}
+
+const defaultHookTimeout = 10
+
+// hookPostApply is the name of the global hooks in skip rules
+const hookPostApply = "post-apply"
+
+// runHook runs a command with sh -c, with the application and the files written in the environment:
+// NWG_LOOK_APP, empty for global hooks, and NWG_LOOK_FILES, separated by colons
+func (h *HooksConfig) runHook(command, app string, files []string) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), "NWG_LOOK_APP="+app, "NWG_LOOK_FILES="+strings.Join(files, ":"))
+	// a command leaving a process behind with our output must not hold the apply
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		log.Warnf("Hook '%s' killed after %ds", command, timeout)
+	case err != nil:
+		log.Warnf("Hook '%s' failed: %v", command, err)
+	default:
+		log.Infof("Hook '%s' done in %v", command, time.Since(start).Round(time.Millisecond))
+	}
+	if output := strings.TrimSpace(string(out)); output != "" {
+		log.Info(output)
+	}
+}
+
+// runHooks runs the hooks of the applications whose files were written, then the global ones
+func (h *HooksConfig) runHooks(written []string, files map[string][]string, skipHooks map[string]bool) {
+	if h == nil || len(written) == 0 {
+		return
+	}
+	var all []string
+	for _, app := range written {
+		all = append(all, files[app]...)
+		if skipHooks[app] {
+			continue
+		}
+		for _, command := range h.Apps[app] {
+			h.runHook(command, app, files[app])
+		}
+	}
+	if skipHooks[hookPostApply] {
+		log.Infof("Not running %s hooks on this host/session", hookPostApply)
+		return
+	}
+	for _, command := range h.PostApply {
+		h.runHook(command, "", all)
+	}
+}
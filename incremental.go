@@ -0,0 +1,109 @@
+// incremental.go
+package main
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RenderRecord is what the last render of a template depended on, so that editing one color
+// only rewrites the targets using it
+type RenderRecord struct {
+	Template string   `json:"template"` // sha256 of the template rendered
+	Slots    []string `json:"slots"`    // palette slots the output depends on
+}
+
+// extendedSources are the slots the extended palette is derived from, see extendedColors
+var extendedSources = []string{"background", "foreground", "color1", "color2", "color3", "color4", "color5", "color6"}
+
+// renderDependencies returns the palette slots an output rendered from the template depends on
+func (tm *TemplateManager) renderDependencies(content string) []string {
+	slots := make(map[string]bool)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		if isPaletteSlot(match[1]) {
+			slots[match[1]] = true
+		}
+	}
+	for _, match := range opacityPattern.FindAllStringSubmatch(content, -1) {
+		if isPaletteSlot(match[1]) {
+			slots[match[1]] = true
+		}
+	}
+	if tm.extended && extendedPlaceholderPattern.MatchString(content) {
+		for _, slot := range extendedSources {
+			slots[slot] = true
+		}
+	}
+	// rgba() colors are flattened onto the background, see applyAccessibility
+	if preferences.ReduceTransparency {
+		slots["background"] = true
+	}
+	return sortedKeys(slots)
+}
+
+// renderRecord describes the target's template as it was just rendered; nil if it can't be read
+func (tm *TemplateManager) renderRecord(t colorTarget) *RenderRecord {
+	content, _, err := tm.templateContent(t)
+	if err != nil {
+		return nil
+	}
+	return &RenderRecord{contentHash(content), tm.renderDependencies(string(content))}
+}
+
+// dependsOn tells if the target needs rendering after the slots changed. Targets never rendered,
+// or whose template was edited since, are rendered too.
+func (tm *TemplateManager) dependsOn(manifest *Manifest, t colorTarget, changed []string) bool {
+	last, ok := manifest.Renders[t.template]
+	if !ok {
+		return true
+	}
+	current := tm.renderRecord(t)
+	if current == nil || current.Template != last.Template {
+		return true
+	}
+	for _, slot := range changed {
+		if isIn(last.Slots, slot) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPaletteColor changes one color of the last palette. With auto-apply on, only the targets using
+// that color are rendered again, and only their applications reloaded.
+func (csm *ColorSyncManager) SetPaletteColor(slot, value string) error {
+	if csm.config.LastColors == nil {
+		return fmt.Errorf("no palette applied yet")
+	}
+	if !isPaletteSlot(slot) {
+		return fmt.Errorf("unknown palette slot '%s'", slot)
+	}
+	r, g, b, err := hexToRGB(value)
+	if err != nil {
+		return err
+	}
+
+	palette := copyPalette(csm.config.LastColors)
+	palette.setSlot(slot, rgbToHex(r, g, b))
+	csm.config.LastColors = palette
+	if !csm.config.Enabled || !csm.config.AutoApply {
+		log.Infof("Palette %s set to %s, color sync or auto-apply is off", slot, palette.slot(slot))
+		return csm.saveConfig()
+	}
+
+	if err := csm.templates.applyColors(palette, csm.config.Applications, []string{slot}, nil); err != nil {
+		return fmt.Errorf("failed to apply colors: %w", err)
+	}
+	csm.config.LastApplied = time.Now()
+	csm.refreshAccents(palette)
+	log.Infof("✓ Palette %s set to %s", slot, palette.slot(slot))
+	if err := csm.saveConfig(); err != nil {
+		return err
+	}
+	csm.syncGreetd()
+	csm.syncSddm()
+	syncVtrgb()
+	return nil
+}
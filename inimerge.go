@@ -0,0 +1,103 @@
+// inimerge.go
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// iniKey is a "key = value" line of a rendered INI template
+type iniKey struct {
+	section, key, line string
+}
+
+// parseINIKeys returns the keys set by the rendered template, in order; comments and blank lines are dropped
+func parseINIKeys(rendered string) ([]iniKey, error) {
+	var keys []iniKey
+	section := ""
+	for n, line := range strings.Split(rendered, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";"):
+			continue
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			section = trimmed
+		default:
+			key, _, found := strings.Cut(trimmed, "=")
+			if !found {
+				return nil, fmt.Errorf("line %d: expected key = value", n+1)
+			}
+			keys = append(keys, iniKey{section, strings.TrimSpace(key), trimmed})
+		}
+	}
+	return keys, nil
+}
+
+// mergeINIKeys sets the keys of the rendered INI template in the existing file: keys already there
+// are replaced in place, the others added at the end of their section, missing sections appended.
+// Everything else, comments included, is kept.
+func mergeINIKeys(existing []byte, rendered string) ([]byte, error) {
+	keys, err := parseINIKeys(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("template: %v", err)
+	}
+	pending := make(map[string]map[string]string) // section -> key -> line
+	for _, k := range keys {
+		if pending[k.section] == nil {
+			pending[k.section] = make(map[string]string)
+		}
+		pending[k.section][k.key] = k.line
+	}
+
+	var out []string
+	// flush adds the keys not found in the section, before its trailing blank lines
+	flush := func(section string) {
+		end := len(out)
+		for end > 0 && strings.TrimSpace(out[end-1]) == "" {
+			end--
+		}
+		var add []string
+		for _, k := range keys {
+			if line, ok := pending[k.section][k.key]; ok && k.section == section {
+				add = append(add, line)
+				delete(pending[k.section], k.key)
+			}
+		}
+		out = append(out[:end], append(add, out[end:]...)...)
+	}
+
+	section := ""
+	lines := strings.Split(strings.TrimRight(string(existing), "\n"), "\n")
+	if len(existing) == 0 {
+		lines = nil
+	}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			flush(section)
+			section = trimmed
+		} else if key, _, found := strings.Cut(trimmed, "="); found && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, ";") {
+			if replacement, ok := pending[section][strings.TrimSpace(key)]; ok {
+				line = replacement
+				delete(pending[section], strings.TrimSpace(key))
+			}
+		}
+		out = append(out, line)
+	}
+	flush(section)
+
+	// sections the file doesn't have yet
+	for _, k := range keys {
+		if len(pending[k.section]) == 0 {
+			continue
+		}
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		if k.section != "" {
+			out = append(out, k.section)
+		}
+		flush(k.section)
+	}
+	return []byte(strings.Join(out, "\n") + "\n"), nil
+}
@@ -0,0 +1,86 @@
+// inject.go
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// destination write modes, see DestinationOptions.Write
+const (
+	writeReplace = "replace" // the whole file is nwg-look's (default)
+	writeInject  = "inject"  // only the block between the markers is
+)
+
+const (
+	injectStart = "nwg-look start"
+	injectEnd   = "nwg-look end"
+)
+
+// injects tells if the output goes between markers in a file otherwise owned by the user
+func (o *DestinationOptions) injects() bool {
+	return o != nil && o.Write == writeInject
+}
+
+// markerLines returns the start and end markers, commented out the way the file's language does
+func markerLines(path string) (string, string, error) {
+	var open, close string
+	switch filepath.Ext(path) {
+	case ".css", ".scss", ".rasi":
+		open, close = "/* ", " */"
+	case ".lua":
+		open = "-- "
+	case ".vim":
+		open = "\" "
+	case ".el":
+		open = ";; "
+	case ".json", ".xml", ".tmTheme", ".theme":
+		return "", "", fmt.Errorf("%s files have no comments to mark the block with", filepath.Ext(path))
+	default:
+		open = "# "
+	}
+	return open + injectStart + close, open + injectEnd + close, nil
+}
+
+// injectBetweenMarkers replaces the lines between the "nwg-look start" and "nwg-look end" markers
+// of the existing file with the rendered block. Files without markers get the block appended.
+func injectBetweenMarkers(path string, existing []byte, rendered string) ([]byte, error) {
+	startLine, endLine, err := markerLines(path)
+	if err != nil {
+		return nil, err
+	}
+	block := append([]string{startLine}, strings.Split(strings.TrimRight(rendered, "\n"), "\n")...)
+	block = append(block, endLine)
+
+	var lines []string
+	if len(existing) > 0 {
+		lines = strings.Split(strings.TrimRight(string(existing), "\n"), "\n")
+	}
+	start, end := -1, -1
+	for i, line := range lines {
+		if start < 0 && strings.Contains(line, injectStart) {
+			start = i
+		} else if start >= 0 && strings.Contains(line, injectEnd) {
+			end = i
+			break
+		}
+	}
+
+	var out []string
+	switch {
+	case start < 0:
+		out = lines
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		out = append(out, block...)
+	case end < 0:
+		return nil, fmt.Errorf("line %d: '%s' without '%s'", start+1, injectStart, injectEnd)
+	default:
+		// keep the user's own marker lines, e.g. with different comment syntax
+		block[0], block[len(block)-1] = lines[start], lines[end]
+		out = append(append(append(out, lines[:start]...), block...), lines[end+1:]...)
+	}
+	return []byte(strings.Join(out, "\n") + "\n"), nil
+}
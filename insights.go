@@ -0,0 +1,147 @@
+// insights.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// kinds of history entries
+const (
+	historyTheme  = "theme"  // gsettings applied, Name is the GTK theme
+	historyColors = "colors" // palette synced, Name is its source
+)
+
+// historyLimit is the number of entries kept, older ones are dropped
+const historyLimit = 1000
+
+// HistoryEntry is a line of the history file. The history never leaves this computer.
+type HistoryEntry struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	Name string    `json:"name"`
+}
+
+func historyFile() string {
+	return filepath.Join(stateDir(), "history.jsonl")
+}
+
+func loadHistory() []HistoryEntry {
+	var entries []HistoryEntry
+	f, err := os.Open(historyFile())
+	if err != nil {
+		return entries
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// recordHistory appends an entry to the history file
+func recordHistory(kind, name string) {
+	entries := append(loadHistory(), HistoryEntry{time.Now(), kind, name})
+	if len(entries) > historyLimit {
+		entries = entries[len(entries)-historyLimit:]
+	}
+	var lines []string
+	for _, e := range entries {
+		if data, err := json.Marshal(e); err == nil {
+			lines = append(lines, string(data))
+		}
+	}
+	makeDir(stateDir())
+	if err := os.WriteFile(historyFile(), []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		log.Warnf("Failed to write %s: %v", historyFile(), err)
+	}
+}
+
+type nameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Insights summarize the history
+type Insights struct {
+	Since        time.Time   `json:"since"`
+	ThemeApplies int         `json:"theme-applies"`
+	ColorApplies int         `json:"color-applies"`
+	TopThemes    []nameCount `json:"top-themes"`
+	LastSources  []string    `json:"last-sources"`
+}
+
+func computeInsights(entries []HistoryEntry, top int) Insights {
+	var in Insights
+	counts := make(map[string]int)
+	for i, e := range entries {
+		if i == 0 {
+			in.Since = e.Time
+		}
+		switch e.Kind {
+		case historyTheme:
+			in.ThemeApplies++
+			counts[e.Name]++
+		case historyColors:
+			in.ColorApplies++
+		}
+	}
+	for name, count := range counts {
+		in.TopThemes = append(in.TopThemes, nameCount{name, count})
+	}
+	sort.Slice(in.TopThemes, func(i, j int) bool {
+		if in.TopThemes[i].Count != in.TopThemes[j].Count {
+			return in.TopThemes[i].Count > in.TopThemes[j].Count
+		}
+		return in.TopThemes[i].Name < in.TopThemes[j].Name
+	})
+	if len(in.TopThemes) > top {
+		in.TopThemes = in.TopThemes[:top]
+	}
+	// most recent first, without repetitions
+	seen := make(map[string]bool)
+	for i := len(entries) - 1; i >= 0 && len(in.LastSources) < top; i-- {
+		if e := entries[i]; e.Kind == historyColors && !seen[e.Name] {
+			seen[e.Name] = true
+			in.LastSources = append(in.LastSources, e.Name)
+		}
+	}
+	return in
+}
+
+// insightsMarkup renders the insights for a GtkLabel
+func insightsMarkup(in Insights) string {
+	if in.ThemeApplies == 0 && in.ColorApplies == 0 {
+		return "<i>Nothing recorded yet</i>"
+	}
+	lines := []string{
+		fmt.Sprintf("Since %s: <b>%v</b> theme applies, <b>%v</b> color syncs", in.Since.Format("2006-01-02"),
+			in.ThemeApplies, in.ColorApplies),
+	}
+	if len(in.TopThemes) > 0 {
+		lines = append(lines, "", "<b>Most used themes</b>")
+		for _, t := range in.TopThemes {
+			lines = append(lines, fmt.Sprintf("%s (%v)", html.EscapeString(t.Name), t.Count))
+		}
+	}
+	if len(in.LastSources) > 0 {
+		lines = append(lines, "", "<b>Last palette sources</b>")
+		for _, s := range in.LastSources {
+			lines = append(lines, html.EscapeString(s))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
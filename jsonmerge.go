@@ -0,0 +1,326 @@
+// jsonmerge.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// targetMergers merge rendered templates into files owned by the application, instead of overwriting them
+var targetMergers = map[string]func(existing []byte, rendered string) ([]byte, error){
+	"vscode-colors.json": mergeJSONSettings,
+	"wob.ini":            mergeINIKeys,
+	"avizo.ini":          mergeINIKeys,
+}
+
+// outputFor returns the data to write to path: the rendered template, its merge into the existing file,
+// or the existing file with the rendered block injected
+func (t colorTarget) outputFor(path, rendered string, opts *DestinationOptions) ([]byte, error) {
+	merge, ok := targetMergers[t.template]
+	if !ok && !opts.injects() {
+		return []byte(rendered), nil
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if opts.injects() {
+		return injectBetweenMarkers(path, existing, rendered)
+	}
+	return merge(existing, rendered)
+}
+
+// shared tells if the destination is also the user's, so that it's written without a conflict check
+func (t colorTarget) shared(opts *DestinationOptions) bool {
+	_, merged := targetMergers[t.template]
+	return merged || opts.injects()
+}
+
+// jsonObject is a JSON object that keeps its key order
+type jsonObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func parseJSONObject(data []byte) (*jsonObject, error) {
+	obj := &jsonObject{values: make(map[string]json.RawMessage)}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("not a JSON object")
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := t.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid object key")
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		obj.set(key, value)
+	}
+	return obj, nil
+}
+
+func (o *jsonObject) set(key string, value json.RawMessage) {
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *jsonObject) marshal() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteString(":")
+		buf.Write(o.values[key])
+	}
+	buf.WriteString("}")
+	return buf.Bytes()
+}
+
+// stripJSONComments turns JSONC (as used by VS Code) into JSON: drops comments and trailing commas
+func stripJSONComments(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			// drop a trailing comma
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// jsonMember is a member of the top-level object of a JSONC file, by offsets into the file
+type jsonMember struct {
+	key                  string
+	keyStart             int
+	valueStart, valueEnd int
+}
+
+// skipJSONSpace returns the offset of the next token, past whitespace and comments
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch {
+		case data[i] == ' ' || data[i] == '\t' || data[i] == '\r' || data[i] == '\n':
+			i++
+		case bytes.HasPrefix(data[i:], []byte("//")):
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case bytes.HasPrefix(data[i:], []byte("/*")):
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return len(data)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// skipJSONString returns the offset right after the string starting at i
+func skipJSONString(data []byte, i int) int {
+	for i++; i < len(data) && data[i] != '"'; i++ {
+		if data[i] == '\\' {
+			i++
+		}
+	}
+	return i + 1
+}
+
+// skipJSONValue returns the offset right after the value starting at i
+func skipJSONValue(data []byte, i int) int {
+	switch data[i] {
+	case '"':
+		return skipJSONString(data, i)
+	case '{', '[':
+		depth := 0
+		for i < len(data) {
+			switch {
+			case data[i] == '"':
+				i = skipJSONString(data, i)
+				continue
+			case bytes.HasPrefix(data[i:], []byte("//")) || bytes.HasPrefix(data[i:], []byte("/*")):
+				i = skipJSONSpace(data, i)
+				continue
+			case data[i] == '{' || data[i] == '[':
+				depth++
+			case data[i] == '}' || data[i] == ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			i++
+		}
+		return i
+	}
+	for i < len(data) && !bytes.ContainsRune([]byte(",}] \t\r\n/"), rune(data[i])) {
+		i++
+	}
+	return i
+}
+
+// jsonMembers lists the members of the top-level object of a JSONC file, and the offset of its closing brace.
+// The file must be valid, see mergeJSONSettings.
+func jsonMembers(data []byte) ([]jsonMember, int) {
+	var members []jsonMember
+	i := skipJSONSpace(data, 0) + 1
+	for {
+		i = skipJSONSpace(data, i)
+		if i >= len(data) || data[i] == '}' {
+			return members, i
+		}
+		m := jsonMember{keyStart: i}
+		end := skipJSONValue(data, i)
+		json.Unmarshal(data[i:end], &m.key)
+		i = skipJSONSpace(data, end) + 1 // past the colon
+		m.valueStart = skipJSONSpace(data, i)
+		m.valueEnd = skipJSONValue(data, m.valueStart)
+		members = append(members, m)
+		i = skipJSONSpace(data, m.valueEnd)
+		if i < len(data) && data[i] == ',' {
+			i++
+		}
+	}
+}
+
+// lineIndent returns the whitespace the line of offset i starts with
+func lineIndent(data []byte, i int) string {
+	start := bytes.LastIndexByte(data[:i], '\n') + 1
+	end := start
+	for end < i && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[start:end])
+}
+
+// mergeJSONSettings merges the rendered JSON object into existing settings: objects present
+// on both sides (e.g. "workbench.colorCustomizations") are merged key by key, other values replaced.
+// Only the values of the rendered keys are rewritten: the rest of the file, comments included, is kept as it is.
+func mergeJSONSettings(existing []byte, rendered string) ([]byte, error) {
+	ours, err := parseJSONObject([]byte(rendered))
+	if err != nil {
+		return nil, fmt.Errorf("template: %v", err)
+	}
+	if len(bytes.TrimSpace(existing)) == 0 {
+		var out bytes.Buffer
+		if err := json.Indent(&out, ours.marshal(), "", strings.Repeat(" ", 4)); err != nil {
+			return nil, err
+		}
+		out.WriteString("\n")
+		return out.Bytes(), nil
+	}
+	if _, err := parseJSONObject(stripJSONComments(existing)); err != nil {
+		return nil, fmt.Errorf("can't merge into invalid settings: %v", err)
+	}
+
+	members, closing := jsonMembers(existing)
+	indent := strings.Repeat(" ", 4)
+	if len(members) > 0 && lineIndent(existing, members[0].keyStart) != "" {
+		indent = lineIndent(existing, members[0].keyStart)
+	}
+	found := make(map[string]jsonMember)
+	for _, m := range members {
+		found[m.key] = m
+	}
+	format := func(value json.RawMessage) []byte {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, value, indent, indent); err != nil {
+			return value
+		}
+		return buf.Bytes()
+	}
+
+	// splices, applied from the end of the file so that offsets stay valid
+	type splice struct {
+		start, end int
+		text       []byte
+	}
+	var splices []splice
+	var added []byte
+	for _, key := range ours.keys {
+		value := ours.values[key]
+		m, exists := found[key]
+		if !exists {
+			k, _ := json.Marshal(key)
+			added = append(added, fmt.Sprintf(",\n%s%s: %s", indent, k, format(value))...)
+			continue
+		}
+		sub, err1 := parseJSONObject(stripJSONComments(existing[m.valueStart:m.valueEnd]))
+		add, err2 := parseJSONObject(value)
+		if err1 == nil && err2 == nil {
+			for _, k := range add.keys {
+				sub.set(k, add.values[k])
+			}
+			value = sub.marshal()
+		}
+		splices = append(splices, splice{m.valueStart, m.valueEnd, format(value)})
+	}
+	if len(added) > 0 {
+		if len(members) > 0 {
+			last := members[len(members)-1].valueEnd
+			splices = append(splices, splice{last, last, added})
+		} else {
+			// the first member of an empty object: no comma before it
+			splices = append(splices, splice{closing, closing, append(added[1:], '\n')})
+		}
+	}
+	sort.Slice(splices, func(i, j int) bool { return splices[i].start > splices[j].start })
+
+	out := append([]byte{}, existing...)
+	for _, s := range splices {
+		out = append(out[:s.start], append(append([]byte{}, s.text...), out[s.end:]...)...)
+	}
+	return out, nil
+}
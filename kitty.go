@@ -0,0 +1,94 @@
+// kitty.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// kittyInstances returns the pids of running kitty instances, with the remote control address each listens
+// on, empty if none. Kitty sets KITTY_PID and KITTY_LISTEN_ON in the environment of the programs it runs,
+// so addresses are found whether they come from listen_on in kitty.conf or --listen-on.
+func kittyInstances() map[int]string {
+	instances := make(map[int]string)
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		log.Warnf("Couldn't list processes: %v", err)
+		return instances
+	}
+	addresses := make(map[int]string)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !ownProcess(pid) {
+			continue
+		}
+		if comm, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "comm")); err == nil &&
+			string(bytes.TrimSpace(comm)) == "kitty" {
+			instances[pid] = ""
+		}
+		environ, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "environ"))
+		if err != nil {
+			continue
+		}
+		kittyPid, address := 0, ""
+		for _, v := range bytes.Split(environ, []byte{0}) {
+			if value, ok := strings.CutPrefix(string(v), "KITTY_PID="); ok {
+				kittyPid, _ = strconv.Atoi(value)
+			} else if value, ok := strings.CutPrefix(string(v), "KITTY_LISTEN_ON="); ok {
+				address = value
+			}
+		}
+		if kittyPid > 0 && address != "" {
+			addresses[kittyPid] = address
+		}
+	}
+	for pid := range instances {
+		instances[pid] = addresses[pid]
+	}
+	return instances
+}
+
+// kittenCommand returns the remote control command: kitten, or kitty @ on kitty before 0.28
+func kittenCommand() []string {
+	if _, err := exec.LookPath("kitten"); err == nil {
+		return []string{"kitten", "@"}
+	}
+	if _, err := exec.LookPath("kitty"); err == nil {
+		return []string{"kitty", "@"}
+	}
+	return nil
+}
+
+// recolorKitty pushes the theme files to running kitty instances, so that open windows recolor at once.
+// Instances without remote control are sent SIGUSR1 instead, which has kitty reload its config.
+func recolorKitty(files []string) {
+	kitten := kittenCommand()
+	for pid, address := range kittyInstances() {
+		if address != "" && kitten != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			args := append(kitten[1:], "--to", address, "set-colors", "--all", "--configured")
+			out, err := exec.CommandContext(ctx, kitten[0], append(args, files...)...).CombinedOutput()
+			cancel()
+			if err == nil {
+				log.Infof("Recolored kitty (%v)", pid)
+				continue
+			}
+			// most likely remote control is off, or password-protected
+			log.Debugf("kitty remote control at %s failed: %v %s", address, err, strings.TrimSpace(string(out)))
+		}
+		if err := syscall.Kill(pid, syscall.SIGUSR1); err != nil {
+			log.Debugf("Couldn't signal kitty (%v): %v", pid, err)
+			continue
+		}
+		log.Infof("Reloaded kitty (%v) config", pid)
+	}
+}
@@ -12,38 +12,25 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
-
-	"github.com/gotk3/gotk3/gdk"
-	"github.com/gotk3/gotk3/gtk"
 )
 
 const version = "1.0.6"
 
 var (
-	preferences           programSettings
-	originalGtkConfig     []string // we will append not parsed settings.ini lines from here
-	gtkConfig             gtkConfigProperties
-	gtkSettings           *gtk.Settings
-	gsettings             gsettingsValues
-	dataDirs              []string
-	cursorThemes          map[string]string // theme name to path
-	cursorThemeNames      map[string]string // theme name to theme folder name
-	viewport              *gtk.Viewport
-	scrolledWindow        *gtk.ScrolledWindow
-	listBox               *gtk.ListBox
-	menuBar               *gtk.MenuBar
-	themeSettingsSelector *gtk.Grid
-	grid                  *gtk.Grid
-	preview               *gtk.Frame
-	cursorSizeSelector    *gtk.Box
-	rowToFocus            *gtk.ListBoxRow
-	voc                   map[string]string
-	gtkThemePaths         map[string]string // theme name to path
-	colorSyncManager		*ColorSyncManager
+	preferences       programSettings
+	originalGtkConfig []string // we will append not parsed settings.ini lines from here
+	gtkConfig         gtkConfigProperties
+	gsettings         gsettingsValues
+	dataDirs          []string
+	cursorThemes      map[string]string // theme name to path
+	cursorThemeNames  map[string]string // theme name to theme folder name
+	gtkThemePaths     map[string]string // theme name to path
+	colorSyncManager  *ColorSyncManager
+	// set by -audit: existing files are read, missing ones not created, nothing saved or pruned
+	readOnly bool
 )
 
 type programSettings struct {
@@ -52,6 +39,7 @@ type programSettings struct {
 	ExportIndexTheme   bool `json:"export-index-theme"`
 	ExportXsettingsd   bool `json:"export-xsettingsd"`
 	ExportGtk4Symlinks bool `json:"export-gtk4-symlinks"`
+	ReduceTransparency bool `json:"reduce-transparency"`
 }
 
 func programSettingsNewWithDefaults() programSettings {
@@ -84,6 +72,7 @@ type gtkConfigProperties struct {
 	xftHintstyle               string
 	xftRgba                    string
 	applicationPreferDarkTheme bool
+	enableAnimations           bool
 }
 
 func gtkConfigPropertiesNewWithDefaults() gtkConfigProperties {
@@ -102,6 +91,7 @@ func gtkConfigPropertiesNewWithDefaults() gtkConfigProperties {
 	s.enableInputFeedbackSounds = true
 	s.xftAntialias = -1
 	s.applicationPreferDarkTheme = false
+	s.enableAnimations = true
 
 	val, err := getGsettingsValue("org.gnome.desktop.interface", "font-antialiasing")
 	if err == nil {
@@ -131,6 +121,7 @@ type gsettingsValues struct {
 	fontRgbaOrder     string
 	textScalingFactor float64
 	colorScheme       string
+	enableAnimations  bool
 	// org.gnome.desktop.sound
 	eventSounds         bool
 	inputFeedbackSounds bool
@@ -152,123 +143,25 @@ func gsettingsNewWithDefaults() gsettingsValues {
 	g.eventSounds = true
 	g.inputFeedbackSounds = false
 	g.colorScheme = "default"
+	g.enableAnimations = true
 
 	return g
 }
 
-func displayThemes() {
-	destroyContent()
-	rowToFocus = nil
-
-	listBox = setUpThemeListBox(gsettings.gtkTheme)
-	viewport.Add(listBox)
-	menuBar.Deactivate()
-	if rowToFocus != nil {
-		rowToFocus.GrabFocus()
-	}
-
-	preview = setUpWidgetsPreview()
-	grid.Attach(preview, 1, 1, 1, 1)
-
-	themeSettingsSelector = setUpThemeSettingsForm(gsettings.fontName)
-	themeSettingsSelector.SetProperty("vexpand", true)
-	themeSettingsSelector.SetProperty("valign", gtk.ALIGN_START)
-	grid.Attach(themeSettingsSelector, 1, 2, 1, 1)
-
-	viewport.ShowAll()
-	grid.ShowAll()
-}
-
-func displayIconThemes() {
-	destroyContent()
-	rowToFocus = nil
-
-	listBox = setUpIconThemeListBox(gsettings.iconTheme)
-	viewport.Add(listBox)
-	menuBar.Deactivate()
-	if rowToFocus != nil {
-		rowToFocus.GrabFocus()
-	}
-
-	preview = setUpIconsPreview()
-	grid.Attach(preview, 1, 1, 1, 1)
-
-	viewport.ShowAll()
-	grid.ShowAll()
-}
-
-func displayCursorThemes() {
-	destroyContent()
-	rowToFocus = nil
-
-	listBox = setUpCursorThemeListBox(gsettings.cursorTheme)
-	viewport.Add(listBox)
-	menuBar.Deactivate()
-	if rowToFocus != nil {
-		rowToFocus.GrabFocus()
-	}
-
-	preview = setUpCursorsPreview(cursorThemes[gsettings.cursorTheme])
-	grid.Attach(preview, 1, 1, 1, 1)
-
-	cursorSizeSelector = setUpCursorSizeSelector()
-	grid.Attach(cursorSizeSelector, 1, 2, 1, 1)
-
-	viewport.ShowAll()
-	grid.ShowAll()
-}
-
-func displayFontSettingsForm() {
-	destroyContent()
-
-	preview = setUpFontSettingsForm()
-	grid.Attach(preview, 0, 1, 1, 1)
-	menuBar.Deactivate()
-	grid.ShowAll()
-	scrolledWindow.Hide()
-}
-
-func displayOtherSettingsForm() {
-	destroyContent()
-
-	preview = setUpOtherSettingsForm()
-	grid.Attach(preview, 0, 1, 1, 1)
-	menuBar.Deactivate()
-	grid.ShowAll()
-	scrolledWindow.Hide()
-}
-
-func displayProgramSettingsForm() {
-	destroyContent()
-
-	preview = setUpProgramSettingsForm()
-	grid.Attach(preview, 0, 1, 1, 1)
-	menuBar.Deactivate()
-	grid.ShowAll()
-	scrolledWindow.Hide()
-}
-
-func destroyContent() {
-	if listBox != nil {
-		listBox.Destroy()
-	}
-	if preview != nil {
-		preview.Destroy()
-	}
-	if themeSettingsSelector != nil {
-		themeSettingsSelector.Destroy()
-	}
-	if cursorSizeSelector != nil {
-		cursorSizeSelector.Destroy()
-	}
-}
-
 func main() {
 	var debug = flag.Bool("d", false, "turn on Debug messages")
 	var displayVersion = flag.Bool("v", false, "display Version information")
 	var applyGs = flag.Bool("a", false, "Apply stored gsetting and quit")
 	var restoreDefaults = flag.Bool("r", false, "Restore default values and quit")
 	var exportConfigs = flag.Bool("x", false, "eXport config files and quit")
+	var rotateTheme = flag.Bool("rotate", false, "apply the next theme from the Rotation list if due, and quit")
+	var rotateNow = flag.Bool("rotate-now", false, "apply the next theme from the Rotation list now, and quit")
+	var probe = flag.Bool("probe", false, "print the theme GTK applications see, as JSON, and quit")
+	var auditMode = flag.Bool("audit", false, "report what would change to match the configuration, without writing anything, and quit")
+	var checkTemplatesMode = flag.Bool("check-templates", false, "render all color templates with a dummy palette, report problems, and quit")
+	flag.StringVar(&configDirOverride, "config-dir", "", "config dir (default $NWG_LOOK_CONFIG_DIR or ~/.config/nwg-look)")
+	flag.StringVar(&stateDirOverride, "state-dir", "", "state dir (default $NWG_LOOK_STATE_DIR or ~/.local/share/nwg-look)")
+	flag.StringVar(&cacheDirOverride, "cache-dir", "", "cache dir (default $NWG_LOOK_CACHE_DIR or ~/.cache/nwg-look)")
 	flag.Parse()
 
 	if *displayVersion {
@@ -280,13 +173,15 @@ func main() {
 		log.SetLevel(log.DebugLevel)
 	}
 
-	loadPreferences()
+	if *probe {
+		runProbe()
+		os.Exit(0)
+	}
 
-	lang := detectLang()
-	log.Infof("lang: %s", lang)
+	readOnly = *auditMode
+	loadPreferences()
 
 	dataDirs = getDataDirs()
-	voc = loadVocabulary(lang)
 
 	// Initialize color sync manager
 	initColorSync()
@@ -331,6 +226,32 @@ func main() {
 
 	readGsettings()
 
+	if *rotateTheme || *rotateNow {
+		if err := rotate(*rotateNow); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *auditMode {
+		if audit() > 0 {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *checkTemplatesMode {
+		if checkTemplates() > 0 {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
+
 	if *applyGs || *exportConfigs {
 		if *applyGs {
 			applyGsettingsFromFile()
@@ -357,120 +278,5 @@ func main() {
 		os.Exit(0)
 	}
 
-	cursorThemes, cursorThemeNames = getCursorThemes()
-
-	gtk.Init(nil)
-
-	// update gtkConfig from gtk-3.0/settings.ini
-	if preferences.ExportSettingsIni {
-		loadGtkConfig()
-	}
-
-	gtkSettings, _ = gtk.SettingsGetDefault()
-
-	gladeFile := ""
-	for _, d := range dataDirs {
-		gladeFile = filepath.Join(d, "/nwg-look/main.glade")
-		if pathExists(gladeFile) {
-			break
-		}
-	}
-
-	builder, _ := gtk.BuilderNewFromFile(gladeFile)
-	win, _ := getWindow(builder, "window")
-
-	win.Connect("destroy", func() {
-		gtk.MainQuit()
-	})
-
-	win.Connect("key-release-event", func(window *gtk.Window, event *gdk.Event) bool {
-		key := &gdk.EventKey{Event: event}
-		if key.KeyVal() == gdk.KEY_Escape {
-			gtk.MainQuit()
-			return true
-		}
-		return false
-	})
-
-	viewport, _ = getViewPort(builder, "viewport-list")
-	scrolledWindow, _ = getScrolledWindow(builder, "scrolled-window")
-	grid, _ = getGrid(builder, "grid")
-
-	menuBar, _ = getMenuBar(builder, "menubar")
-
-	item1, _ := getMenuItem(builder, "item-widgets")
-	item1.SetLabel(voc["widgets"])
-	item1.Connect("button-release-event", displayThemes)
-
-	item2, _ := getMenuItem(builder, "item-icons")
-	item2.SetLabel(voc["icon-theme"])
-	item2.Connect("button-release-event", displayIconThemes)
-
-	item3, _ := getMenuItem(builder, "item-cursors")
-	item3.SetLabel(voc["mouse-cursor"])
-	item3.Connect("button-release-event", displayCursorThemes)
-
-	item4, _ := getMenuItem(builder, "item-font")
-	item4.SetLabel(voc["font"])
-	item4.Connect("button-release-event", displayFontSettingsForm)
-
-	item5, _ := getMenuItem(builder, "item-other")
-	item5.SetLabel(voc["other"])
-	item5.Connect("button-release-event", displayOtherSettingsForm)
-
-	item6, _ := getMenuItem(builder, "item-preferences")
-	item6.SetLabel(voc["preferences"])
-	item6.Connect("button-release-event", displayProgramSettingsForm)
-
-    item7, _ := getMenuItem(builder, "item-color-sync")
-    item7.SetLabel("Color Sync")
-    item7.Connect("button-release-event", displayColorSyncForm)
-
-	btnClose, _ := getButton(builder, "btn-close")
-	btnClose.SetLabel(voc["close"])
-	btnClose.Connect("clicked", func() {
-		gtk.MainQuit()
-	})
-
-	btnApply, _ := getButton(builder, "btn-apply")
-	btnApply.SetLabel(voc["apply"])
-	btnApply.Connect("clicked", func() {
-	    applyGsettings()
-	    saveGsettingsBackup()
-	
-	    if preferences.ExportSettingsIni {
-	        saveGtkIni3()
-	    }
-	    if preferences.ExportGtkRc20 {
-	        saveGtkRc20()
-	    }
-	    if preferences.ExportIndexTheme {
-	        saveIndexTheme()
-	    }
-	    if preferences.ExportXsettingsd {
-	        saveXsettingsd()
-	    }
-	    if preferences.ExportGtk4Symlinks {
-	        linkGtk4Stuff()
-	        saveGtkIni4()
-	    }
-	    savePreferences()
-	    
-	    // Apply color sync if enabled
-	    if colorSyncManager != nil && colorSyncManager.IsEnabled() {
-	        go func() {
-	            if err := colorSyncManager.ApplyTheme(gsettings.gtkTheme); err != nil {
-	                log.Warnf("Failed to sync colors: %v", err)
-	            }
-	        }()
-	    }
-	})
-	verLabel, _ := getLabel(builder, "version-label")
-	verLabel.SetMarkup(fmt.Sprintf("<b>nwg-look</b> v%s <a href='https://github.com/nwg-piotr/nwg-look'>GitHub</a>", version))
-
-	displayThemes()
-
-	win.ShowAll()
-
-	gtk.Main()
+	runGUI()
 }
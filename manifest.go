@@ -0,0 +1,100 @@
+// manifest.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// decisions on a destination file that nwg-look didn't generate
+const (
+	conflictOverwrite = "overwrite" // back the file up, then overwrite it
+	conflictAlongside = "alongside" // write to <destination>.nwg-look instead
+	conflictSkip      = "skip"
+)
+
+// Manifest records the files color sync wrote, to tell them from files edited or created by the user
+type Manifest struct {
+	Files     map[string]string `json:"files"`     // destination -> sha256 of the content written
+	Decisions map[string]string `json:"decisions"` // destination -> remembered conflict decision
+	// application -> result of the last apply, see appstatus.go
+	Outcomes map[string]*AppOutcome `json:"outcomes,omitempty"`
+	// template -> what its last render depended on, see incremental.go
+	Renders map[string]*RenderRecord `json:"renders,omitempty"`
+}
+
+func manifestFile() string {
+	return filepath.Join(stateDir(), "manifest.json")
+}
+
+func loadManifest() *Manifest {
+	m := &Manifest{}
+	if data, err := os.ReadFile(manifestFile()); err == nil {
+		if err := json.Unmarshal(data, m); err != nil {
+			log.Warnf("Failed to parse %s: %v", manifestFile(), err)
+		}
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]string)
+	}
+	if m.Decisions == nil {
+		m.Decisions = make(map[string]string)
+	}
+	if m.Outcomes == nil {
+		m.Outcomes = make(map[string]*AppOutcome)
+	}
+	if m.Renders == nil {
+		m.Renders = make(map[string]*RenderRecord)
+	}
+	return m
+}
+
+func (m *Manifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	makeDir(stateDir())
+	return os.WriteFile(manifestFile(), data, 0644)
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// isConflict tells if writing data to path would clobber a file nwg-look didn't generate
+func (m *Manifest) isConflict(path string, data []byte) bool {
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	hash := contentHash(current)
+	if hash == contentHash(data) || hash == m.Files[path] {
+		return false
+	}
+	// written by nwg-look before the manifest existed
+	if _, known := m.Files[path]; !known && strings.Contains(string(current), "Generated by nwg-look") {
+		return false
+	}
+	return true
+}
+
+// backupFile copies the file to <path>.bak
+func backupFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+".bak", data, info.Mode().Perm())
+}
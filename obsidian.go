@@ -0,0 +1,54 @@
+// obsidian.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// obsidianConfigDirs are where Obsidian keeps obsidian.json: native and Flatpak installs
+func obsidianConfigDirs() []string {
+	return []string{
+		filepath.Join(configHome(), "obsidian"),
+		filepath.Join(os.Getenv("HOME"), ".var/app/md.obsidian.Obsidian/config/obsidian"),
+	}
+}
+
+type obsidianVault struct {
+	Path string `json:"path"`
+	Ts   int64  `json:"ts"` // last opened, in milliseconds
+	Open bool   `json:"open"`
+}
+
+// obsidianVaultDir returns the vault open in Obsidian, or else the one opened last.
+// Other vaults may be chosen with a destination path override.
+func obsidianVaultDir() (string, error) {
+	for _, dir := range obsidianConfigDirs() {
+		data, err := os.ReadFile(filepath.Join(dir, "obsidian.json"))
+		if err != nil {
+			continue
+		}
+		var config struct {
+			Vaults map[string]obsidianVault `json:"vaults"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return "", fmt.Errorf("invalid %s: %w", filepath.Join(dir, "obsidian.json"), err)
+		}
+		var found *obsidianVault
+		for _, v := range config.Vaults {
+			v := v
+			if v.Path == "" || !pathExists(v.Path) {
+				continue
+			}
+			if found == nil || v.Open && !found.Open || v.Open == found.Open && v.Ts > found.Ts {
+				found = &v
+			}
+		}
+		if found != nil {
+			return found.Path, nil
+		}
+	}
+	return "", fmt.Errorf("no Obsidian vault found")
+}
@@ -0,0 +1,137 @@
+// opacity.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Translucent backgrounds are set per application in color-sync.json, and filled into templates with
+// {background-alpha}, the opacity alone, and {<color>-rgba}, the color as rgba() with that opacity.
+// {<color>-rgba:0.85} gives a fixed opacity instead. While an application has no opacity set, template lines
+// with {background-alpha} are left out, so that the application's own setting applies.
+
+// minOpacity keeps windows from becoming invisible
+const minOpacity = 0.1
+
+// opacityPattern matches {name-alpha} and {name-rgba}, with an optional fixed opacity after a colon
+var opacityPattern = regexp.MustCompile(`\{(\w+)-(alpha|rgba)(?::(\d*\.?\d+))?\}`)
+
+// unsetAlphaPattern matches the placeholders of lines left out while no opacity is set
+var unsetAlphaPattern = regexp.MustCompile(`\{\w+-alpha\}`)
+
+// opacityFor returns the application's background opacity: 0 if not set, 1 if transparency is reduced
+func (tm *TemplateManager) opacityFor(app string) float64 {
+	if preferences.ReduceTransparency {
+		return 1
+	}
+	if o, ok := tm.opacity[app]; ok && o >= minOpacity && o <= 1 {
+		return o
+	}
+	return 0
+}
+
+// formatAlpha prints the opacity with at most 2 decimals, e.g. 0.85 or 1
+func formatAlpha(alpha float64) string {
+	return strconv.FormatFloat(math.Round(alpha*100)/100, 'f', -1, 64)
+}
+
+// fillOpacity replaces the opacity placeholders of a template, see opacityPattern; opacity 0 is unset
+func fillOpacity(template string, values map[string]string, opacity float64) string {
+	if opacity == 0 {
+		var lines []string
+		for _, line := range strings.Split(template, "\n") {
+			if !unsetAlphaPattern.MatchString(line) {
+				lines = append(lines, line)
+			}
+		}
+		template = strings.Join(lines, "\n")
+		opacity = 1
+	}
+	return opacityPattern.ReplaceAllStringFunc(template, func(match string) string {
+		m := opacityPattern.FindStringSubmatch(match)
+		value, ok := values[m[1]]
+		if !ok {
+			return match
+		}
+		alpha := opacity
+		if m[3] != "" && !preferences.ReduceTransparency {
+			fixed, err := strconv.ParseFloat(m[3], 64)
+			if err != nil || fixed > 1 {
+				return match
+			}
+			alpha = fixed
+		}
+		if m[2] == "alpha" {
+			return formatAlpha(alpha)
+		}
+		r, g, b, err := hexToRGB(value)
+		if err != nil {
+			return match
+		}
+		return fmt.Sprintf("rgba(%d, %d, %d, %s)", r, g, b, formatAlpha(alpha))
+	})
+}
+
+// usesOpacity tells if any template of the application has opacity placeholders
+func (tm *TemplateManager) usesOpacity(app string) bool {
+	for _, t := range colorTargets {
+		if t.app != app {
+			continue
+		}
+		if content, _, err := tm.templateContent(t); err == nil && opacityPattern.Match(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// Opacity returns the background opacity set for the application, 1 if none
+func (csm *ColorSyncManager) Opacity(app string) float64 {
+	if o, ok := csm.config.Opacity[app]; ok {
+		return o
+	}
+	return 1
+}
+
+// SetOpacity sets the application's background opacity, from 0.1 to 1, or removes it if "reset".
+// With auto-apply on, the application's files are rendered again.
+func (csm *ColorSyncManager) SetOpacity(app, value string) error {
+	if !isIn(colorApps(), app) {
+		return fmt.Errorf("unknown application '%s'", app)
+	}
+	if value == "reset" {
+		delete(csm.config.Opacity, app)
+	} else {
+		o, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err == nil && strings.HasSuffix(value, "%") {
+			o /= 100
+		}
+		// written this way round, NaN is rejected too
+		if err != nil || !(o >= minOpacity && o <= 1) {
+			return fmt.Errorf("opacity must be from %v to 1, or 'reset'", minOpacity)
+		}
+		if csm.config.Opacity == nil {
+			csm.config.Opacity = make(map[string]float64)
+		}
+		csm.config.Opacity[app] = o
+	}
+	csm.templates.opacity = csm.config.Opacity
+	if err := csm.saveConfig(); err != nil {
+		return err
+	}
+
+	if !csm.config.Enabled || !csm.config.AutoApply || csm.config.LastColors == nil || !csm.IsAppEnabled(app) {
+		return nil
+	}
+	if err := csm.templates.ApplyColors(csm.config.LastColors, map[string]bool{app: true}); err != nil {
+		return fmt.Errorf("failed to apply colors: %w", err)
+	}
+	log.Infof("✓ %s opacity set to %s", app, formatAlpha(csm.Opacity(app)))
+	return nil
+}
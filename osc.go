@@ -0,0 +1,84 @@
+// osc.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Terminals already open keep their colors until restarted, except those reading the palette from escape
+// sequences: OSC 4 sets the numbered colors, OSC 10, 11 and 12 the foreground, background and cursor. Writing
+// them to the user's pseudo-terminals recolors foot, alacritty, xterm and most others at once, as pywal does.
+
+// hookTerminals is the name of the terminal recolor in skip rules
+const hookTerminals = "terminals"
+
+// oscSequences returns the escape sequences setting the palette
+func oscSequences(palette *ColorPalette, extended bool) string {
+	var b strings.Builder
+	valid := func(value string) bool {
+		_, _, _, err := hexToRGB(value)
+		return err == nil
+	}
+	colors := palette.Colors
+	if extended {
+		colors = make(map[string]string, 256)
+		for name, value := range palette.Colors {
+			colors[name] = value
+		}
+		for name, value := range extendedColors(palette) {
+			colors[name] = value
+		}
+	}
+	for i := 0; i < 256; i++ {
+		if value, ok := colors[fmt.Sprintf("color%d", i)]; ok && valid(value) {
+			fmt.Fprintf(&b, "\033]4;%d;%s\033\\", i, value)
+		}
+	}
+	for i, value := range []string{palette.Foreground, palette.Background, palette.Cursor} {
+		if valid(value) {
+			fmt.Fprintf(&b, "\033]%d;%s\033\\", 10+i, value)
+		}
+	}
+	return b.String()
+}
+
+// recolorTerminals writes the palette to the user's pseudo-terminals. Best effort: terminals ignoring
+// the sequences just don't change, and a terminal busy with a full-screen program may show it repainted.
+func recolorTerminals(palette *ColorPalette, extended bool) {
+	ptys, err := filepath.Glob("/dev/pts/[0-9]*")
+	if err != nil {
+		return
+	}
+	sequences := []byte(oscSequences(palette, extended))
+	count := 0
+	for _, pty := range ptys {
+		info, err := os.Stat(pty)
+		if err != nil {
+			continue
+		}
+		if st, ok := info.Sys().(*syscall.Stat_t); !ok || int(st.Uid) != os.Getuid() {
+			continue
+		}
+		// not to become the controlling terminal, nor to block on a stuck one
+		f, err := os.OpenFile(pty, os.O_WRONLY|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			log.Debugf("Couldn't open %s: %v", pty, err)
+			continue
+		}
+		if _, err := f.Write(sequences); err != nil {
+			log.Debugf("Couldn't write to %s: %v", pty, err)
+		} else {
+			count++
+		}
+		f.Close()
+	}
+	if count > 0 {
+		log.Infof("Recolored %v open terminals", count)
+	}
+}
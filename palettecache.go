@@ -0,0 +1,77 @@
+// palettecache.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func paletteCacheFile(themeName string) string {
+	return filepath.Join(cacheDir(), "palettes", strings.ReplaceAll(themeName, "/", "_")+".json")
+}
+
+// cachedExtract extracts the theme palette, reusing the cached result unless the theme's gtk.css
+// or the color mapping rules have changed since
+func (ce *ColorExtractor) cachedExtract(themeName string) (*ColorPalette, error) {
+	themePath := ce.FindThemePath(themeName)
+	if themePath == "" {
+		return nil, fmt.Errorf("theme %s not found", themeName)
+	}
+	cacheFile := paletteCacheFile(themeName)
+	if cacheInfo, err := os.Stat(cacheFile); err == nil {
+		fresh := true
+		for _, source := range []string{filepath.Join(themePath, "gtk.css"), colorMappingFile()} {
+			if info, err := os.Stat(source); err == nil && info.ModTime().After(cacheInfo.ModTime()) {
+				fresh = false
+			}
+		}
+		if fresh {
+			if data, err := os.ReadFile(cacheFile); err == nil {
+				palette := &ColorPalette{}
+				if err := json.Unmarshal(data, palette); err == nil {
+					log.Debugf("Palette of %s loaded from cache", themeName)
+					return palette, nil
+				}
+			}
+		}
+	}
+
+	palette, err := ce.ExtractColors(themeName)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(palette); err == nil {
+		makeDir(filepath.Dir(cacheFile))
+		if err := os.WriteFile(cacheFile, data, 0644); err != nil {
+			log.Debugf("Failed to cache palette: %v", err)
+		}
+	}
+	return palette, nil
+}
+
+// paletteColumns are the palette slots in matrix order
+func paletteColumns() []string {
+	columns := []string{"background", "foreground", "cursor"}
+	for i := 0; i < 16; i++ {
+		columns = append(columns, fmt.Sprintf("color%d", i))
+	}
+	return columns
+}
+
+// slot returns the value of a palette slot, see setSlot
+func (p *ColorPalette) slot(name string) string {
+	switch name {
+	case "background":
+		return p.Background
+	case "foreground":
+		return p.Foreground
+	case "cursor":
+		return p.Cursor
+	}
+	return p.Colors[name]
+}
@@ -0,0 +1,54 @@
+// paletteschema.go
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// paletteSchema is the version of the palette model templates are rendered with.
+// 1: background, foreground, cursor, color0-color15; strip and rgb modifiers
+// 2: color16-color255, filled if the extended palette is on
+const paletteSchema = 2
+
+// paletteCapabilities maps features a template may require to the schema version providing them
+var paletteCapabilities = map[string]int{
+	"ansi16":    1,
+	"modifiers": 1,
+	"ansi256":   2,
+	"opacity":   2,
+}
+
+// e.g. "# nwg-look-palette: 1" and "/* nwg-look-requires: ansi16, modifiers */" in a template comment
+var (
+	templateSchemaPattern   = regexp.MustCompile(`nwg-look-palette:\s*(\d+)`)
+	templateRequiresPattern = regexp.MustCompile(`nwg-look-requires:[ \t]*([\w \t,-]+)`)
+)
+
+// checkTemplateCompat verifies that the template's declared needs are met by this palette model.
+// Templates that declare nothing are assumed to be written for schema 1.
+func checkTemplateCompat(name, content string) error {
+	if m := templateSchemaPattern.FindStringSubmatch(content); m != nil {
+		v, _ := strconv.Atoi(m[1])
+		if v > paletteSchema {
+			return fmt.Errorf("template %s needs palette schema %d, but nwg-look %s provides %d: "+
+				"update nwg-look, or lower the nwg-look-palette line at your own risk", name, v, version, paletteSchema)
+		}
+	}
+	if m := templateRequiresPattern.FindStringSubmatch(content); m != nil {
+		for _, feature := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			v, known := paletteCapabilities[feature]
+			if !known {
+				return fmt.Errorf("template %s requires '%s', which palette schema %d doesn't provide: "+
+					"update nwg-look, or remove it from the nwg-look-requires line", name, feature, paletteSchema)
+			}
+			if v > paletteSchema {
+				return fmt.Errorf("template %s requires '%s' from palette schema %d, this nwg-look provides %d: "+
+					"update nwg-look", name, feature, v, paletteSchema)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+// pipeline.go
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// A pipeline is a list of steps, e.g. ["extract", "dark-variant", "saturation 0.9", "contrast-fix", "apply"].
+// It starts with a source step: "extract [theme]", "import <file>" or "last" (the last applied palette),
+// followed by transforms, and usually ends with "apply" and/or "export-gtk".
+
+// paletteTransform returns a modified copy of the palette
+type paletteTransform func(p *ColorPalette, args []string) (*ColorPalette, error)
+
+var paletteTransforms = map[string]paletteTransform{
+	"dark-variant":  darkVariant,
+	"light-variant": lightVariant,
+	"saturation":    scaleSaturation,
+	"lightness":     scaleLightness,
+	"contrast-fix":  fixContrast,
+}
+
+// copyPalette returns a deep copy, so that transforms never modify the source
+func copyPalette(p *ColorPalette) *ColorPalette {
+	c := &ColorPalette{Background: p.Background, Foreground: p.Foreground, Cursor: p.Cursor,
+		Colors: make(map[string]string)}
+	for name, value := range p.Colors {
+		c.Colors[name] = value
+	}
+	return c
+}
+
+// mapColors applies f to every color of a copy of the palette
+func mapColors(p *ColorPalette, f func(string) string) *ColorPalette {
+	c := copyPalette(p)
+	c.Background, c.Foreground, c.Cursor = f(c.Background), f(c.Foreground), f(c.Cursor)
+	for name, value := range c.Colors {
+		c.Colors[name] = f(value)
+	}
+	return c
+}
+
+// floatArg parses the single optional numeric argument of a transform
+func floatArg(args []string, fallback float64) (float64, error) {
+	if len(args) == 0 {
+		return fallback, nil
+	}
+	v, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid argument '%s'", args[0])
+	}
+	return v, nil
+}
+
+// swapTones exchanges background with foreground, and the dark with the light ANSI grays
+func swapTones(p *ColorPalette) *ColorPalette {
+	c := copyPalette(p)
+	c.Background, c.Foreground = p.Foreground, p.Background
+	c.Cursor = p.Background
+	c.Colors["color0"], c.Colors["color7"] = p.Colors["color7"], p.Colors["color0"]
+	c.Colors["color8"], c.Colors["color15"] = p.Colors["color15"], p.Colors["color8"]
+	return c
+}
+
+func darkVariant(p *ColorPalette, args []string) (*ColorPalette, error) {
+	if isDarkColor(p.Background) {
+		return copyPalette(p), nil
+	}
+	return swapTones(p), nil
+}
+
+func lightVariant(p *ColorPalette, args []string) (*ColorPalette, error) {
+	if !isDarkColor(p.Background) {
+		return copyPalette(p), nil
+	}
+	return swapTones(p), nil
+}
+
+func scaleSaturation(p *ColorPalette, args []string) (*ColorPalette, error) {
+	factor, err := floatArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return mapColors(p, func(hex string) string {
+		h, s, l, err := hexToHSL(hex)
+		if err != nil {
+			return hex
+		}
+		return hslToHex(h, s*factor, l)
+	}), nil
+}
+
+func scaleLightness(p *ColorPalette, args []string) (*ColorPalette, error) {
+	factor, err := floatArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return mapColors(p, func(hex string) string {
+		h, s, l, err := hexToHSL(hex)
+		if err != nil {
+			return hex
+		}
+		return hslToHex(h, s, l*factor)
+	}), nil
+}
+
+// fixContrast moves the foreground and ANSI colors away from the background, until they reach
+// the given WCAG contrast ratio (4.5 by default)
+func fixContrast(p *ColorPalette, args []string) (*ColorPalette, error) {
+	ratio, err := floatArg(args, 4.5)
+	if err != nil {
+		return nil, err
+	}
+	c := copyPalette(p)
+	target := "#ffffff"
+	if !isDarkColor(c.Background) {
+		target = "#000000"
+	}
+	fix := func(hex string) string {
+		fixed := hex
+		for t := 0.05; t <= 1.0 && contrastRatio(fixed, c.Background) < ratio; t += 0.05 {
+			fixed = mixColors(hex, target, t)
+		}
+		return fixed
+	}
+	c.Foreground = fix(c.Foreground)
+	for name, value := range c.Colors {
+		// color0 and color8 are meant to be close to the background
+		if name != "color0" && name != "color8" {
+			c.Colors[name] = fix(value)
+		}
+	}
+	return c, nil
+}
+
+// runPipeline executes the named pipeline from the color sync config, and returns the resulting palette
+func (csm *ColorSyncManager) runPipeline(name string) (*ColorPalette, error) {
+	steps, ok := csm.config.Pipelines[name]
+	if !ok {
+		return nil, fmt.Errorf("no such pipeline '%s'", name)
+	}
+	log.Infof(">>> Running pipeline '%s'", name)
+
+	var palette *ColorPalette
+	source := "pipeline " + name
+	for i, step := range steps {
+		fields := strings.Fields(step)
+		if len(fields) == 0 {
+			continue
+		}
+		op, args := fields[0], fields[1:]
+		if palette == nil && op != "extract" && op != "import" && op != "last" {
+			return nil, fmt.Errorf("pipeline '%s': step %v: '%s' needs a palette, start with extract, import or last", name, i+1, op)
+		}
+
+		var err error
+		switch op {
+		case "extract":
+			themeName := gsettings.gtkTheme
+			if len(args) > 0 {
+				themeName = strings.Join(args, " ")
+			}
+			palette, err = csm.extractor.ExtractColors(themeName)
+		case "import":
+			if len(args) == 0 {
+				err = fmt.Errorf("import needs a file name")
+				break
+			}
+			palette, err = ImportPalette(expandPath(strings.Join(args, " ")))
+		case "last":
+			if palette = csm.config.LastColors; palette == nil {
+				err = fmt.Errorf("no palette applied yet")
+			}
+		case "apply":
+			err = csm.ApplyPalette(palette, source)
+		case "export-gtk":
+			err = ExportGtkColors(palette)
+		default:
+			transform, known := paletteTransforms[op]
+			if !known {
+				err = fmt.Errorf("unknown step, available transforms: %s", strings.Join(transformNames(), ", "))
+				break
+			}
+			palette, err = transform(palette, args)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pipeline '%s': step %v (%s): %v", name, i+1, step, err)
+		}
+	}
+	return palette, nil
+}
+
+// pipelineNames returns the names of the pipelines defined in the config
+func (csm *ColorSyncManager) pipelineNames() []string {
+	var names []string
+	for name := range csm.config.Pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func transformNames() []string {
+	var names []string
+	for name := range paletteTransforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
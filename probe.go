@@ -0,0 +1,87 @@
+// probe.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ThemeProbe is what a freshly started GTK application sees
+type ThemeProbe struct {
+	GtkTheme    string `json:"gtk-theme"`
+	IconTheme   string `json:"icon-theme"`
+	CursorTheme string `json:"cursor-theme"`
+	FontName    string `json:"font-name"`
+	BgColor     string `json:"theme-bg-color"`
+	FgColor     string `json:"theme-fg-color"`
+}
+
+func rgbaToHex(r, g, b float64) string {
+	return rgbToHex(int(r*255+0.5), int(g*255+0.5), int(b*255+0.5))
+}
+
+// probeTheme starts the probe in a new process, so that it sees the settings as any other application would
+func probeTheme() (*ThemeProbe, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, exe, "-probe").Output()
+	if err != nil {
+		return nil, fmt.Errorf("theme probe failed: %v", err)
+	}
+	probe := &ThemeProbe{}
+	if err := json.Unmarshal(out, probe); err != nil {
+		return nil, fmt.Errorf("theme probe: %v", err)
+	}
+	return probe, nil
+}
+
+// verifyTheme checks that the applied settings actually reach GTK applications.
+// Returns a diagnostic message, or "" if all is well.
+func verifyTheme() string {
+	probe, err := probeTheme()
+	if err != nil {
+		log.Warn(err)
+		return ""
+	}
+
+	var problems []string
+	check := func(what, want, got string) {
+		if want != "" && want != got {
+			problems = append(problems, fmt.Sprintf("%s is '%s' instead of '%s'", what, got, want))
+		}
+	}
+	check("GTK theme", gsettings.gtkTheme, probe.GtkTheme)
+	check("icon theme", gsettings.iconTheme, probe.IconTheme)
+	check("cursor theme", gsettings.cursorTheme, probe.CursorTheme)
+	check("font", gsettings.fontName, probe.FontName)
+	if len(problems) == 0 {
+		log.Infof("✓ Theme verified: %s, background %s", probe.GtkTheme, probe.BgColor)
+		return ""
+	}
+
+	var hint string
+	switch {
+	case os.Getenv("GTK_THEME") != "":
+		hint = fmt.Sprintf("GTK_THEME=%s is set in the environment, and overrides the theme.", os.Getenv("GTK_THEME"))
+	case os.Getenv("WAYLAND_DISPLAY") == "" && os.Getenv("DISPLAY") != "":
+		hint = "On X11, GTK reads settings from xsettingsd: make sure it's running, and exporting its config is enabled in Preferences."
+	case os.Getenv("GSETTINGS_BACKEND") != "" && os.Getenv("GSETTINGS_BACKEND") != "dconf":
+		hint = fmt.Sprintf("GSETTINGS_BACKEND=%s: settings are not shared through dconf.", os.Getenv("GSETTINGS_BACKEND"))
+	default:
+		hint = "gsettings changes don't reach GTK applications: check that dconf is installed and the session bus is running."
+	}
+	msg := fmt.Sprintf("GTK applications don't see the applied settings: %s.\n%s", strings.Join(problems, ", "), hint)
+	log.Warn(msg)
+	return msg
+}
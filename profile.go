@@ -0,0 +1,186 @@
+// profile.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Profile is a named snapshot of the appearance settings
+type Profile struct {
+	Name        string `json:"name"`
+	GtkTheme    string `json:"gtk-theme"`
+	IconTheme   string `json:"icon-theme"`
+	CursorTheme string `json:"cursor-theme"`
+	CursorSize  int    `json:"cursor-size"`
+	FontName    string `json:"font-name"`
+	ColorScheme string `json:"color-scheme"`
+	// optional, see wallpaper.go
+	Wallpaper *ProfileWallpaper `json:"wallpaper,omitempty"`
+}
+
+func profilesDir() string {
+	return filepath.Join(configDir(), "profiles")
+}
+
+func profileFile(name string) string {
+	return filepath.Join(profilesDir(), name+".json")
+}
+
+// profileFromGsettings captures the current settings as a profile
+func profileFromGsettings(name string) *Profile {
+	return &Profile{
+		Name:        name,
+		GtkTheme:    gsettings.gtkTheme,
+		IconTheme:   gsettings.iconTheme,
+		CursorTheme: gsettings.cursorTheme,
+		CursorSize:  gsettings.cursorSize,
+		FontName:    gsettings.fontName,
+		ColorScheme: gsettings.colorScheme,
+	}
+}
+
+// presetProfile wraps a bare GTK theme name, leaving other settings untouched
+func presetProfile(themeName string) *Profile {
+	p := profileFromGsettings(themeName)
+	p.GtkTheme = themeName
+	return p
+}
+
+func loadProfile(name string) (*Profile, error) {
+	data, err := os.ReadFile(profileFile(name))
+	if err != nil {
+		return nil, err
+	}
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", name, err)
+	}
+	if p.Name == "" {
+		p.Name = name
+	}
+	return &p, nil
+}
+
+// saveProfile saves the profile; the wallpaper isn't part of gsettings, so one set before is kept
+func saveProfile(p *Profile) error {
+	if p.Wallpaper == nil && pathExists(profileFile(p.Name)) {
+		if old, err := loadProfile(p.Name); err == nil {
+			p.Wallpaper = old.Wallpaper
+		}
+	}
+	return writeProfile(p)
+}
+
+func writeProfile(p *Profile) error {
+	if p.Name == "" || strings.ContainsAny(p.Name, "/\\") {
+		return fmt.Errorf("invalid profile name: '%s'", p.Name)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	makeDir(profilesDir())
+	log.Infof("Saving profile '%s'", p.Name)
+	return os.WriteFile(profileFile(p.Name), data, 0644)
+}
+
+// listProfiles returns sorted names of saved profiles
+func listProfiles() []string {
+	var names []string
+	files, err := listFiles(profilesDir())
+	if err != nil {
+		return names
+	}
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".json") {
+			names = append(names, strings.TrimSuffix(f.Name(), ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveProfile returns the saved profile, or a theme preset if there's no profile of this name
+func resolveProfile(name string) (*Profile, error) {
+	if pathExists(profileFile(name)) {
+		return loadProfile(name)
+	}
+	if _, themePaths := getThemeNames(); themePaths[name] != "" {
+		return presetProfile(name), nil
+	}
+	return nil, fmt.Errorf("no profile or theme named '%s'", name)
+}
+
+// applyProfile applies the profile settings, exports config files, syncs colors and sets the wallpaper.
+// A wallpaper that can't be set stops the profile from being applied, so that the look doesn't flip halfway.
+func applyProfile(p *Profile) error {
+	if p.Wallpaper != nil {
+		if err := p.Wallpaper.check(); err != nil {
+			return fmt.Errorf("profile '%s': %w", p.Name, err)
+		}
+	}
+	createRestorePoint(fmt.Sprintf("before profile '%s'", p.Name))
+	applyProfileSettings(p)
+
+	if colorSyncManager != nil && colorSyncManager.IsEnabled() {
+		if err := colorSyncManager.ApplyTheme(gsettings.gtkTheme); err != nil {
+			log.Warnf("Failed to sync colors: %v", err)
+		}
+	}
+	if p.Wallpaper != nil {
+		return p.Wallpaper.apply()
+	}
+	return nil
+}
+
+// applyProfileSettings applies the profile settings and exports config files
+func applyProfileSettings(p *Profile) {
+	log.Infof(">>> Applying profile '%s'", p.Name)
+	if p.GtkTheme != "" {
+		gsettings.gtkTheme = p.GtkTheme
+	}
+	if p.IconTheme != "" {
+		gsettings.iconTheme = p.IconTheme
+	}
+	if p.CursorTheme != "" {
+		gsettings.cursorTheme = p.CursorTheme
+	}
+	if p.CursorSize > 0 {
+		gsettings.cursorSize = p.CursorSize
+	}
+	if p.FontName != "" {
+		gsettings.fontName = p.FontName
+	}
+	if p.ColorScheme != "" {
+		gsettings.colorScheme = p.ColorScheme
+	}
+
+	applyGsettings()
+	saveGsettingsBackup()
+	recordHistory(historyTheme, gsettings.gtkTheme)
+
+	if preferences.ExportSettingsIni {
+		saveGtkIni3()
+	}
+	if preferences.ExportGtkRc20 {
+		saveGtkRc20()
+	}
+	if preferences.ExportIndexTheme {
+		saveIndexTheme()
+	}
+	if preferences.ExportXsettingsd {
+		saveXsettingsd()
+	}
+	if preferences.ExportGtk4Symlinks {
+		_, gtkThemePaths = getThemeNames()
+		linkGtk4Stuff()
+		saveGtkIni4()
+	}
+}
@@ -0,0 +1,59 @@
+// profilediff.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// diffProfile lists what applying the profile would change: settings, palette entries and color files.
+// Only differences are returned, as audit items with the profile's value as Wanted.
+func diffProfile(p *Profile) []AuditItem {
+	var items []AuditItem
+	setting := func(key, current, wanted string) {
+		// empty profile values leave the setting untouched, see applyProfileSettings
+		if wanted != "" && wanted != "0" && current != wanted {
+			items = append(items, AuditItem{Kind: "setting", Target: key, Status: auditChanged, Current: current, Wanted: wanted})
+		}
+	}
+	setting("gtk-theme", gsettings.gtkTheme, p.GtkTheme)
+	setting("icon-theme", gsettings.iconTheme, p.IconTheme)
+	setting("cursor-theme", gsettings.cursorTheme, p.CursorTheme)
+	setting("cursor-size", strconv.Itoa(gsettings.cursorSize), strconv.Itoa(p.CursorSize))
+	setting("font-name", gsettings.fontName, p.FontName)
+	setting("color-scheme", gsettings.colorScheme, p.ColorScheme)
+	if p.Wallpaper != nil {
+		// the current wallpaper can't be read back from the setters
+		setting("wallpaper", "", p.Wallpaper.Path)
+	}
+
+	if !colorSyncManager.IsEnabled() {
+		return items
+	}
+	themeName := p.GtkTheme
+	if themeName == "" {
+		themeName = gsettings.gtkTheme
+	}
+	wanted, err := colorSyncManager.extractor.cachedExtract(themeName)
+	if err != nil {
+		items = append(items, AuditItem{Kind: "palette", Target: themeName, Status: auditMissing,
+			Current: fmt.Sprintf("%v", err)})
+		return items
+	}
+	current := colorSyncManager.config.LastColors
+	for _, slot := range paletteColumns() {
+		c := ""
+		if current != nil {
+			c = current.slot(slot)
+		}
+		if w := wanted.slot(slot); c != w {
+			items = append(items, AuditItem{Kind: "palette", Target: slot, Status: auditChanged, Current: c, Wanted: w})
+		}
+	}
+	for _, item := range compareColorFiles(wanted) {
+		if item.Status != auditOK {
+			items = append(items, item)
+		}
+	}
+	return items
+}
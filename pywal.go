@@ -0,0 +1,40 @@
+// pywal.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// pywalColorsFile is where pywal writes the palette of the last `wal` run
+func pywalColorsFile() string {
+	return filepath.Join(cacheHome(), "wal/colors.json")
+}
+
+// pendingPywalPalette returns the pywal palette if it was generated after the colors nwg-look applied last,
+// and hasn't been offered yet. The returned time identifies the change, see markPywalOffered.
+func (csm *ColorSyncManager) pendingPywalPalette() (*ColorPalette, time.Time, bool) {
+	info, err := os.Stat(pywalColorsFile())
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	modified := info.ModTime()
+	if !modified.After(csm.config.LastApplied) || modified.Equal(csm.config.PywalOffered) {
+		return nil, modified, false
+	}
+	palette, err := ImportPalette(pywalColorsFile())
+	if err != nil {
+		log.Warnf("Couldn't read the pywal palette: %v", err)
+		return nil, modified, false
+	}
+	return palette, modified, true
+}
+
+// markPywalOffered remembers the pywal palette change, so that it's offered once
+func (csm *ColorSyncManager) markPywalOffered(modified time.Time) {
+	csm.config.PywalOffered = modified
+	csm.saveConfig()
+}
@@ -0,0 +1,225 @@
+// reload.go
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// appReloadCommands are run after an application's colors have been written
+var appReloadCommands = map[string][]string{
+	"mako": {"makoctl", "reload"},
+	"bat":  {"bat", "cache", "--build"},
+	// a new shell sources conf.d, setting universal variables, which running shells pick up
+	"fish": {"fish", "-c", "true"},
+	// only if the theme is in use, and the Emacs server running
+	"emacs": {"emacsclient", "-e", "(when (custom-theme-enabled-p 'nwg-look) (load-theme 'nwg-look t))"},
+}
+
+// sessionReload is a compositor reload, run if the compositor runs the session, told by its environment variable
+type sessionReload struct {
+	env     string
+	command []string
+}
+
+// sessionReloadCommands have the compositor re-read its config, with the colors included
+var sessionReloadCommands = map[string]sessionReload{
+	"hyprland": {"HYPRLAND_INSTANCE_SIGNATURE", []string{"hyprctl", "reload"}},
+	"sway":     {"SWAYSOCK", []string{"swaymsg", "reload"}},
+}
+
+// optInReloadCommands reload the whole application config, or restart it, so they only run if enabled in the "reload" section
+var optInReloadCommands = map[string][]string{
+	"i3": {"i3-msg", "reload"},
+	// patches the Spotify client, and restarts it if running
+	"spicetify": {"spicetify", "apply"},
+}
+
+// appRespawnProcesses only read their style on startup, so running instances get restarted.
+// Process names are as in /proc/<pid>/comm, i.e. cut to 15 characters.
+var appRespawnProcesses = map[string][]string{
+	"nwg-drawer":        {"nwg-drawer"},
+	"nwg-panel":         {"nwg-panel"},
+	"nwg-dock":          {"nwg-dock"},
+	"nwg-dock-hyprland": {"nwg-dock-hyprla"},
+	"swayosd":           {"swayosd-server"},
+	"avizo":             {"avizo-service"},
+}
+
+// appReloadSignals are sent to the application's running processes, by process name
+var appReloadSignals = map[string]map[string]syscall.Signal{
+	// reloads config and style
+	"waybar": {"waybar": syscall.SIGUSR2},
+}
+
+// appLiveReloads push the files written to running instances of the application
+var appLiveReloads = map[string]func(files []string){
+	"kitty": recolorKitty,
+	"dunst": reloadDunst,
+}
+
+// reloadApp asks a running application to pick up its new colors, from the files written
+func reloadApp(app string, files []string, optIn map[string]bool) {
+	// built-in reloads may be turned off, e.g. for a hook restarting the application instead
+	if on, ok := optIn[app]; ok && !on {
+		log.Debugf("Not reloading %s: turned off in \"reload\"", app)
+		return
+	}
+	if signals, ok := appReloadSignals[app]; ok {
+		for process, signal := range signals {
+			signalProcess(process, signal)
+		}
+		return
+	}
+	if live, ok := appLiveReloads[app]; ok {
+		live(files)
+		return
+	}
+	if processes, ok := appRespawnProcesses[app]; ok {
+		for _, process := range processes {
+			respawnProcess(process)
+		}
+		return
+	}
+	command, ok := appReloadCommands[app]
+	if r, session := sessionReloadCommands[app]; session {
+		if os.Getenv(r.env) == "" {
+			log.Debugf("Not reloading %s: not running in this session", app)
+			return
+		}
+		command, ok = r.command, true
+	}
+	if !ok {
+		command, ok = optInReloadCommands[app]
+		if !ok || !optIn[app] {
+			return
+		}
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		log.Debugf("Not reloading %s: %s not found", app, command[0])
+		return
+	}
+	out, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+	if err != nil {
+		// most likely the app is just not running
+		log.Debugf("Reloading %s failed: %v %s", app, err, string(out))
+		return
+	}
+	log.Infof("Reloaded %s", app)
+}
+
+// reloadDunst has running dunst re-read its config, or restarts it if it's too old for dunstctl reload (1.10).
+// Not running, it's left alone: dunstctl would have D-Bus start whichever notification daemon.
+func reloadDunst(files []string) {
+	if len(processesNamed("dunst")) == 0 {
+		return
+	}
+	if _, err := exec.LookPath("dunstctl"); err == nil {
+		out, err := commandOutput("dunstctl", "reload")
+		if err == nil {
+			log.Info("Reloaded dunst")
+			return
+		}
+		log.Debugf("dunstctl reload failed: %v %s", err, out)
+	}
+	if _, err := commandOutput("systemctl", "--user", "is-active", "--quiet", "dunst"); err == nil {
+		if out, err := commandOutput("systemctl", "--user", "restart", "dunst"); err != nil {
+			log.Warnf("Couldn't restart dunst: %v %s", err, out)
+			return
+		}
+		log.Info("Restarted dunst")
+		return
+	}
+	respawnProcess("dunst")
+}
+
+// processesNamed returns the pids of our running processes of the name, see appRespawnProcesses
+func processesNamed(name string) []int {
+	var pids []int
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		log.Warnf("Couldn't list processes: %v", err)
+		return pids
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() || !ownProcess(pid) {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "comm"))
+		if err == nil && string(bytes.TrimSpace(comm)) == name {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// ownProcess tells if the process runs as the user, e.g. not another user's panel on a shared machine
+func ownProcess(pid int) bool {
+	info, err := os.Stat(filepath.Join("/proc", strconv.Itoa(pid)))
+	if err != nil {
+		return false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
+
+// signalProcess sends the signal to running instances of the process
+func signalProcess(name string, signal syscall.Signal) {
+	for _, pid := range processesNamed(name) {
+		if err := syscall.Kill(pid, signal); err != nil {
+			log.Debugf("Couldn't signal %s (%v): %v", name, pid, err)
+			continue
+		}
+		log.Infof("Reloaded %s (%v)", name, pid)
+	}
+}
+
+// respawnProcess restarts running instances of the process with their original arguments.
+// Run from a systemd unit, e.g. nwg-look-rotate.service, the new instances go into scopes of their own:
+// they'd be killed with the unit otherwise.
+func respawnProcess(name string) {
+	var launcher []string
+	if os.Getenv("INVOCATION_ID") != "" {
+		if _, err := exec.LookPath("systemd-run"); err != nil {
+			log.Warnf("Not restarting %s: systemd-run not found, it'd stop with this service", name)
+			return
+		}
+		launcher = []string{"systemd-run", "--user", "--scope", "--collect", "--quiet", "--"}
+	}
+	for _, pid := range processesNamed(name) {
+		cmdline, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+		args := append([]string{}, launcher...)
+		for _, arg := range bytes.Split(bytes.TrimRight(cmdline, "\x00"), []byte{0}) {
+			args = append(args, string(arg))
+		}
+
+		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+			log.Warnf("Couldn't stop %s (%v): %v", name, pid, err)
+			continue
+		}
+		// the new instance must not find the old one still running
+		for i := 0; i < 20 && syscall.Kill(pid, 0) == nil; i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+		if err := cmd.Start(); err != nil {
+			log.Warnf("Couldn't restart %s: %v", name, err)
+			continue
+		}
+		cmd.Process.Release()
+		log.Infof("Restarted %s", name)
+	}
+}
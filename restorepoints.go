@@ -0,0 +1,130 @@
+// restorepoints.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// restorePointLimit is the number of restore points kept, older ones are deleted
+const restorePointLimit = 20
+
+// RestorePoint is a snapshot of the settings, taken before operations that change many of them at once
+type RestorePoint struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Created   time.Time       `json:"created"`
+	Settings  *Profile        `json:"settings"`
+	ColorSync json.RawMessage `json:"color-sync,omitempty"` // color-sync.json as it was
+	Manifest  *Manifest       `json:"manifest"`
+}
+
+func restorePointsDir() string {
+	return filepath.Join(stateDir(), "restore-points")
+}
+
+// createRestorePoint snapshots gsettings, the color sync config and the manifest of written files
+func createRestorePoint(name string) {
+	now := time.Now()
+	rp := &RestorePoint{
+		ID:       now.Format("20060102-150405.000"),
+		Name:     name,
+		Created:  now,
+		Settings: profileFromGsettings(name),
+		Manifest: loadManifest(),
+	}
+	if colorSyncManager != nil {
+		if data, err := os.ReadFile(colorSyncManager.configFile); err == nil && json.Valid(data) {
+			rp.ColorSync = data
+		}
+	}
+	data, err := json.MarshalIndent(rp, "", "  ")
+	if err != nil {
+		log.Warnf("Failed to create restore point: %v", err)
+		return
+	}
+	makeDir(restorePointsDir())
+	if err := os.WriteFile(filepath.Join(restorePointsDir(), rp.ID+".json"), data, 0644); err != nil {
+		log.Warnf("Failed to create restore point: %v", err)
+		return
+	}
+	log.Infof("Restore point created: %s", name)
+
+	points := listRestorePoints()
+	for _, old := range points[min(len(points), restorePointLimit):] {
+		os.Remove(filepath.Join(restorePointsDir(), old.ID+".json"))
+	}
+}
+
+// listRestorePoints returns restore points, newest first
+func listRestorePoints() []*RestorePoint {
+	var points []*RestorePoint
+	files, err := listFiles(restorePointsDir())
+	if err != nil {
+		return points
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(restorePointsDir(), f.Name()))
+		if err != nil {
+			continue
+		}
+		rp := &RestorePoint{}
+		if err := json.Unmarshal(data, rp); err != nil || rp.Settings == nil {
+			log.Warnf("Skipping invalid restore point %s", f.Name())
+			continue
+		}
+		points = append(points, rp)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Created.After(points[j].Created)
+	})
+	return points
+}
+
+func findRestorePoint(id string) (*RestorePoint, error) {
+	for _, rp := range listRestorePoints() {
+		if rp.ID == id {
+			return rp, nil
+		}
+	}
+	return nil, fmt.Errorf("no restore point '%s'", id)
+}
+
+// restore brings back the settings, the color sync config, and the files color sync wrote.
+// The current state is saved as a restore point first, so that restoring may be undone.
+func (rp *RestorePoint) restore() error {
+	log.Infof(">>> Restoring '%s' from %s", rp.Name, rp.Created.Format("2006-01-02 15:04"))
+	createRestorePoint(fmt.Sprintf("before restoring '%s'", rp.Name))
+
+	if rp.Manifest != nil {
+		if err := rp.Manifest.save(); err != nil {
+			return err
+		}
+	}
+	if len(rp.ColorSync) > 0 && colorSyncManager != nil {
+		if err := os.WriteFile(colorSyncManager.configFile, rp.ColorSync, 0644); err != nil {
+			return err
+		}
+		colorSyncManager.loadConfig()
+	}
+
+	applyProfileSettings(rp.Settings)
+
+	if csm := colorSyncManager; csm != nil && csm.IsEnabled() && csm.config.LastColors != nil {
+		if err := csm.templates.ApplyColors(csm.config.LastColors, csm.config.Applications); err != nil {
+			return fmt.Errorf("failed to apply colors: %w", err)
+		}
+		csm.refreshAccents(csm.config.LastColors)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+// rotation.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RotationConfig holds settings for the theme rotation mode
+type RotationConfig struct {
+	Enabled  bool      `json:"enabled"`
+	Entries  []string  `json:"entries"`  // profile names or GTK theme names
+	Interval string    `json:"interval"` // Go duration, e.g. "24h" or "168h"
+	Random   bool      `json:"random"`
+	Index    int       `json:"index"`
+	LastRun  time.Time `json:"last-run"`
+	// minutes to announce the next rotation ahead, 0 to switch without a preview notification
+	NotifyAhead int `json:"notify-ahead"`
+	// do-not-disturb window, "HH:MM"; no rotation takes place within it
+	QuietFrom string `json:"quiet-from"`
+	QuietTo   string `json:"quiet-to"`
+	// entry announced in the preview notification, -1 if none
+	Pending int `json:"pending"`
+}
+
+func rotationConfigFile() string {
+	return filepath.Join(configDir(), "rotation.json")
+}
+
+func rotationConfigNewWithDefaults() *RotationConfig {
+	return &RotationConfig{
+		Enabled:     false,
+		Entries:     []string{},
+		Interval:    "168h",
+		Index:       -1,
+		NotifyAhead: 5,
+		Pending:     -1,
+	}
+}
+
+func loadRotationConfig() *RotationConfig {
+	rc := rotationConfigNewWithDefaults()
+	data, err := os.ReadFile(rotationConfigFile())
+	if err == nil {
+		if err := json.Unmarshal(data, rc); err != nil {
+			log.Warnf("Failed to parse %s: %v", rotationConfigFile(), err)
+		}
+	}
+	return rc
+}
+
+func (rc *RotationConfig) save() error {
+	data, err := json.MarshalIndent(rc, "", "  ")
+	if err != nil {
+		return err
+	}
+	makeDir(filepath.Dir(rotationConfigFile()))
+	return os.WriteFile(rotationConfigFile(), data, 0644)
+}
+
+// interval returns the parsed rotation interval, falling back to one week
+func (rc *RotationConfig) interval() time.Duration {
+	d, err := time.ParseDuration(rc.Interval)
+	if err != nil || d <= 0 {
+		return 168 * time.Hour
+	}
+	return d
+}
+
+// dueAt returns the time of the next rotation
+func (rc *RotationConfig) dueAt() time.Time {
+	if rc.LastRun.IsZero() {
+		return time.Time{}
+	}
+	return rc.LastRun.Add(rc.interval())
+}
+
+// isDue tells if the next rotation should happen at the given time
+func (rc *RotationConfig) isDue(now time.Time) bool {
+	return !now.Before(rc.dueAt())
+}
+
+// minuteOfDay parses "HH:MM"
+func minuteOfDay(s string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// inQuietHours tells if the time falls within the do-not-disturb window, which may span midnight
+func (rc *RotationConfig) inQuietHours(now time.Time) bool {
+	from, ok1 := minuteOfDay(rc.QuietFrom)
+	to, ok2 := minuteOfDay(rc.QuietTo)
+	if !ok1 || !ok2 || from == to {
+		return false
+	}
+	m := now.Hour()*60 + now.Minute()
+	if from < to {
+		return m >= from && m < to
+	}
+	return m >= from || m < to
+}
+
+// nextIndex picks the next entry, avoiding a repetition in random mode
+func (rc *RotationConfig) nextIndex() int {
+	n := len(rc.Entries)
+	if n == 0 {
+		return -1
+	}
+	if rc.Random && (rc.Index < 0 || rc.Index >= n) {
+		return rand.Intn(n)
+	}
+	if rc.Random && n > 1 {
+		i := rand.Intn(n - 1)
+		if i >= rc.Index {
+			i++
+		}
+		return i
+	}
+	return (rc.Index + 1) % n
+}
+
+// rotate applies the next rotation entry if enabled and due; force skips the schedule check
+func rotate(force bool) error {
+	rc := loadRotationConfig()
+	if !rc.Enabled && !force {
+		log.Info("Theme rotation is disabled")
+		return nil
+	}
+	if len(rc.Entries) == 0 {
+		return fmt.Errorf("no rotation entries defined in %s", rotationConfigFile())
+	}
+	now := time.Now()
+	if !force {
+		if rc.inQuietHours(now) {
+			log.Infof("Rotation postponed: do-not-disturb until %s", rc.QuietTo)
+			return nil
+		}
+		if !rc.isDue(now) {
+			return rc.preview(now)
+		}
+	}
+	return rc.apply(now)
+}
+
+// preview announces the next rotation, if it's due within the NotifyAhead period, and handles the user's choice
+func (rc *RotationConfig) preview(now time.Time) error {
+	due := rc.dueAt()
+	lead := time.Duration(rc.NotifyAhead) * time.Minute
+	if rc.NotifyAhead <= 0 || due.Sub(now) > lead || rc.Pending >= 0 {
+		log.Infof("Next rotation due at %s", due.Format(time.RFC1123))
+		return nil
+	}
+	// neither announced nor applied then: the first run after the quiet window applies it
+	if rc.inQuietHours(due) {
+		log.Infof("Rotation due at %s postponed: do-not-disturb until %s", due.Format("15:04"), rc.QuietTo)
+		return nil
+	}
+
+	rc.Pending = rc.nextIndex()
+	if err := rc.save(); err != nil {
+		return err
+	}
+	switch notifyRotation(rc.Entries[rc.Pending], due) {
+	case "skip":
+		log.Infof("Rotation to '%s' skipped", rc.Entries[rc.Pending])
+		rc.LastRun = due
+		rc.Pending = -1
+		return rc.save()
+	case "apply":
+		return rc.apply(time.Now())
+	}
+	time.Sleep(time.Until(due))
+	return rc.apply(due)
+}
+
+// apply switches to the pending entry, or the next one, skipping entries that no longer resolve
+func (rc *RotationConfig) apply(now time.Time) error {
+	for range rc.Entries {
+		if rc.Pending >= 0 && rc.Pending < len(rc.Entries) {
+			rc.Index = rc.Pending
+		} else {
+			rc.Index = rc.nextIndex()
+		}
+		rc.Pending = -1
+		name := rc.Entries[rc.Index]
+		p, err := resolveProfile(name)
+		if err != nil {
+			log.Warnf("Rotation: %v", err)
+			continue
+		}
+		if err := applyProfile(p); err != nil {
+			log.Warnf("Rotation: %v", err)
+			continue
+		}
+		rc.LastRun = now
+		return rc.save()
+	}
+	return fmt.Errorf("none of the rotation entries could be resolved")
+}
+
+// notifyRotation shows an actionable notification until the rotation is due, and returns the chosen
+// action: "skip", "apply", or "" on timeout or if notifications with actions are not supported
+func notifyRotation(entry string, due time.Time) string {
+	if _, err := exec.LookPath("notify-send"); err != nil {
+		log.Debug("Not announcing the rotation: notify-send not found")
+		return ""
+	}
+	timeout := time.Until(due)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	body := fmt.Sprintf("Switching to '%s' at %s", entry, due.Format("15:04"))
+	out, err := exec.CommandContext(ctx, "notify-send", "--app-name=nwg-look", "--wait",
+		fmt.Sprintf("--expire-time=%d", timeout.Milliseconds()),
+		"--action=apply=Apply now", "--action=skip=Skip",
+		"Theme rotation", body).Output()
+	if err != nil {
+		log.Debugf("Rotation notification: %v", err)
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
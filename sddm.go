@@ -0,0 +1,170 @@
+// sddm.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// sddmThemesDir is where SDDM themes are installed. A theme.conf.user file next to a theme's
+// theme.conf overrides its settings, and survives theme updates.
+const sddmThemesDir = "/usr/share/sddm/themes"
+
+const sddmUserConf = "theme.conf.user"
+
+// SddmOptions set up writing the SDDM theme colors on apply
+type SddmOptions struct {
+	// Where to write; empty: the current theme's theme.conf.user, installed with polkit
+	Path string `json:"path,omitempty"`
+}
+
+// sddmCurrentTheme returns the theme set in the [Theme] section of the SDDM config, read in the order
+// SDDM does: the system defaults, then /etc/sddm.conf.d, then /etc/sddm.conf
+func sddmCurrentTheme() string {
+	var files []string
+	for _, dir := range []string{"/usr/lib/sddm/sddm.conf.d", "/etc/sddm.conf.d"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.conf"))
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	files = append(files, "/etc/sddm.conf")
+
+	theme := ""
+	for _, file := range files {
+		sections, _, err := parseIniSections(file)
+		if err != nil {
+			continue
+		}
+		if current, ok := sections["Theme"]["Current"]; ok {
+			theme = current
+		}
+	}
+	return theme
+}
+
+// sddmStyle returns the color overrides, under the keys used by the common themes: Breeze (color),
+// Sugar Candy and Sugar Dark (MainColor, AccentColor, BackgroundColor); themes ignore the others.
+func sddmStyle(p *ColorPalette) string {
+	lines := []string{
+		"# Generated by nwg-look",
+		"[General]",
+		fmt.Sprintf("color=%s", p.Background),
+		fmt.Sprintf("MainColor=%s", p.Foreground),
+		fmt.Sprintf("AccentColor=%s", p.Colors["color4"]),
+		fmt.Sprintf("BackgroundColor=%s", p.Background),
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// sddmOutput merges the color overrides into the existing file at path, keeping the user's other settings
+func sddmOutput(path string, p *ColorPalette) ([]byte, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return mergeINIKeys(existing, sddmStyle(p))
+}
+
+// sddmThemeConf returns the theme.conf.user path of the theme, or of the current one if empty
+func sddmThemeConf(theme string) (string, error) {
+	if theme == "" {
+		theme = sddmCurrentTheme()
+	}
+	if theme == "" {
+		return "", fmt.Errorf("no SDDM theme set in [Theme] Current=")
+	}
+	dir := filepath.Join(sddmThemesDir, theme)
+	if !pathExists(dir) {
+		return "", fmt.Errorf("SDDM theme %s not found in %s", theme, sddmThemesDir)
+	}
+	return filepath.Join(dir, sddmUserConf), nil
+}
+
+// exportSddm writes the color overrides to path, merged into the file if it exists
+func exportSddm(path string) error {
+	palette := colorSyncManager.config.LastColors
+	if palette == nil {
+		return fmt.Errorf("no palette applied yet")
+	}
+	data, err := sddmOutput(path, palette)
+	if err != nil {
+		return err
+	}
+	makeDir(filepath.Dir(path))
+	return os.WriteFile(path, data, 0644)
+}
+
+// installSddm merges the color overrides into the theme's theme.conf.user, authorized with polkit.
+// Nothing is done if the file is up to date, so that polkit only asks when the login screen would change.
+func installSddm(theme string) error {
+	target, err := sddmThemeConf(theme)
+	if err != nil {
+		return err
+	}
+	palette := colorSyncManager.config.LastColors
+	if palette == nil {
+		return fmt.Errorf("no palette applied yet")
+	}
+	data, err := sddmOutput(target, palette)
+	if err != nil {
+		return err
+	}
+	if installed, err := os.ReadFile(target); err == nil && bytes.Equal(installed, data) {
+		log.Debugf("%s is up to date", target)
+		return nil
+	}
+
+	staged := filepath.Join(cacheDir(), "sddm", sddmUserConf)
+	makeDir(filepath.Dir(staged))
+	if err := os.WriteFile(staged, data, 0644); err != nil {
+		return err
+	}
+	if _, err := exec.LookPath("pkexec"); err != nil {
+		return fmt.Errorf("pkexec not found, copy %s to %s manually", staged, target)
+	}
+	out, err := exec.Command("pkexec", "install", "-m", "0644", staged, target).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("installing %s failed: %v %s", target, err, string(out))
+	}
+	log.Infof("✓ Installed SDDM colors into %s", target)
+	return nil
+}
+
+// syncSddm writes the SDDM colors again after colors were applied, if opted in
+func (csm *ColorSyncManager) syncSddm() {
+	opts := csm.config.Sddm
+	if opts == nil {
+		return
+	}
+	var err error
+	if opts.Path != "" {
+		err = exportSddm(expandPath(opts.Path))
+	} else {
+		err = installSddm("")
+	}
+	if err != nil {
+		log.Warnf("Failed to update the SDDM colors: %v", err)
+	}
+}
+
+// IsSddmSync returns whether the SDDM colors are written on apply
+func (csm *ColorSyncManager) IsSddmSync() bool {
+	return csm.config.Sddm != nil
+}
+
+// SetSddmSync turns writing the SDDM colors on apply on (to path, or the current theme if empty) or off
+func (csm *ColorSyncManager) SetSddmSync(on bool, path string) {
+	if on {
+		csm.config.Sddm = &SddmOptions{Path: path}
+	} else {
+		csm.config.Sddm = nil
+	}
+	csm.saveConfig()
+}
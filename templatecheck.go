@@ -0,0 +1,176 @@
+// templatecheck.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// template check statuses
+const (
+	checkOK    = "ok"
+	checkOff   = "off"   // not written: application disabled, skipped or refused
+	checkError = "error" // needs fixing
+)
+
+// TemplateProblem is a finding of checkTemplates, Line is 0 if not about a template line
+type TemplateProblem struct {
+	Template string
+	Status   string
+	Line     int
+	Detail   string
+}
+
+// leftoverPattern matches what looks like a placeholder left in the output, e.g. {colour4} or {color4.hex}.
+// Braces of the target's own syntax are followed by a space, a quote or a line end, and don't match.
+var leftoverPattern = regexp.MustCompile(`\{[A-Za-z][\w.:-]*\}`)
+
+// checkPalette is the dummy palette templates are rendered with, every slot set
+func checkPalette() *ColorPalette {
+	palette := &ColorPalette{Background: "#101010", Foreground: "#f0f0f0", Cursor: "#c0c0c0", Colors: make(map[string]string)}
+	for i := 0; i < 16; i++ {
+		palette.Colors[fmt.Sprintf("color%d", i)] = fmt.Sprintf("#%02x%02x%02x", 16*i, 255-16*i, 128)
+	}
+	return palette
+}
+
+// checkPlaceholders renders the template line by line, and reports what was left unreplaced
+func (tm *TemplateManager) checkPlaceholders(name, content string) []TemplateProblem {
+	var problems []TemplateProblem
+	palette := checkPalette()
+	for i, line := range strings.Split(content, "\n") {
+		out := tm.fillTemplate(line, palette, 0.85)
+		for _, token := range leftoverPattern.FindAllString(out, -1) {
+			detail := fmt.Sprintf("unreplaced %s", token)
+			if m := placeholderPattern.FindStringSubmatch(token); m != nil {
+				if isPaletteSlot(m[1]) || extendedPlaceholderPattern.MatchString("{"+m[1]+"}") {
+					detail = fmt.Sprintf("unknown modifier in %s, use strip, rgb, short, red, green or blue", token)
+				} else {
+					detail = fmt.Sprintf("unknown placeholder %s", token)
+				}
+			}
+			problems = append(problems, TemplateProblem{name, checkError, i + 1, detail})
+		}
+	}
+	return problems
+}
+
+// checkWritable tells why the destination can't be written, nil if it can: the file, if it exists, and
+// the directory, where the temporary file is created
+func checkWritable(path string) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		if err := syscall.Access(path, 2); err != nil { // W_OK
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	// the nearest existing directory, the others are created
+	dir := filepath.Dir(path)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		if dir == filepath.Dir(dir) {
+			return err
+		}
+		dir = filepath.Dir(dir)
+	}
+	if err := syscall.Access(dir, 2|1); err != nil { // W_OK|X_OK
+		return fmt.Errorf("%s: %v", dir, err)
+	}
+	return nil
+}
+
+// checkTemplate renders a target's template against the dummy palette, and checks its destination
+func (csm *ColorSyncManager) checkTemplate(t colorTarget, skipApps map[string]bool) []TemplateProblem {
+	tm := csm.templates
+	problem := func(status, format string, args ...interface{}) []TemplateProblem {
+		return []TemplateProblem{{t.template, status, 0, fmt.Sprintf(format, args...)}}
+	}
+
+	content, source, err := tm.templateContent(t)
+	if err != nil {
+		return problem(checkError, "%v", err)
+	}
+	if err := checkTemplateCompat(t.template, string(content)); err != nil {
+		return problem(checkError, "%v", err)
+	}
+	problems := tm.checkPlaceholders(t.template, string(content))
+
+	// destinations only matter if written
+	var off []TemplateProblem
+	switch {
+	case !csm.IsAppEnabled(t.app):
+		off = problem(checkOff, "%s disabled, enable it in the Color Sync tab or with `nwg-look colors enable %s`", t.app, t.app)
+	case skipApps[t.app] || skipApps[t.template]:
+		off = problem(checkOff, "skipped on this host/session, see \"skip\" in color-sync.json")
+	case tm.consent[t.app] == consentNever:
+		off = problem(checkOff, "writing %s files refused, see `nwg-look colors consent`", t.app)
+	case tm.templateOff(t):
+		off = problem(checkOff, "template disabled, see `nwg-look templates enable %s`", t.template)
+	}
+	if off != nil {
+		if len(problems) > 0 {
+			return problems
+		}
+		return off
+	}
+
+	opts := tm.destinations[t.template]
+	path := t.destPath(opts)
+	switch {
+	case path == "":
+		problems = append(problems, problem(checkError, "no destination, see the log")...)
+	case opts.injects():
+		if _, _, err := markerLines(path); err != nil {
+			problems = append(problems, problem(checkError, "can't inject into %s: %v", path, err)...)
+		}
+	}
+	if path != "" {
+		if err := checkWritable(path); err != nil {
+			problems = append(problems, problem(checkError, "destination not writable: %v", err)...)
+		} else if rendered, ok := tm.render(t, checkPalette()); ok {
+			if _, err := t.outputFor(path, rendered, opts); err != nil {
+				problems = append(problems, problem(checkError, "merging into %s: %v", path, err)...)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+	return problem(checkOK, "%s -> %s", source, path)
+}
+
+// checkTemplates renders every template against a dummy palette, and prints unknown placeholders,
+// unreplaced braces and unwritable destinations. Returns the number of errors.
+func checkTemplates() int {
+	skipApps, _ := activeSkips(colorSyncManager.templates.skip)
+	failed := 0
+	for _, t := range colorTargets {
+		for _, p := range colorSyncManager.checkTemplate(t, skipApps) {
+			if p.Status == checkError {
+				failed++
+			}
+			where := p.Template
+			if p.Line > 0 {
+				where = fmt.Sprintf("%s:%d", p.Template, p.Line)
+			}
+			fmt.Printf("%-6s %-28s %s\n", p.Status, where, p.Detail)
+		}
+	}
+	fmt.Printf("%v problems in %v templates\n", failed, len(colorTargets))
+	return failed
+}
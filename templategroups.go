@@ -0,0 +1,90 @@
+// templategroups.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// An application may have several templates, e.g. Waybar colors and a whole style sheet: all of them are
+// written while the application is enabled. Besides built-in and community templates, users declare their
+// own in ~/.config/nwg-look/templates.json, the template files living in color-templates:
+//
+//	{"templates": [{"name": "waybar-style.css", "app": "waybar", "dest": "waybar/style.css"}]}
+
+// LocalTemplate is a template of the user's, grouped under the application's checkbox
+type LocalTemplate struct {
+	Name        string `json:"name"`
+	App         string `json:"app"`
+	Dest        string `json:"dest"` // relative to ~/.config, or to the home directory if starting with "~/"
+	Description string `json:"description,omitempty"`
+}
+
+type localTemplateManifest struct {
+	Templates []LocalTemplate `json:"templates"`
+}
+
+func localTemplatesFile() string {
+	return filepath.Join(configDir(), "templates.json")
+}
+
+// validate refuses entries shadowing a built-in template, or without a template file
+func (t LocalTemplate) validate() error {
+	if t.Name == "" || t.App == "" || t.Dest == "" {
+		return fmt.Errorf("template '%s': name, app and dest are required", t.Name)
+	}
+	if strings.ContainsAny(t.Name, `/\`) || strings.HasPrefix(t.Name, ".") {
+		return fmt.Errorf("template '%s': invalid name", t.Name)
+	}
+	for _, target := range colorTargets {
+		if target.template == t.Name && target.content != nil {
+			return fmt.Errorf("template '%s': a built-in template has this name, edit it with `nwg-look templates edit`", t.Name)
+		}
+	}
+	if filepath.IsAbs(t.Dest) {
+		return fmt.Errorf("template '%s': destination must be relative to ~/.config, or start with ~/", t.Name)
+	}
+	if !pathExists(communityTemplatePath(t.Name)) {
+		return fmt.Errorf("template '%s': %s not found", t.Name, communityTemplatePath(t.Name))
+	}
+	return nil
+}
+
+func loadLocalTemplates() []LocalTemplate {
+	var manifest localTemplateManifest
+	data, err := os.ReadFile(localTemplatesFile())
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		log.Warnf("Failed to parse %s: %v", localTemplatesFile(), err)
+	}
+	return manifest.Templates
+}
+
+// registerLocalTemplates adds the user's templates to the color targets, after the community ones
+func registerLocalTemplates() {
+	for _, t := range loadLocalTemplates() {
+		if err := t.validate(); err != nil {
+			log.Warnf("Template ignored: %v", err)
+			continue
+		}
+		registerTarget(t.Name, t.App, t.Dest)
+	}
+}
+
+// appTemplates returns the templates written for the application
+func appTemplates(app string) []colorTarget {
+	var targets []colorTarget
+	for _, t := range colorTargets {
+		if t.app == app {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
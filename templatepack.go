@@ -0,0 +1,266 @@
+// templatepack.go
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// A template pack is a tarball or git repository holding templates, listed in a manifest at its root.
+// GitHub-style archives, with everything in one top directory, are accepted too.
+const packManifest = "nwg-look-pack.json"
+
+// packs are read into memory, with these limits
+const (
+	maxPackDownload = 8 << 20
+	maxPackFiles    = 500
+)
+
+// TemplatePack is the pack manifest
+type TemplatePack struct {
+	Name      string         `json:"name"`
+	Version   string         `json:"version,omitempty"`
+	Templates []PackTemplate `json:"templates"`
+}
+
+// PackTemplate is a template of the pack; File is its path in the pack, the template name if empty
+type PackTemplate struct {
+	Name        string `json:"name"`
+	App         string `json:"app"`
+	Dest        string `json:"dest"`
+	Description string `json:"description,omitempty"`
+	File        string `json:"file,omitempty"`
+}
+
+// isGitSource tells if the pack is to be cloned rather than downloaded
+func isGitSource(source string) bool {
+	return strings.HasSuffix(source, ".git") || strings.HasPrefix(source, "git+")
+}
+
+// readPackSource returns the files of the pack, keyed by their slash-separated path
+func readPackSource(source string) (map[string][]byte, error) {
+	switch {
+	case isGitSource(source):
+		return readGitPack(strings.TrimPrefix(source, "git+"))
+	case strings.HasPrefix(source, "https://"):
+		data, err := downloadLimited(source, maxPackDownload)
+		if err != nil {
+			return nil, err
+		}
+		return readTarPack(data)
+	case strings.Contains(source, "://"):
+		return nil, fmt.Errorf("refusing to download from '%s': https required", source)
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return readDirPack(source)
+	}
+	if info.Size() > maxPackDownload {
+		return nil, fmt.Errorf("%s: file too large", source)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	return readTarPack(data)
+}
+
+// readTarPack reads the regular files of a tar archive, gzipped or not
+func readTarPack(data []byte) (map[string][]byte, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if gz, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
+		r = gz
+	}
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if len(files) >= maxPackFiles {
+			return nil, fmt.Errorf("too many files in the archive")
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, maxTemplateDownload+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > maxTemplateDownload {
+			continue // not a template
+		}
+		files[path.Clean(strings.TrimPrefix(hdr.Name, "./"))] = content
+	}
+	return files, nil
+}
+
+// readDirPack reads the regular files of a directory; symlinks are skipped, so that nothing outside is read
+func readDirPack(dir string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(files) >= maxPackFiles {
+			return fmt.Errorf("too many files in %s", dir)
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxTemplateDownload {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	return files, err
+}
+
+// readGitPack clones the repository's default branch into a temporary directory
+func readGitPack(url string) (map[string][]byte, error) {
+	if !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("refusing to clone '%s': https required", url)
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git not found")
+	}
+	tmp, err := os.MkdirTemp("", "nwg-look-pack-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	out, err := exec.Command("git", "clone", "--depth", "1", "--quiet", url, tmp).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("git clone failed: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return readDirPack(tmp)
+}
+
+// loadTemplatePack finds and parses the manifest, and returns the templates with their content.
+// Nothing is installed unless all templates are valid.
+func loadTemplatePack(files map[string][]byte) (*TemplatePack, map[string]string, error) {
+	root := ""
+	if _, ok := files[packManifest]; !ok {
+		for name := range files {
+			if dir, file := path.Split(name); file == packManifest && strings.Count(dir, "/") == 1 {
+				root = dir
+				break
+			}
+		}
+	}
+	data, ok := files[root+packManifest]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s not found in the pack", packManifest)
+	}
+	var pack TemplatePack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %v", packManifest, err)
+	}
+	if pack.Name == "" || len(pack.Templates) == 0 {
+		return nil, nil, fmt.Errorf("invalid %s: a name and templates are required", packManifest)
+	}
+
+	contents := make(map[string]string)
+	for _, pt := range pack.Templates {
+		file := pt.File
+		if file == "" {
+			file = pt.Name
+		}
+		file = path.Clean(file)
+		if path.IsAbs(file) || file == ".." || strings.HasPrefix(file, "../") {
+			return nil, nil, fmt.Errorf("template '%s': file must be inside the pack", pt.Name)
+		}
+		content, ok := files[root+file]
+		if !ok {
+			return nil, nil, fmt.Errorf("template '%s': %s not found in the pack", pt.Name, file)
+		}
+		if _, dup := contents[pt.Name]; dup {
+			return nil, nil, fmt.Errorf("template '%s' listed twice", pt.Name)
+		}
+		if err := pt.community(&pack, content).validate(); err != nil {
+			return nil, nil, err
+		}
+		if err := checkTemplateCompat(pt.Name, string(content)); err != nil {
+			return nil, nil, err
+		}
+		contents[pt.Name] = string(content)
+	}
+	return &pack, contents, nil
+}
+
+// community returns the entry listing the template as installed
+func (pt PackTemplate) community(pack *TemplatePack, content []byte) CommunityTemplate {
+	return CommunityTemplate{
+		Name:        pt.Name,
+		App:         pt.App,
+		Dest:        pt.Dest,
+		Description: pt.Description,
+		Version:     pack.Version,
+		Sha256:      sha256Hex(content),
+		Pack:        pack.Name,
+	}
+}
+
+// installTemplatePack installs all templates of the pack found at source: an https URL of a tarball
+// or git repository, or a local directory or tarball. Returns the names of the installed templates.
+func (csm *ColorSyncManager) installTemplatePack(source string) ([]string, error) {
+	pack, contents, err := readTemplatePack(source)
+	if err != nil {
+		return nil, err
+	}
+	return csm.installPackTemplates(pack, contents)
+}
+
+// readTemplatePack downloads or reads the pack at source, and checks its templates
+func readTemplatePack(source string) (*TemplatePack, map[string]string, error) {
+	files, err := readPackSource(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	return loadTemplatePack(files)
+}
+
+// installPackTemplates installs the templates of a pack read by readTemplatePack. Like installTemplateContent,
+// it registers them in colorTargets: in the GUI, call it from the main loop.
+func (csm *ColorSyncManager) installPackTemplates(pack *TemplatePack, contents map[string]string) ([]string, error) {
+	var names []string
+	for _, pt := range pack.Templates {
+		content := contents[pt.Name]
+		if err := csm.installTemplateContent(pt.community(pack, []byte(content)), content); err != nil {
+			return names, err
+		}
+		names = append(names, pt.Name)
+	}
+	log.Infof("✓ Installed %d templates from pack %s %s", len(names), pack.Name, pack.Version)
+	return names, nil
+}
@@ -0,0 +1,176 @@
+// templatesource.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Templates are looked up in the user's color-templates directory first, then in nwg-look/color-templates
+// of the data dirs, where packagers install the defaults, and last built into the binary. Only templates
+// the user edits live in the config, so that the others improve with each release.
+
+const builtinTemplate = "built-in"
+
+// systemTemplateDirs returns nwg-look/color-templates of the data dirs, in lookup order
+func systemTemplateDirs() []string {
+	var dirs []string
+	for _, d := range dataDirs {
+		dirs = append(dirs, filepath.Join(d, "nwg-look", "color-templates"))
+	}
+	return dirs
+}
+
+// defaultTemplate returns the template shipped with nwg-look, and its path or "built-in"
+func (tm *TemplateManager) defaultTemplate(t colorTarget) ([]byte, string, error) {
+	for _, dir := range systemTemplateDirs() {
+		path := filepath.Join(dir, t.template)
+		if content, err := os.ReadFile(path); err == nil {
+			return content, path, nil
+		}
+	}
+	if t.content == nil {
+		return nil, "", fmt.Errorf("template %s not found", t.template)
+	}
+	return []byte(t.content(tm)), builtinTemplate, nil
+}
+
+// templateContent returns the template to render, the user's own if any, and where it was found
+func (tm *TemplateManager) templateContent(t colorTarget) ([]byte, string, error) {
+	path := filepath.Join(tm.configDir, t.template)
+	content, err := os.ReadFile(path)
+	if err == nil {
+		return content, path, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, path, err
+	}
+	return tm.defaultTemplate(t)
+}
+
+// templateOff tells if the template is left out although its application is enabled: disabled by the user,
+// e.g. one of several templates of the application, or a default while only the user's own are rendered
+func (tm *TemplateManager) templateOff(t colorTarget) bool {
+	if isIn(tm.disabled, t.template) {
+		return true
+	}
+	return tm.userOnly && !pathExists(filepath.Join(tm.configDir, t.template))
+}
+
+// pruneDefaultCopies removes the user's templates identical to the defaults. Earlier versions copied
+// all defaults into the config: these copies would otherwise never get the improved defaults.
+func (tm *TemplateManager) pruneDefaultCopies() {
+	for _, t := range colorTargets {
+		path := filepath.Join(tm.configDir, t.template)
+		own, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if content, _, err := tm.defaultTemplate(t); err == nil && bytes.Equal(own, content) {
+			if err := os.Remove(path); err == nil {
+				log.Debugf("Removed %s, same as the default", path)
+			}
+		}
+	}
+}
+
+// overrideTemplate copies the default template into the user's directory, to be edited there
+func (tm *TemplateManager) overrideTemplate(name string) (string, error) {
+	for _, t := range colorTargets {
+		if t.template != name {
+			continue
+		}
+		path := filepath.Join(tm.configDir, name)
+		if pathExists(path) {
+			return path, nil
+		}
+		content, _, err := tm.defaultTemplate(t)
+		if err != nil {
+			return "", err
+		}
+		return path, os.WriteFile(path, content, 0644)
+	}
+	return "", fmt.Errorf("unknown template '%s'", name)
+}
+
+// resetTemplate removes the user's version of a default template, so that the default is used again.
+// The removed file goes into a backup set, to be restored like the files of an apply run.
+func (tm *TemplateManager) resetTemplate(name string) error {
+	for _, t := range colorTargets {
+		if t.template != name {
+			continue
+		}
+		if _, _, err := tm.defaultTemplate(t); err != nil {
+			return fmt.Errorf("'%s' has no default to go back to, see `nwg-look templates remove`", name)
+		}
+		path := filepath.Join(tm.configDir, name)
+		if !pathExists(path) {
+			return nil
+		}
+		// the user's edits are kept among the backups, as before an apply
+		backups := newBackupSet()
+		if err := backups.add(path, loadManifest()); err != nil {
+			return fmt.Errorf("couldn't back up %s: %w", path, err)
+		}
+		if err := backups.save(tm.backups); err != nil {
+			return fmt.Errorf("couldn't back up %s: %w", path, err)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		log.Infof("Removed %s, backed up as %s (nwg-look backups restore %s)", path, backups.ID, backups.ID)
+		return nil
+	}
+	return fmt.Errorf("unknown template '%s'", name)
+}
+
+// exportDefaultTemplates writes the built-in templates into dir, e.g. for packagers to install
+// into /usr/share/nwg-look/color-templates
+func (tm *TemplateManager) exportDefaultTemplates(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, t := range colorTargets {
+		if t.content == nil {
+			continue
+		}
+		path := filepath.Join(dir, t.template)
+		if err := os.WriteFile(path, []byte(t.content(tm)), 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// SetTemplateEnabled disables a template, so that deleting the user's copy doesn't bring the default back,
+// or enables it again
+func (csm *ColorSyncManager) SetTemplateEnabled(name string, enabled bool) error {
+	if !isColorTemplate(name) {
+		return fmt.Errorf("unknown template '%s'", name)
+	}
+	var disabled []string
+	for _, d := range csm.config.DisabledTemplates {
+		if d != name {
+			disabled = append(disabled, d)
+		}
+	}
+	if !enabled {
+		disabled = append(disabled, name)
+	}
+	csm.config.DisabledTemplates = disabled
+	csm.templates.disabled = disabled
+	return csm.saveConfig()
+}
+
+// SetUserTemplatesOnly makes color sync render only the templates in color-templates, or the defaults too
+func (csm *ColorSyncManager) SetUserTemplatesOnly(on bool) error {
+	csm.config.UserTemplatesOnly = on
+	csm.templates.userOnly = on
+	return csm.saveConfig()
+}
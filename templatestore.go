@@ -0,0 +1,319 @@
+// templatestore.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// downloads larger than this are refused, templates are small text files
+const maxTemplateDownload = 1 << 20
+
+// CommunityTemplate is an entry of the template index, and of the list of installed templates
+type CommunityTemplate struct {
+	Name        string `json:"name"` // template file name, e.g. "aerc-colors.ini"
+	App         string `json:"app"`
+	Dest        string `json:"dest"` // relative to ~/.config
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+	URL         string `json:"url,omitempty"` // empty for templates installed from a pack
+	Sha256      string `json:"sha256"`
+	Pack        string `json:"pack,omitempty"` // installed from this template pack, see templatepack.go
+}
+
+// TemplateIndex is the document served at the index URL
+type TemplateIndex struct {
+	Templates []CommunityTemplate `json:"templates"`
+}
+
+func communityTemplatesFile() string {
+	return filepath.Join(configDir(), "community-templates.json")
+}
+
+func communityTemplatePath(name string) string {
+	return filepath.Join(configDir(), "color-templates", name)
+}
+
+// validate refuses entries that could write outside of the config home, or shadow a built-in template
+func (t CommunityTemplate) validate() error {
+	if t.Name == "" || t.App == "" || t.Dest == "" || t.URL == "" && t.Pack == "" {
+		return fmt.Errorf("template '%s': name, app, dest and url are required", t.Name)
+	}
+	if strings.ContainsAny(t.Name, `/\`) || strings.HasPrefix(t.Name, ".") {
+		return fmt.Errorf("template '%s': invalid name", t.Name)
+	}
+	for _, target := range colorTargets {
+		if target.template == t.Name && target.content != nil {
+			return fmt.Errorf("template '%s': a built-in template has this name", t.Name)
+		}
+	}
+	dest := filepath.Clean(t.Dest)
+	if filepath.IsAbs(dest) || strings.HasPrefix(t.Dest, "~") || dest == ".." || strings.HasPrefix(dest, "../") {
+		return fmt.Errorf("template '%s': destination must be relative to ~/.config", t.Name)
+	}
+	if len(t.Sha256) != sha256.Size*2 {
+		return fmt.Errorf("template '%s': missing or invalid checksum", t.Name)
+	}
+	return nil
+}
+
+var templateStoreClient = &http.Client{Timeout: 15 * time.Second}
+
+// download fetches a URL, refusing non-https ones
+func download(url string) ([]byte, error) {
+	return downloadLimited(url, maxTemplateDownload)
+}
+
+// downloadLimited fetches a URL of at most limit bytes, refusing non-https ones
+func downloadLimited(url string, limit int64) ([]byte, error) {
+	if !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("refusing to download from '%s': https required", url)
+	}
+	resp, err := templateStoreClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s: file too large", url)
+	}
+	return data, nil
+}
+
+// fetchTemplateIndex downloads the index; invalid entries are left out with a warning
+func (csm *ColorSyncManager) fetchTemplateIndex() ([]CommunityTemplate, error) {
+	// there's no index nwg-look trusts by default: which one to download templates from is the user's choice
+	url := csm.config.TemplateIndex
+	if url == "" {
+		return nil, fmt.Errorf("no template index set, point \"template-index\" in %s to its https URL", csm.configFile)
+	}
+	data, err := download(url)
+	if err != nil {
+		return nil, err
+	}
+	var index TemplateIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid template index: %w", err)
+	}
+	var templates []CommunityTemplate
+	for _, t := range index.Templates {
+		if err := t.validate(); err != nil {
+			log.Warnf("Skipping index entry: %v", err)
+			continue
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// findIndexTemplate returns the index entry of the template
+func findIndexTemplate(index []CommunityTemplate, name string) (CommunityTemplate, error) {
+	for _, t := range index {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return CommunityTemplate{}, fmt.Errorf("no template named '%s' in the index", name)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// downloadTemplate fetches the template content and verifies its checksum
+func downloadTemplate(t CommunityTemplate) (string, error) {
+	data, err := download(t.URL)
+	if err != nil {
+		return "", err
+	}
+	if sum := sha256Hex(data); !strings.EqualFold(sum, t.Sha256) {
+		return "", fmt.Errorf("template '%s': checksum mismatch, expected %s, got %s", t.Name, t.Sha256, sum)
+	}
+	if err := checkTemplateCompat(t.Name, string(data)); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// loadCommunityTemplates returns installed community templates
+func loadCommunityTemplates() []CommunityTemplate {
+	var installed []CommunityTemplate
+	data, err := os.ReadFile(communityTemplatesFile())
+	if err != nil {
+		return installed
+	}
+	if err := json.Unmarshal(data, &installed); err != nil {
+		log.Warnf("Failed to parse %s: %v", communityTemplatesFile(), err)
+	}
+	return installed
+}
+
+func saveCommunityTemplates(installed []CommunityTemplate) error {
+	if installed == nil {
+		installed = []CommunityTemplate{}
+	}
+	data, err := json.MarshalIndent(installed, "", "  ")
+	if err != nil {
+		return err
+	}
+	makeDir(configDir())
+	return os.WriteFile(communityTemplatesFile(), data, 0644)
+}
+
+// registerCommunityTemplates adds installed community templates to color targets
+func registerCommunityTemplates() {
+	for _, t := range loadCommunityTemplates() {
+		if err := t.validate(); err != nil {
+			log.Warnf("Community template ignored: %v", err)
+			continue
+		}
+		registerCommunityTarget(t)
+	}
+}
+
+func registerCommunityTarget(t CommunityTemplate) {
+	registerTarget(t.Name, t.App, t.Dest)
+}
+
+// registerTarget adds a template without built-in content to the color targets, or moves an existing one
+func registerTarget(name, app, dest string) {
+	for i, target := range colorTargets {
+		if target.template == name {
+			colorTargets[i].app, colorTargets[i].dest = app, dest
+			return
+		}
+	}
+	colorTargets = append(colorTargets, colorTarget{template: name, app: app, dest: dest})
+}
+
+// installCommunityTemplate downloads and installs (or updates) a template, and enables its application.
+// It registers the template in colorTargets: in the GUI, download first and install from the main loop.
+func (csm *ColorSyncManager) installCommunityTemplate(t CommunityTemplate) error {
+	content, err := downloadTemplate(t)
+	if err != nil {
+		return err
+	}
+	return csm.installTemplateContent(t, content)
+}
+
+// installTemplateContent writes the template, lists it as installed and enables its application
+func (csm *ColorSyncManager) installTemplateContent(t CommunityTemplate, content string) error {
+	if err := os.WriteFile(communityTemplatePath(t.Name), []byte(content), 0644); err != nil {
+		return err
+	}
+
+	installed := loadCommunityTemplates()
+	replaced := false
+	for i := range installed {
+		if installed[i].Name == t.Name {
+			installed[i], replaced = t, true
+		}
+	}
+	if !replaced {
+		installed = append(installed, t)
+	}
+	if err := saveCommunityTemplates(installed); err != nil {
+		return err
+	}
+
+	registerCommunityTarget(t)
+	if !replaced {
+		csm.SetAppEnabled(t.App, true)
+	}
+	log.Infof("✓ Installed template %s %s for %s", t.Name, t.Version, t.App)
+	return nil
+}
+
+// removeCommunityTemplate uninstalls a community template; files already generated from it are left
+func (csm *ColorSyncManager) removeCommunityTemplate(name string) error {
+	installed := loadCommunityTemplates()
+	var kept []CommunityTemplate
+	for _, t := range installed {
+		if t.Name != name {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == len(installed) {
+		return fmt.Errorf("no community template named '%s' installed", name)
+	}
+	if err := saveCommunityTemplates(kept); err != nil {
+		return err
+	}
+	if err := os.Remove(communityTemplatePath(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for i, target := range colorTargets {
+		if target.template == name {
+			colorTargets = append(colorTargets[:i], colorTargets[i+1:]...)
+			break
+		}
+	}
+	log.Infof("Removed template %s", name)
+	return nil
+}
+
+// TemplateStatus tells how an index entry relates to the installed templates
+type TemplateStatus struct {
+	CommunityTemplate
+	Installed       string `json:"installed,omitempty"` // installed version
+	UpdateAvailable bool   `json:"update-available"`
+	ModifiedLocally bool   `json:"modified-locally"`
+}
+
+// templateStatuses compares the index with the installed templates
+func templateStatuses(index []CommunityTemplate) []TemplateStatus {
+	installed := make(map[string]CommunityTemplate)
+	for _, t := range loadCommunityTemplates() {
+		installed[t.Name] = t
+	}
+	var statuses []TemplateStatus
+	for _, t := range index {
+		s := TemplateStatus{CommunityTemplate: t}
+		if local, ok := installed[t.Name]; ok {
+			s.Installed = local.Version
+			s.UpdateAvailable = !strings.EqualFold(local.Sha256, t.Sha256)
+			if data, err := os.ReadFile(communityTemplatePath(t.Name)); err == nil {
+				s.ModifiedLocally = !strings.EqualFold(sha256Hex(data), local.Sha256)
+			}
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// updateCommunityTemplates installs new versions of installed templates; locally edited ones are skipped
+func (csm *ColorSyncManager) updateCommunityTemplates(index []CommunityTemplate, only string) ([]string, error) {
+	var updated []string
+	for _, s := range templateStatuses(index) {
+		if s.Installed == "" || !s.UpdateAvailable || only != "" && s.Name != only {
+			continue
+		}
+		if s.ModifiedLocally {
+			log.Warnf("Not updating %s: edited locally. Remove it and install again to discard your changes.", s.Name)
+			continue
+		}
+		if err := csm.installCommunityTemplate(s.CommunityTemplate); err != nil {
+			return updated, err
+		}
+		updated = append(updated, s.Name)
+	}
+	return updated, nil
+}
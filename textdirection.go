@@ -0,0 +1,42 @@
+// textdirection.go
+//go:build !headless
+
+package main
+
+// #cgo pkg-config: gtk+-3.0
+// #include <gtk/gtk.h>
+import "C"
+
+import (
+	"strings"
+
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// rtlLanguages are written right to left; our translations may be chosen in nwg-shell settings
+// rather than by $LANG, so GTK can't always tell the direction from the locale
+var rtlLanguages = []string{"ar", "ckb", "dv", "fa", "he", "ps", "sd", "ug", "ur", "yi"}
+
+func isRTLLanguage(lang string) bool {
+	code := strings.SplitN(strings.SplitN(lang, "_", 2)[0], "-", 2)[0]
+	return isIn(rtlLanguages, code)
+}
+
+// setTextDirection sets the direction of all widgets that don't have one of their own;
+// existing windows are mirrored on the fly
+func setTextDirection(dir gtk.TextDirection) {
+	C.gtk_widget_set_default_direction(C.GtkTextDirection(dir))
+}
+
+func textDirection() gtk.TextDirection {
+	return gtk.TextDirection(C.gtk_widget_get_default_direction())
+}
+
+// toggleTextDirection switches between left-to-right and right-to-left, for testing layouts
+func toggleTextDirection() {
+	if textDirection() == gtk.TEXT_DIR_RTL {
+		setTextDirection(gtk.TEXT_DIR_LTR)
+	} else {
+		setTextDirection(gtk.TEXT_DIR_RTL)
+	}
+}
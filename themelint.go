@@ -0,0 +1,216 @@
+// themelint.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Minimum WCAG contrast ratios checked by the linter: AA for text, and for UI components such as the accent
+const (
+	minTextContrast   = 4.5
+	minAccentContrast = 3.0
+)
+
+// LintIssue is a problem found in a theme under development
+type LintIssue struct {
+	Severity string `json:"severity"` // "error" or "warning"
+	Check    string `json:"check"`
+	Name     string `json:"name,omitempty"` // color name or palette slot
+	Message  string `json:"message"`
+}
+
+// ThemeLintReport is the result of `nwg-look theme lint`
+type ThemeLintReport struct {
+	Theme   string        `json:"theme"`
+	Path    string        `json:"path"`
+	Palette *ColorPalette `json:"palette,omitempty"`
+	Issues  []LintIssue   `json:"issues"`
+}
+
+func (r *ThemeLintReport) add(severity, check, name, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, LintIssue{severity, check, name, fmt.Sprintf(format, args...)})
+}
+
+// errors counts issues of the "error" severity
+func (r *ThemeLintReport) errors() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == "error" {
+			n++
+		}
+	}
+	return n
+}
+
+// textContrastPairs are GTK foreground / background colors meant to be used together
+var textContrastPairs = [][2]string{
+	{"theme_fg_color", "theme_bg_color"},
+	{"theme_text_color", "theme_base_color"},
+	{"theme_selected_fg_color", "theme_selected_bg_color"},
+	{"window_fg_color", "window_bg_color"},
+	{"view_fg_color", "view_bg_color"},
+	{"accent_fg_color", "accent_bg_color"},
+}
+
+// themeDir returns the root directory of a theme, given either its name or a path to a theme being developed
+func themeDir(theme string) (string, error) {
+	if strings.Contains(theme, "/") || pathExists(filepath.Join(theme, "gtk-3.0")) {
+		if !pathExists(filepath.Join(theme, "gtk-3.0")) {
+			return "", fmt.Errorf("%s: no gtk-3.0 directory", theme)
+		}
+		return filepath.Clean(theme), nil
+	}
+	path := NewColorExtractor().FindThemePath(theme)
+	if path == "" {
+		return "", fmt.Errorf("theme %s not found", theme)
+	}
+	return filepath.Dir(path), nil
+}
+
+// anyDefineColor matches every @define-color, including references and expressions extraction leaves out
+var anyDefineColor = regexp.MustCompile(`@define-color\s+(\w+)\s+([^;]+);`)
+
+// readThemeColors returns the colors of <dir>/<gtkDir>/gtk.css as extraction sees them, and all definitions
+// with references resolved, reporting the ones that can't be
+func readThemeColors(report *ThemeLintReport, dir, gtkDir string) (map[string]string, map[string]string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, gtkDir, "gtk.css"))
+	if err != nil {
+		return nil, nil, err
+	}
+	colors := (&ColorExtractor{}).resolveColorReferences(parseThemeColors(string(content)))
+
+	defined := make(map[string]string)
+	for _, match := range anyDefineColor.FindAllStringSubmatch(string(content), -1) {
+		defined[match[1]] = strings.TrimSpace(match[2])
+	}
+	ce := &ColorExtractor{}
+	defined = ce.resolveColorReferences(defined)
+	for _, name := range ce.Report().Unresolved {
+		report.add("error", "unresolved", name, "%s: @%s refers to an undefined color", gtkDir, name)
+	}
+	for _, name := range ce.Report().Cyclic {
+		report.add("error", "cyclic", name, "%s: @%s takes part in a reference cycle", gtkDir, name)
+	}
+	return colors, defined, nil
+}
+
+// lintTheme checks a theme for what color sync relies on: the colors looked up by the color mapping rules,
+// references that can't be resolved, readable contrast, and gtk-3.0 and gtk-4.0 colors that disagree
+func lintTheme(theme string) (*ThemeLintReport, error) {
+	dir, err := themeDir(theme)
+	if err != nil {
+		return nil, err
+	}
+	report := &ThemeLintReport{Theme: filepath.Base(dir), Path: dir, Issues: []LintIssue{}}
+
+	colors, defined, err := readThemeColors(report, dir, "gtk-3.0")
+	if err != nil {
+		return nil, err
+	}
+
+	ce := NewColorExtractor()
+	for _, rule := range ce.rules {
+		if _, ok := rule.lookup(colors); !ok {
+			report.add("warning", "missing-color", rule.Slot, "no color for the %s slot, define one of: %s",
+				rule.Slot, strings.Join(rule.Sources, ", "))
+		}
+		for _, source := range rule.Sources {
+			// unresolved references are reported already
+			value := defined[source]
+			if value == "" || strings.Contains(value, "@") {
+				continue
+			}
+			if _, _, _, err := hexToRGB(ce.normalizeColor(value)); err != nil {
+				report.add("warning", "unsupported-value", source, "@%s is '%s', extraction only reads hex and rgb() colors",
+					source, value)
+			}
+		}
+	}
+
+	report.Palette = ce.generateStandardPalette(colors)
+	lintContrast(report, colors)
+	lintGtk4(report, ce.rules, dir, defined)
+	return report, nil
+}
+
+// lintContrast checks the extracted palette, and foreground / background pairs the theme defines
+func lintContrast(report *ThemeLintReport, colors map[string]string) {
+	p := report.Palette
+	if ratio := contrastRatio(p.Foreground, p.Background); ratio < minTextContrast {
+		report.add("warning", "contrast", "foreground", "foreground %s on background %s: contrast %.2f, at least %.1f needed",
+			p.Foreground, p.Background, ratio, minTextContrast)
+	}
+	if accent := p.Colors["color4"]; contrastRatio(accent, p.Background) < minAccentContrast {
+		report.add("warning", "contrast", "color4", "accent %s on background %s: contrast %.2f, at least %.1f needed",
+			accent, p.Background, contrastRatio(accent, p.Background), minAccentContrast)
+	}
+
+	ce := &ColorExtractor{}
+	for _, pair := range textContrastPairs {
+		fg, okFg := colors[pair[0]]
+		bg, okBg := colors[pair[1]]
+		if !okFg || !okBg {
+			continue
+		}
+		fg, bg = ce.normalizeColor(fg), ce.normalizeColor(bg)
+		if _, _, _, err := hexToRGB(fg); err != nil {
+			continue
+		}
+		if _, _, _, err := hexToRGB(bg); err != nil {
+			continue
+		}
+		if ratio := contrastRatio(fg, bg); ratio < minTextContrast {
+			report.add("warning", "contrast", pair[0], "@%s on @%s: contrast %.2f, at least %.1f needed",
+				pair[0], pair[1], ratio, minTextContrast)
+		}
+	}
+}
+
+// lintGtk4 compares colors defined for both GTK versions, so that GTK 4 applications don't look different
+func lintGtk4(report *ThemeLintReport, rules []ColorMappingRule, dir string, gtk3 map[string]string) {
+	if !pathExists(filepath.Join(dir, "gtk-4.0", "gtk.css")) {
+		report.add("warning", "gtk4-missing", "", "no gtk-4.0/gtk.css, GTK 4 applications will use their default theme")
+		return
+	}
+	_, gtk4, err := readThemeColors(report, dir, "gtk-4.0")
+	if err != nil {
+		report.add("error", "gtk4-missing", "", "%v", err)
+		return
+	}
+
+	ce := &ColorExtractor{}
+	for _, name := range sortedColorNames(gtk3) {
+		value4, ok := gtk4[name]
+		if !ok {
+			continue
+		}
+		c3, c4 := ce.normalizeColor(gtk3[name]), ce.normalizeColor(value4)
+		if !strings.EqualFold(c3, c4) {
+			report.add("warning", "gtk4-differs", name, "@%s is %s in gtk-3.0, %s in gtk-4.0", name, c3, c4)
+		}
+	}
+	// names the color mapping looks up, defined for one GTK version only
+	reported := make(map[string]bool)
+	for _, rule := range rules {
+		for _, source := range rule.Sources {
+			_, in3 := gtk3[source]
+			_, in4 := gtk4[source]
+			if in3 && !in4 && !reported[source] {
+				report.add("warning", "gtk4-undefined", source, "@%s is defined in gtk-3.0, but not in gtk-4.0", source)
+				reported[source] = true
+			}
+		}
+	}
+}
+
+func sortedColorNames(colors map[string]string) []string {
+	names := make(map[string]bool)
+	for name := range colors {
+		names[name] = true
+	}
+	return sortedKeys(names)
+}
@@ -13,7 +13,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/gotk3/gotk3/gtk"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -25,13 +24,52 @@ func configHome() string {
 	return filepath.Join(os.Getenv("HOME"), ".config/")
 }
 
+func cacheHome() string {
+	xdgCacheHome := os.Getenv("XDG_CACHE_HOME")
+	if xdgCacheHome != "" {
+		return xdgCacheHome
+	}
+	return filepath.Join(os.Getenv("HOME"), ".cache")
+}
+
+// overrides of nwg-look's own directories, set with command line flags
+var configDirOverride, stateDirOverride, cacheDirOverride string
+
+// nwgDir returns the flag override, the env variable value or the default, in this order
+func nwgDir(override, envVar, defaultDir string) string {
+	if override != "" {
+		return override
+	}
+	if dir := os.Getenv(envVar); dir != "" {
+		return dir
+	}
+	return defaultDir
+}
+
+// configDir holds preferences, color sync settings, templates and profiles
+func configDir() string {
+	return nwgDir(configDirOverride, "NWG_LOOK_CONFIG_DIR", filepath.Join(configHome(), "nwg-look"))
+}
+
+// stateDir holds the gsettings backup
+func stateDir() string {
+	return nwgDir(stateDirOverride, "NWG_LOOK_STATE_DIR", filepath.Join(dataHome(), "nwg-look"))
+}
+
+// cacheDir holds data that may be safely deleted
+func cacheDir() string {
+	return nwgDir(cacheDirOverride, "NWG_LOOK_CACHE_DIR", filepath.Join(cacheHome(), "nwg-look"))
+}
+
 func loadPreferences() {
-	cH := configHome()
-	preferencesFile := filepath.Join(cH, "/nwg-look/config")
+	preferencesFile := filepath.Join(configDir(), "config")
 	if !pathExists(preferencesFile) {
-		log.Infof("%s file not found, creating", preferencesFile)
-		makeDir(filepath.Join(cH, "/nwg-look/"))
 		preferences = programSettingsNewWithDefaults()
+		if readOnly {
+			return
+		}
+		log.Infof("%s file not found, creating", preferencesFile)
+		makeDir(configDir())
 		savePreferences()
 	} else {
 		file, err := os.Open(preferencesFile)
@@ -50,7 +88,7 @@ func loadPreferences() {
 }
 
 func savePreferences() {
-	preferencesFile := filepath.Join(configHome(), "/nwg-look/config")
+	preferencesFile := filepath.Join(configDir(), "config")
 	jsonData, err := json.MarshalIndent(preferences, "", " ")
 	if err != nil {
 		log.Warn(err)
@@ -124,6 +162,8 @@ func loadGtkConfig() {
 					gtkConfig.xftRgba = value
 				case "gtk-application-prefer-dark-theme":
 					gtkConfig.applicationPreferDarkTheme = value == "1"
+				case "gtk-enable-animations":
+					gtkConfig.enableAnimations = value != "0"
 				default:
 					log.Warnf("Unsupported config key: %s", key)
 				}
@@ -207,7 +247,7 @@ func readGsettings() {
 			log.Infof("cursor-size: %v", gsettings.cursorSize)
 		}
 	} else {
-		log.Warnf("Couldn't read cursorSize, leaving default %s",
+		log.Warnf("Couldn't read cursorSize, leaving default %v",
 			gsettings.cursorSize)
 	}
 
@@ -264,7 +304,7 @@ func readGsettings() {
 			log.Infof("text-scaling-factor: %v", gsettings.textScalingFactor)
 		}
 	} else {
-		log.Warnf("Couldn't read textScalingFactor, leaving default %s",
+		log.Warnf("Couldn't read textScalingFactor, leaving default %v",
 			gsettings.textScalingFactor)
 	}
 
@@ -277,6 +317,15 @@ func readGsettings() {
 			gsettings.colorScheme)
 	}
 
+	val, err = getGsettingsValue("org.gnome.desktop.interface", "enable-animations")
+	if err == nil {
+		gsettings.enableAnimations = val == "true"
+		log.Infof("enable-animations: %v", gsettings.enableAnimations)
+	} else {
+		log.Warnf("Couldn't read enable-animations, leaving default %v",
+			gsettings.enableAnimations)
+	}
+
 	val, err = getGsettingsValue("org.gnome.desktop.sound", "event-sounds")
 	if err == nil {
 		if val == "true" {
@@ -305,7 +354,7 @@ func readGsettings() {
 }
 
 func saveGsettingsBackup() {
-	gsettingsFile := filepath.Join(dataHome(), "nwg-look/")
+	gsettingsFile := stateDir()
 	makeDir(gsettingsFile)
 	log.Infof(">>> Backing up gsettings to %s", gsettingsFile)
 
@@ -323,13 +372,14 @@ func saveGsettingsBackup() {
 		"font-antialiasing",
 		"font-rgba-order",
 		"text-scaling-factor",
-		"color-scheme"} {
+		"color-scheme",
+		"enable-animations"} {
 		val, err := getGsettingsValue("org.gnome.desktop.interface", key)
 		if err == nil {
 			line := fmt.Sprintf("%s=%s", key, val)
 			lines = append(lines, line)
 		} else {
-			log.Warnf("Couldn't get gsettings key: $s", key)
+			log.Warnf("Couldn't get gsettings key: %s", key)
 		}
 	}
 	for _, key := range []string{"event-sounds", "input-feedback-sounds"} {
@@ -338,11 +388,11 @@ func saveGsettingsBackup() {
 			line := fmt.Sprintf("%s=%s", key, val)
 			lines = append(lines, line)
 		} else {
-			log.Warnf("Couldn't get gsettings key: $s", key)
+			log.Warnf("Couldn't get gsettings key: %s", key)
 		}
 	}
 
-	saveTextFile(lines, filepath.Join(dataHome(), "nwg-look/gsettings"))
+	saveTextFile(lines, filepath.Join(stateDir(), "gsettings"))
 }
 
 func getGsettingsValue(schema, key string) (string, error) {
@@ -433,7 +483,7 @@ func applyGsettings() {
 	cmd = exec.Command("gsettings", "set", gnomeSchema, "text-scaling-factor", fmt.Sprintf("%f", gsettings.textScalingFactor))
 	err = cmd.Run()
 	if err != nil {
-		log.Warnf("text-scaling-factor: %s %s", gsettings.textScalingFactor, err)
+		log.Warnf("text-scaling-factor: %v %s", gsettings.textScalingFactor, err)
 	} else {
 		log.Infof("text-scaling-factor: %v OK", gsettings.textScalingFactor)
 	}
@@ -462,6 +512,15 @@ func applyGsettings() {
 		log.Infof("color-scheme: %s OK", gsettings.colorScheme)
 	}
 
+	val = fmt.Sprintf("%v", gsettings.enableAnimations)
+	cmd = exec.Command("gsettings", "set", gnomeSchema, "enable-animations", val)
+	err = cmd.Run()
+	if err != nil {
+		log.Warnf("enable-animations: %s %s", val, err)
+	} else {
+		log.Infof("enable-animations: %s OK", val)
+	}
+
 	gnomeSchema = "org.gnome.desktop.sound"
 	log.Infof(">> %s", gnomeSchema)
 
@@ -493,7 +552,7 @@ func applyGsettings() {
 }
 
 func applyGsettingsFromFile() {
-	gsettingsFile := filepath.Join(dataHome(), "nwg-look/gsettings")
+	gsettingsFile := filepath.Join(stateDir(), "gsettings")
 	if pathExists(gsettingsFile) {
 		log.Infof("Loading gsettings from %s", gsettingsFile)
 		lines, err := loadTextFile(gsettingsFile)
@@ -543,6 +602,8 @@ func applyGsettingsFromFile() {
 						gsettings.inputFeedbackSounds = value == "true"
 					case "color-scheme":
 						gsettings.colorScheme = value
+					case "enable-animations":
+						gsettings.enableAnimations = value == "true"
 					}
 				}
 			}
@@ -636,6 +697,13 @@ func saveGtkIni3() {
 	}
 	lines = append(lines, fmt.Sprintf("gtk-application-prefer-dark-theme=%v", v))
 
+	if gsettings.enableAnimations {
+		v = 1
+	} else {
+		v = 0
+	}
+	lines = append(lines, fmt.Sprintf("gtk-enable-animations=%v", v))
+
 	// append unsupported lines / comments from the original settings.ini file
 	for _, l := range originalGtkConfig {
 		if l != "" && !isSupported(l) {
@@ -673,6 +741,13 @@ func saveGtkIni4() {
 	}
 	lines = append(lines, fmt.Sprintf("gtk-application-prefer-dark-theme=%v", v))
 
+	if gsettings.enableAnimations {
+		v = 1
+	} else {
+		v = 0
+	}
+	lines = append(lines, fmt.Sprintf("gtk-enable-animations=%v", v))
+
 	for _, l := range lines {
 		log.Debug(l)
 	}
@@ -698,6 +773,7 @@ func isSupported(line string) bool {
 		"gtk-xft-hintstyle",
 		"gtk-xft-rgba",
 		"gtk-application-prefer-dark-theme",
+		"gtk-enable-animations",
 	}
 	for _, d := range supported {
 		if strings.HasPrefix(line, d) {
@@ -829,6 +905,13 @@ func saveXsettingsd() {
 	}
 	lines = append(lines, fmt.Sprintf("EnableInputFeedbackSounds %v", v))
 
+	if gsettings.enableAnimations {
+		v = 1
+	} else {
+		v = 0
+	}
+	lines = append(lines, fmt.Sprintf("Gtk/EnableAnimations %v", v))
+
 	if gsettings.fontAntialiasing != "none" {
 		v = 1
 	} else {
@@ -885,6 +968,8 @@ func linkGtk4Stuff() {
 			return
 		}
 
+		// the palette block written over the gtk.css symlink goes with it, put it back over the new one
+		colorBlock := managedCssBlock(filepath.Join(configPath, "gtk-4.0/gtk.css"))
 		clearGtk4Symlinks()
 
 		// Create symlinks
@@ -933,6 +1018,13 @@ func linkGtk4Stuff() {
 			}
 		}
 
+		if colorBlock != nil {
+			cssFile := filepath.Join(configPath, "gtk-4.0/gtk.css")
+			if err := writeGtkCss(cssFile, colorBlock, nil); err != nil {
+				log.Warnf("Couldn't restore GTK named colors in %s: %s", cssFile, err)
+			}
+		}
+
 	} else {
 		log.Warnf("GTK theme name unknown")
 	}
@@ -1292,105 +1384,6 @@ func makeDir(dir string) {
 	}
 }
 
-// Assert types to gtk.Builder objects
-func getWindow(b *gtk.Builder, id string) (*gtk.Window, error) {
-	obj, err := b.GetObject(id)
-	if err != nil {
-		return nil, err
-	}
-
-	window, ok := obj.(*gtk.Window)
-	if !ok {
-		return nil, err
-	}
-	return window, nil
-}
-
-func getScrolledWindow(b *gtk.Builder, id string) (*gtk.ScrolledWindow, error) {
-	obj, err := b.GetObject(id)
-	if err != nil {
-		return nil, err
-	}
-
-	window, ok := obj.(*gtk.ScrolledWindow)
-	if !ok {
-		return nil, err
-	}
-	return window, nil
-}
-
-func getViewPort(b *gtk.Builder, id string) (*gtk.Viewport, error) {
-	obj, err := b.GetObject(id)
-	if err != nil {
-		return nil, err
-	}
-	viewport, ok := obj.(*gtk.Viewport)
-	if !ok {
-		return nil, err
-	}
-	return viewport, nil
-}
-
-func getButton(b *gtk.Builder, id string) (*gtk.Button, error) {
-	obj, err := b.GetObject(id)
-	if err != nil {
-		return nil, err
-	}
-	btn, ok := obj.(*gtk.Button)
-	if !ok {
-		return nil, err
-	}
-	return btn, nil
-}
-
-func getGrid(b *gtk.Builder, id string) (*gtk.Grid, error) {
-	obj, err := b.GetObject(id)
-	if err != nil {
-		return nil, err
-	}
-	grid, ok := obj.(*gtk.Grid)
-	if !ok {
-		return nil, err
-	}
-	return grid, nil
-}
-
-func getLabel(b *gtk.Builder, id string) (*gtk.Label, error) {
-	obj, err := b.GetObject(id)
-	if err != nil {
-		return nil, err
-	}
-	label, ok := obj.(*gtk.Label)
-	if !ok {
-		return nil, err
-	}
-	return label, nil
-}
-
-func getMenuBar(b *gtk.Builder, id string) (*gtk.MenuBar, error) {
-	obj, err := b.GetObject(id)
-	if err != nil {
-		return nil, err
-	}
-	menuBar, ok := obj.(*gtk.MenuBar)
-	if !ok {
-		return nil, err
-	}
-	return menuBar, nil
-}
-
-func getMenuItem(b *gtk.Builder, id string) (*gtk.MenuItem, error) {
-	obj, err := b.GetObject(id)
-	if err != nil {
-		return nil, err
-	}
-	item, ok := obj.(*gtk.MenuItem)
-	if !ok {
-		return nil, err
-	}
-	return item, nil
-}
-
 func detectLang() string {
 	lang := ""
 	shellDataFile := filepath.Join(dataHome(), "/nwg-shell/data")
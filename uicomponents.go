@@ -1,3 +1,5 @@
+//go:build !headless
+
 package main
 
 import (
@@ -7,8 +9,10 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
 	log "github.com/sirupsen/logrus"
 )
@@ -255,6 +259,12 @@ func setUpThemeSettingsForm(defaultFontName string) *gtk.Grid {
 	})
 	grid.Attach(fontButton, 1, 0, 1, 1)
 
+	installButton, _ := gtk.ButtonNewWithLabel("Install font…")
+	installButton.SetProperty("valign", gtk.ALIGN_CENTER)
+	installButton.SetTooltipText("Install TTF/OTF files or font archives into ~/.local/share/fonts")
+	installButton.Connect("clicked", onInstallFontClicked)
+	grid.Attach(installButton, 2, 0, 1, 1)
+
 	label, _ = gtk.LabelNew(fmt.Sprintf("%s:", voc["color-scheme"]))
 	label.SetProperty("halign", gtk.ALIGN_END)
 	grid.Attach(label, 0, 1, 1, 1)
@@ -281,6 +291,121 @@ func setUpThemeSettingsForm(defaultFontName string) *gtk.Grid {
 	return grid
 }
 
+func onInstallFontClicked() {
+	dialog, err := gtk.FileChooserDialogNewWith2Buttons("Install font", nil, gtk.FILE_CHOOSER_ACTION_OPEN,
+		"Cancel", gtk.RESPONSE_CANCEL, "Install", gtk.RESPONSE_ACCEPT)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+	filter, _ := gtk.FileFilterNew()
+	filter.SetName("Fonts and archives")
+	for _, pattern := range []string{"*.ttf", "*.otf", "*.ttc", "*.otc", "*.woff", "*.woff2", "*.zip",
+		"*.tar", "*.tar.gz", "*.tgz", "*.tar.bz2", "*.tbz2"} {
+		filter.AddPattern(pattern)
+		filter.AddPattern(strings.ToUpper(pattern))
+	}
+	dialog.AddFilter(filter)
+	path := ""
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		path = dialog.GetFilename()
+	}
+	dialog.Destroy()
+	if path == "" {
+		return
+	}
+
+	go func() {
+		createRestorePoint(fmt.Sprintf("before installing %s", filepath.Base(path)))
+		dir, files, err := installFont(path)
+		if err != nil {
+			log.Warnf("Font installation failed: %v", err)
+			glib.IdleAdd(func() {
+				showMessage(gtk.MESSAGE_ERROR, fmt.Sprintf("Font installation failed: %v", err))
+			})
+			return
+		}
+		afterInstall(assetFont, dir)
+		families := fontFamilies(files)
+		glib.IdleAdd(func() {
+			offerInstalledFont(families)
+		})
+	}()
+}
+
+// offerInstalledFont asks whether to use a freshly installed family as the default font
+func offerInstalledFont(families []string) {
+	if len(families) == 0 {
+		showMessage(gtk.MESSAGE_INFO, "Font installed")
+		return
+	}
+	dialog := gtk.MessageDialogNew(nil, gtk.DIALOG_MODAL, gtk.MESSAGE_QUESTION, gtk.BUTTONS_YES_NO,
+		"Installed: %s\n\nUse '%s' as the default font?", strings.Join(families, ", "), families[0])
+	response := dialog.Run()
+	dialog.Destroy()
+	if response == gtk.RESPONSE_YES {
+		size := "10"
+		if parts := strings.Fields(gsettings.fontName); len(parts) > 1 {
+			size = parts[len(parts)-1]
+		}
+		gsettings.fontName = fmt.Sprintf("%s %s", families[0], size)
+		gtkSettings.SetProperty("gtk-font-name", gsettings.fontName)
+		displayThemes()
+	}
+}
+
+// offerAppearanceImport asks, on the first run, whether to start from the colors and font already in use
+func offerAppearanceImport(a *DetectedAppearance) {
+	text := fmt.Sprintf("Colors found in your %s configuration.", strings.Join(a.Sources, ", "))
+	if a.FontName != "" {
+		text += fmt.Sprintf("\nFont: %s", a.FontName)
+	}
+	dialog := gtk.MessageDialogNew(nil, gtk.DIALOG_MODAL, gtk.MESSAGE_QUESTION, gtk.BUTTONS_YES_NO,
+		"%s\n\nImport them as the initial palette and the 'imported' profile?", text)
+	response := dialog.Run()
+	dialog.Destroy()
+	if response != gtk.RESPONSE_YES {
+		return
+	}
+	// off the main loop, which the consent and conflict dialogs need
+	go func() {
+		err := importAppearance(a)
+		glib.IdleAdd(func() {
+			if err != nil {
+				showMessage(gtk.MESSAGE_ERROR, err.Error())
+				return
+			}
+			gtkSettings.SetProperty("gtk-font-name", gsettings.fontName)
+			displayThemes()
+		})
+	}()
+}
+
+// offerPywalPalette asks whether to adopt the palette generated by a `wal` run made since the last color sync
+func offerPywalPalette(palette *ColorPalette, modified time.Time) {
+	colorSyncManager.markPywalOffered(modified)
+	dialog := gtk.MessageDialogNew(nil, gtk.DIALOG_MODAL, gtk.MESSAGE_QUESTION, gtk.BUTTONS_YES_NO,
+		"pywal generated a new palette on %s.\n\nApply it to color sync targets?", modified.Format("2006-01-02 15:04"))
+	response := dialog.Run()
+	dialog.Destroy()
+	if response != gtk.RESPONSE_YES {
+		return
+	}
+	go func() {
+		if err := colorSyncManager.ApplyPalette(palette, "pywal"); err != nil {
+			glib.IdleAdd(func() {
+				showMessage(gtk.MESSAGE_ERROR, err.Error())
+			})
+		}
+	}()
+}
+
+func showMessage(messageType gtk.MessageType, text string) {
+	dialog := gtk.MessageDialogNew(nil, gtk.DIALOG_MODAL, messageType, gtk.BUTTONS_OK, "%s", text)
+	dialog.Run()
+	dialog.Destroy()
+}
+
 func setUpIconsPreview() *gtk.Frame {
 	frame, _ := gtk.FrameNew(fmt.Sprintf("  %s  ", voc["icon-theme-preview"]))
 	frame.SetLabelAlign(0.5, 0.5)
@@ -627,6 +752,28 @@ func setUpOtherSettingsForm() *gtk.Frame {
 	})
 	g.Attach(cbInputSounds, 0, 7, 2, 1)
 
+	lbl, _ = gtk.LabelNew("")
+	lbl.SetMarkup(fmt.Sprintf("<b>%s</b>", voc["accessibility"]))
+	lbl.SetProperty("halign", gtk.ALIGN_START)
+	g.Attach(lbl, 0, 8, 1, 1)
+
+	cbReduceMotion, _ := gtk.CheckButtonNewWithLabel(voc["reduce-motion"])
+	cbReduceMotion.SetTooltipText(voc["reduce-motion-tooltip"])
+	cbReduceMotion.SetActive(!gsettings.enableAnimations)
+	cbReduceMotion.Connect("toggled", func() {
+		gsettings.enableAnimations = !cbReduceMotion.GetActive()
+		gtkConfig.enableAnimations = gsettings.enableAnimations
+	})
+	g.Attach(cbReduceMotion, 0, 9, 2, 1)
+
+	cbReduceTransparency, _ := gtk.CheckButtonNewWithLabel(voc["reduce-transparency"])
+	cbReduceTransparency.SetTooltipText(voc["reduce-transparency-tooltip"])
+	cbReduceTransparency.SetActive(preferences.ReduceTransparency)
+	cbReduceTransparency.Connect("toggled", func() {
+		preferences.ReduceTransparency = cbReduceTransparency.GetActive()
+	})
+	g.Attach(cbReduceTransparency, 0, 10, 2, 1)
+
 	return frame
 }
 
@@ -688,5 +835,136 @@ func setUpProgramSettingsForm() *gtk.Frame {
 	btn.SetTooltipText(voc["clear-gtk4-tooltip"])
 	g.Attach(btn, 1, 5, 1, 1)
 
+	g.Attach(setUpInsightsFrame(), 0, 6, 2, 1)
+
+	restoreBtn, _ := gtk.ButtonNewWithLabel("Restore points…")
+	restoreBtn.SetTooltipText("Settings saved automatically before applying profiles and installing fonts")
+	restoreBtn.SetProperty("halign", gtk.ALIGN_START)
+	restoreBtn.Connect("clicked", showRestorePointsDialog)
+	g.Attach(restoreBtn, 0, 7, 1, 1)
+
+	return frame
+}
+
+// setUpHealthBanner runs the health check in the background, and reveals the banner if anything is wrong
+func setUpHealthBanner() *gtk.InfoBar {
+	banner, _ := gtk.InfoBarNew()
+	banner.SetMessageType(gtk.MESSAGE_WARNING)
+	banner.SetShowCloseButton(true)
+	banner.SetNoShowAll(true)
+	banner.Connect("response", func() {
+		banner.Hide()
+	})
+	content, _ := banner.GetContentArea()
+	label, _ := gtk.LabelNew("")
+	label.SetLineWrap(true)
+	label.SetProperty("halign", gtk.ALIGN_START)
+	content.PackStart(label, true, true, 0)
+
+	go func() {
+		problems := healthCheck()
+		if len(problems) == 0 {
+			return
+		}
+		var lines []string
+		for _, p := range problems {
+			lines = append(lines, fmt.Sprintf("• <b>%s</b>: %s", glib.MarkupEscapeText(p.problem), glib.MarkupEscapeText(p.hint)))
+		}
+		glib.IdleAdd(func() {
+			label.SetMarkup("Applying settings may have no effect:\n" + strings.Join(lines, "\n"))
+			label.Show()
+			banner.Show()
+		})
+	}()
+	return banner
+}
+
+// setUpInsightsFrame shows statistics computed from the local history
+func setUpInsightsFrame() *gtk.Frame {
+	frame, _ := gtk.FrameNew("Usage insights")
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 6)
+	box.SetProperty("margin", 6)
+	frame.Add(box)
+
+	label, _ := gtk.LabelNew("")
+	label.SetMarkup(insightsMarkup(computeInsights(loadHistory(), 5)))
+	label.SetProperty("halign", gtk.ALIGN_START)
+	box.PackStart(label, false, false, 0)
+
+	note, _ := gtk.LabelNew("")
+	note.SetMarkup(fmt.Sprintf("<small><i>Computed from %s, which is never sent anywhere</i></small>", historyFile()))
+	note.SetProperty("halign", gtk.ALIGN_START)
+	box.PackStart(note, false, false, 0)
+
+	btn, _ := gtk.ButtonNewWithLabel("Clear history")
+	btn.SetProperty("halign", gtk.ALIGN_START)
+	btn.Connect("clicked", func() {
+		if err := os.Remove(historyFile()); err != nil && !os.IsNotExist(err) {
+			log.Warn(err)
+			return
+		}
+		label.SetMarkup(insightsMarkup(Insights{}))
+	})
+	box.PackStart(btn, false, false, 0)
+
 	return frame
 }
+
+// showRestorePointsDialog lists restore points, with a button to restore each
+func showRestorePointsDialog() {
+	dialog, _ := gtk.DialogNew()
+	dialog.SetTitle("Restore points")
+	dialog.SetDefaultSize(520, 400)
+	dialog.SetModal(true)
+	dialog.AddButton(voc["close"], gtk.RESPONSE_CLOSE)
+
+	content, _ := dialog.GetContentArea()
+	scrolled, _ := gtk.ScrolledWindowNew(nil, nil)
+	scrolled.SetProperty("vexpand", true)
+	scrolled.SetProperty("margin", 6)
+	content.PackStart(scrolled, true, true, 0)
+
+	list, _ := gtk.ListBoxNew()
+	list.SetSelectionMode(gtk.SELECTION_NONE)
+	scrolled.Add(list)
+
+	points := listRestorePoints()
+	if len(points) == 0 {
+		label, _ := gtk.LabelNew("No restore points yet")
+		label.SetProperty("margin", 12)
+		list.Add(label)
+	}
+	for _, rp := range points {
+		point := rp
+		box, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 12)
+		box.SetProperty("margin", 6)
+		label, _ := gtk.LabelNew("")
+		label.SetMarkup(fmt.Sprintf("<b>%s</b>\n<small>%s · %s</small>", glib.MarkupEscapeText(point.Name),
+			point.Created.Format("2006-01-02 15:04"), glib.MarkupEscapeText(point.Settings.GtkTheme)))
+		label.SetProperty("halign", gtk.ALIGN_START)
+		box.PackStart(label, true, true, 0)
+
+		btn, _ := gtk.ButtonNewWithLabel("Restore")
+		btn.SetProperty("valign", gtk.ALIGN_CENTER)
+		btn.Connect("clicked", func() {
+			dialog.Response(gtk.RESPONSE_CLOSE)
+			go func() {
+				err := point.restore()
+				glib.IdleAdd(func() {
+					if err != nil {
+						showMessage(gtk.MESSAGE_ERROR, fmt.Sprintf("Restore failed: %v", err))
+						return
+					}
+					readGsettings()
+					displayThemes()
+				})
+			}()
+		})
+		box.PackStart(btn, false, false, 0)
+		list.Add(box)
+	}
+
+	dialog.ShowAll()
+	dialog.Run()
+	dialog.Destroy()
+}
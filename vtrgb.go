@@ -0,0 +1,99 @@
+// vtrgb.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The console palette is applied on boot by a systemd unit running setvtrgb as root, from a copy
+// of the file in /etc: the home directory may not be mounted yet.
+const (
+	vtrgbInstalled = "/etc/nwg-look/vtrgb"
+	vtrgbUnit      = "/etc/systemd/system/nwg-look-vtrgb.service"
+)
+
+const vtrgbUnitContent = `[Unit]
+Description=Linux console palette set by nwg-look
+After=systemd-vconsole-setup.service
+
+[Service]
+Type=oneshot
+ExecStart=/usr/bin/env setvtrgb ` + vtrgbInstalled + `
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// installVtrgb copies the palette to /etc, installs the systemd unit applying it on boot and runs it now,
+// authorized with polkit
+func installVtrgb() error {
+	palette := targetPath("vtrgb")
+	if !pathExists(palette) {
+		return fmt.Errorf("%s not found: enable the console target and apply colors first", palette)
+	}
+	if _, err := exec.LookPath("pkexec"); err != nil {
+		return fmt.Errorf("pkexec not found, install %s as %s and run setvtrgb manually", palette, vtrgbInstalled)
+	}
+
+	unit := filepath.Join(cacheDir(), "vtrgb", filepath.Base(vtrgbUnit))
+	makeDir(filepath.Dir(unit))
+	if err := os.WriteFile(unit, []byte(vtrgbUnitContent), 0644); err != nil {
+		return err
+	}
+	// paths are passed as arguments, not in the script, so that the shell running as root never parses them
+	script := strings.Join([]string{
+		`install -m 0644 -D "$1" "$2"`,
+		`install -m 0644 "$3" "$4"`,
+		"systemctl daemon-reload",
+		`systemctl enable "$5"`,
+		`systemctl restart "$5"`,
+	}, " && ")
+	out, err := exec.Command("pkexec", "sh", "-c", script, "sh",
+		palette, vtrgbInstalled, unit, vtrgbUnit, filepath.Base(vtrgbUnit)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("installing the console palette failed: %v %s", err, string(out))
+	}
+	log.Infof("✓ Installed the console palette into %s, applied on boot by %s", vtrgbInstalled, vtrgbUnit)
+	return nil
+}
+
+// uninstallVtrgb disables and removes the systemd unit and the installed palette. The console keeps
+// its colors until reboot.
+func uninstallVtrgb() error {
+	if !pathExists(vtrgbUnit) {
+		return fmt.Errorf("%s not installed", vtrgbUnit)
+	}
+	script := `systemctl disable "$1"; rm -f "$2" "$3" && systemctl daemon-reload`
+	out, err := exec.Command("pkexec", "sh", "-c", script, "sh",
+		filepath.Base(vtrgbUnit), vtrgbUnit, vtrgbInstalled).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("removing the console palette failed: %v %s", err, string(out))
+	}
+	log.Infof("✓ Removed %s", vtrgbUnit)
+	return nil
+}
+
+// syncVtrgb installs the console palette again after colors were applied, if the unit is installed and
+// the palette changed, so that polkit only asks then
+func syncVtrgb() {
+	if !pathExists(vtrgbUnit) {
+		return
+	}
+	written, err := os.ReadFile(targetPath("vtrgb"))
+	if err != nil {
+		return
+	}
+	if installed, err := os.ReadFile(vtrgbInstalled); err == nil && bytes.Equal(installed, written) {
+		return
+	}
+	if err := installVtrgb(); err != nil {
+		log.Warnf("Failed to update the console palette: %v", err)
+	}
+}
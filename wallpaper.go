@@ -0,0 +1,96 @@
+// wallpaper.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ProfileWallpaper is the wallpaper set along with a profile
+type ProfileWallpaper struct {
+	Path string `json:"path"`
+	// setter, arguments separated by spaces, with {path} replaced; detected if empty, see wallpaperSetter.
+	// It must return once the wallpaper is set, so e.g. swaybg needs to be started by other means.
+	Command string `json:"command,omitempty"`
+}
+
+// wallpaperSetter returns the command line setting the wallpaper, with {path} still in place
+func (w *ProfileWallpaper) wallpaperSetter() (string, error) {
+	if w.Command != "" {
+		return w.Command, nil
+	}
+	if _, err := exec.LookPath("swww"); err == nil {
+		return "swww img {path}", nil
+	}
+	if os.Getenv("SWAYSOCK") != "" {
+		return "swaymsg output * bg {path} fill", nil
+	}
+	if os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "" {
+		return "hyprctl hyprpaper reload ,{path}", nil
+	}
+	return "", fmt.Errorf("no wallpaper setter found, set \"command\" in the profile, e.g. \"swww img {path}\"")
+}
+
+// command resolves the setter into arguments; no shell is involved, so paths need no quoting
+func (w *ProfileWallpaper) command() ([]string, error) {
+	setter, err := w.wallpaperSetter()
+	if err != nil {
+		return nil, err
+	}
+	path := expandPath(w.Path)
+	args := strings.Fields(setter)
+	for i := range args {
+		args[i] = strings.ReplaceAll(args[i], "{path}", path)
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("wallpaper setter %s not found", args[0])
+	}
+	return args, nil
+}
+
+// check makes sure the wallpaper can be set, before anything else of the profile is applied
+func (w *ProfileWallpaper) check() error {
+	if w.Path == "" {
+		return fmt.Errorf("wallpaper path is empty")
+	}
+	if !pathExists(expandPath(w.Path)) {
+		return fmt.Errorf("wallpaper %s not found", w.Path)
+	}
+	_, err := w.command()
+	return err
+}
+
+func (w *ProfileWallpaper) apply() error {
+	args, err := w.command()
+	if err != nil {
+		return err
+	}
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	log.Infof("Wallpaper set: %s", w.Path)
+	return nil
+}
+
+// setProfileWallpaper adds a wallpaper to a saved profile, or removes it if the path is empty
+func setProfileWallpaper(name, path, command string) error {
+	p, err := loadProfile(name)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		p.Wallpaper = nil
+		return writeProfile(p)
+	}
+	w := &ProfileWallpaper{Path: path, Command: command}
+	if err := w.check(); err != nil {
+		return err
+	}
+	p.Wallpaper = w
+	return writeProfile(p)
+}